@@ -19,6 +19,7 @@ package nacos
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"strings"
 	"sync"
@@ -112,8 +113,8 @@ func (n *nacosServiceDiscovery) Callback(services []model2.SubscribeService, err
 
 	for i := range services {
 		service := services[i]
-		if !service.Enable {
-			// instance is not available,so ignore it
+		if !service.Enable || service.Weight <= 0 {
+			// instance is not available or nacos routes no traffic to it,so ignore it
 			continue
 		}
 
@@ -245,6 +246,7 @@ func fromInstanceToServiceInstance(serviceName string, instance model2.Instance)
 		Healthy:     instance.Healthy,
 		Enable:      instance.Enable,
 		CLusterName: instance.ClusterName,
+		Weight:      fromNacosWeight(instance.Weight),
 		Metadata:    instance.Metadata,
 	}
 }
@@ -269,6 +271,17 @@ func fromSubscribeServiceToServiceInstance(instance model2.SubscribeService) ser
 		Healthy:     true,
 		Enable:      instance.Enable,
 		CLusterName: instance.ClusterName,
+		Weight:      fromNacosWeight(instance.Weight),
 		Metadata:    instance.Metadata,
 	}
 }
+
+// fromNacosWeight nacos weight is a float like 0.5, keep two decimal places in the integer weight of endpoint.
+// the instances with weight 0 are filtered out before, a tiny positive weight is kept as the min weight 1
+func fromNacosWeight(weight float64) uint32 {
+	w := uint32(math.Round(weight * 100))
+	if w == 0 {
+		return 1
+	}
+	return w
+}
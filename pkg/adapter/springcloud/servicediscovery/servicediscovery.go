@@ -36,6 +36,8 @@ type (
 		Healthy     bool
 		CLusterName string
 		Enable      bool
+		// Weight the weight of endpoint, 0 means the default weight
+		Weight uint32
 		// extra info such as label or other meta data
 		Metadata map[string]string
 	}
@@ -76,7 +78,7 @@ func (i *ServiceInstance) GetUniqKey() string {
 // ToEndpoint
 func (i *ServiceInstance) ToEndpoint() *model.Endpoint {
 	a := model.SocketAddress{Address: i.Host, Port: i.Port}
-	return &model.Endpoint{ID: i.ID, Address: a, Name: i.ServiceName, Metadata: i.Metadata}
+	return &model.Endpoint{ID: i.ID, Address: a, Name: i.ServiceName, Metadata: i.Metadata, Weight: i.Weight}
 }
 
 // ToRoute route ID is cluster name, so equal with endpoint name and routerMatch prefix is also service name
@@ -20,6 +20,7 @@ package zookeeper
 import (
 	"encoding/json"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -199,6 +200,14 @@ func (sd *zookeeperDiscovery) queryForInstance(name string, id string) (*service
 	instance.ID = sczk.ID
 	instance.CLusterName = sczk.Name
 	instance.Healthy = sczk.Payload.Metadata.InstanceStatus == StatUP
+	if w := sczk.Payload.Metadata.Weight; w != "" {
+		weight, err := strconv.ParseUint(w, 10, 32)
+		if err != nil {
+			logger.Warnf("%s invalid weight %s of instance %s", common.ZKLogDiscovery, w, sczk.ID)
+		} else {
+			instance.Weight = uint32(weight)
+		}
+	}
 	return instance, nil
 }
 
@@ -294,6 +303,7 @@ type SpringCloudZKInstance struct {
 		Name     string `json:"name"`
 		Metadata struct {
 			InstanceStatus string `json:"instance_status"`
+			Weight         string `json:"weight"`
 		} `json:"metadata"`
 	} `json:"payload"`
 	RegistrationTimeUTC int64  `json:"registrationTimeUTC"`
@@ -0,0 +1,217 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// Resource the kind of upstream resource limited by circuit breakers
+type Resource int
+
+const (
+	// ResourceConnection the connections to upstream
+	ResourceConnection Resource = iota
+	// ResourcePendingRequest the requests waiting for ResourceRequest
+	ResourcePendingRequest
+	// ResourceRequest the concurrent requests to upstream
+	ResourceRequest
+	// ResourceRetry the concurrent retries to upstream
+	ResourceRetry
+)
+
+var resourceNames = map[Resource]string{
+	ResourceConnection:     "connection",
+	ResourcePendingRequest: "pending_request",
+	ResourceRequest:        "request",
+	ResourceRetry:          "retry",
+}
+
+func (r Resource) String() string {
+	return resourceNames[r]
+}
+
+// OverflowError the resource of cluster overflows the limit of circuit breakers
+type OverflowError struct {
+	Cluster  string
+	Resource Resource
+}
+
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf("cluster %s overflows the max %s limit", e.Cluster, e.Resource)
+}
+
+var (
+	overflowOnce    sync.Once
+	overflowCounter syncint64.Counter
+)
+
+// recordOverflow count the overflow by cluster and resource, the otel global meter delegates to the provider set later
+func recordOverflow(cluster string, resource Resource) {
+	overflowOnce.Do(func() {
+		counter, err := global.MeterProvider().Meter("pixiu").SyncInt64().Counter("pixiu_upstream_overflow_count",
+			instrument.WithDescription("requests rejected by the circuit breakers of upstream cluster"))
+		if err != nil {
+			logger.Errorf("register pixiu_upstream_overflow_count metric failed, err: %v", err)
+			return
+		}
+		overflowCounter = counter
+	})
+	if overflowCounter != nil {
+		overflowCounter.Add(context.Background(), 1,
+			attribute.String("cluster", cluster), attribute.String("resource", resource.String()))
+	}
+}
+
+// CircuitBreaker counts the resources in use of a cluster, the limits are read from the config on acquiring
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	counts   [ResourceRetry + 1]uint32
+	overflow [ResourceRetry + 1]uint64
+	// released closed and recreated when a request is released, the pending requests wait on it
+	released chan struct{}
+	// config the latest limits, the pending requests check it since the cluster may be updated while they wait
+	config *model.CircuitBreakers
+}
+
+// NewCircuitBreaker create a circuit breaker without any resource in use
+func NewCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{}
+}
+
+// Acquire take one of the resource, release must be called when the resource is no longer used.
+// the request waits as a pending request if max requests is reached and max pending requests is not,
+// until a request is released or ctx is done
+func (cb *CircuitBreaker) Acquire(ctx context.Context, cluster string, config *model.CircuitBreakers, resource Resource) (release func(), err error) {
+	if config == nil {
+		return func() {}, nil
+	}
+	limit := limitOf(config, resource)
+
+	cb.mu.Lock()
+	if cb.config != config {
+		// wake the pending requests up to check the new limits
+		cb.config = config
+		cb.wake()
+	}
+	if limit == 0 || cb.counts[resource] < limit {
+		cb.counts[resource]++
+		cb.mu.Unlock()
+		return cb.releaseFunc(resource), nil
+	}
+	if resource != ResourceRequest || cb.counts[ResourcePendingRequest] >= config.MaxPendingRequests {
+		if resource == ResourceRequest {
+			resource = ResourcePendingRequest
+		}
+		cb.overflow[resource]++
+		cb.mu.Unlock()
+		recordOverflow(cluster, resource)
+		return nil, &OverflowError{Cluster: cluster, Resource: resource}
+	}
+
+	cb.counts[ResourcePendingRequest]++
+	for {
+		if cb.released == nil {
+			cb.released = make(chan struct{})
+		}
+		released := cb.released
+		cb.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			cb.mu.Lock()
+			cb.counts[ResourcePendingRequest]--
+			cb.mu.Unlock()
+			return nil, ctx.Err()
+		case <-released:
+		}
+
+		cb.mu.Lock()
+		// 0 means unlimited
+		if limit := cb.config.MaxRequests; limit == 0 || cb.counts[ResourceRequest] < limit {
+			cb.counts[ResourcePendingRequest]--
+			cb.counts[ResourceRequest]++
+			cb.mu.Unlock()
+			return cb.releaseFunc(ResourceRequest), nil
+		}
+	}
+}
+
+// Count the resource in use
+func (cb *CircuitBreaker) Count(resource Resource) uint32 {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.counts[resource]
+}
+
+// Overflow the times the resource overflowed
+func (cb *CircuitBreaker) Overflow(resource Resource) uint64 {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.overflow[resource]
+}
+
+// releaseFunc the release is idempotent so that it is safe to be called by several paths
+func (cb *CircuitBreaker) releaseFunc(resource Resource) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cb.mu.Lock()
+			defer cb.mu.Unlock()
+			cb.counts[resource]--
+			if resource == ResourceRequest {
+				cb.wake()
+			}
+		})
+	}
+}
+
+// wake the pending requests, cb.mu must be held
+func (cb *CircuitBreaker) wake() {
+	if cb.released != nil {
+		close(cb.released)
+		cb.released = nil
+	}
+}
+
+func limitOf(config *model.CircuitBreakers, resource Resource) uint32 {
+	switch resource {
+	case ResourceConnection:
+		return config.MaxConnections
+	case ResourceRequest:
+		return config.MaxRequests
+	case ResourceRetry:
+		return config.MaxRetries
+	default:
+		return config.MaxPendingRequests
+	}
+}
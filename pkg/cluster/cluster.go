@@ -18,19 +18,42 @@
 package cluster
 
 import (
+	"crypto/tls"
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/dns"
 	"github.com/apache/dubbo-go-pixiu/pkg/cluster/healthcheck"
+	pixiutls "github.com/apache/dubbo-go-pixiu/pkg/common/tls"
 	"github.com/apache/dubbo-go-pixiu/pkg/model"
 )
 
 type Cluster struct {
 	HealthCheck *healthcheck.HealthChecker
+	Outlier     *OutlierDetector
+	Breaker     *CircuitBreaker
 	Config      *model.ClusterConfig
+	// Dns resolve the endpoints of StrictDNS and LogicalDns cluster
+	Dns *dns.Discovery
+
+	subsets *subsets
+	// localities config name -> priority levels of the cluster and its subsets
+	localities map[string]priorityLevels
+
+	tlsLock sync.Mutex
+	// tlsSource the config which tlsConfig is created from, tlsConfig is recreated when the config is updated
+	tlsSource *model.UpstreamTlsConfig
+	tlsConfig *tls.Config
 }
 
 func NewCluster(clusterConfig *model.ClusterConfig) *Cluster {
 	c := &Cluster{
-		Config: clusterConfig,
+		Config:  clusterConfig,
+		Outlier: NewOutlierDetector(clusterConfig.OutlierDetection),
+		Breaker: NewCircuitBreaker(),
 	}
+	c.UpdateEndpoints(clusterConfig)
 
 	// only handle one health checker
 	if len(c.Config.HealthChecks) != 0 {
@@ -44,12 +67,18 @@ func (c *Cluster) Stop() {
 	if c.HealthCheck != nil {
 		c.HealthCheck.Stop()
 	}
+	if c.Dns != nil {
+		c.Dns.Stop()
+	}
 }
 
 func (c *Cluster) RemoveEndpoint(endpoint *model.Endpoint) {
 	if c.HealthCheck != nil {
 		c.HealthCheck.StopOne(endpoint)
 	}
+	if c.Outlier != nil {
+		c.Outlier.Remove(endpoint)
+	}
 }
 
 func (c *Cluster) AddEndpoint(endpoint *model.Endpoint) {
@@ -57,3 +86,39 @@ func (c *Cluster) AddEndpoint(endpoint *model.Endpoint) {
 		c.HealthCheck.StartOne(endpoint)
 	}
 }
+
+// Update replace the config of cluster, the outlier detector is rebuilt from the new config and the endpoints
+// ejected by the previous detector return to service
+func (c *Cluster) Update(config *model.ClusterConfig) {
+	c.Config = config
+	c.Outlier = NewOutlierDetector(config.OutlierDetection)
+	for _, e := range config.Endpoints {
+		e.Ejected = false
+	}
+	c.UpdateEndpoints(config)
+}
+
+// UpdateEndpoints recompute the subsets and priority levels, it must be called when the endpoints are changed
+func (c *Cluster) UpdateEndpoints(config *model.ClusterConfig) {
+	c.UpdateSubsets(config)
+	c.updateLocalities(config)
+}
+
+// TlsConfig return the cached client tls config created from cfg, it must not be modified by caller
+func (c *Cluster) TlsConfig(cfg *model.UpstreamTlsConfig) (*tls.Config, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	c.tlsLock.Lock()
+	defer c.tlsLock.Unlock()
+	if c.tlsSource == cfg {
+		return c.tlsConfig, nil
+	}
+	tlsConfig, err := pixiutls.NewUpstreamConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c.tlsSource = cfg
+	c.tlsConfig = tlsConfig
+	return tlsConfig, nil
+}
@@ -0,0 +1,241 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/util/stringutil"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const (
+	DefaultRefreshRate    = 5 * time.Second
+	DefaultResolveTimeout = 5 * time.Second
+)
+
+type (
+	// Record a resolved address, TTL is 0 if the resolver doesn't know it
+	Record struct {
+		IP  net.IP
+		TTL time.Duration
+	}
+
+	// Resolver resolve the hostname to addresses
+	Resolver interface {
+		Resolve(ctx context.Context, host string) ([]Record, error)
+	}
+
+	// Updater apply the resolved endpoints to the cluster
+	Updater interface {
+		SetEndpoint(clusterName string, endpoint *model.Endpoint)
+		DeleteEndpoint(clusterName string, endpointID string)
+	}
+
+	// Discovery re-resolve the hostnames of the endpoints of StrictDNS or LogicalDns cluster periodically.
+	// the endpoint of hostname is replaced by the endpoints of resolved addresses, all of the addresses are
+	// used by StrictDNS while LogicalDns keeps one endpoint whose address is changed only if it is not resolved any more
+	Discovery struct {
+		clusterName string
+		logical     bool
+		refreshRate time.Duration
+		respectTTL  bool
+		resolver    Resolver
+		updater     Updater
+		targets     []*model.Endpoint
+
+		stopOnce sync.Once
+		done     chan struct{}
+	}
+
+	// netResolver resolve by the resolver of go, the ttl is unknown, see NewResolver for the one knows the ttl
+	netResolver struct {
+		resolver *net.Resolver
+	}
+)
+
+// NewNetResolver the resolver using the system dns config
+func NewNetResolver() Resolver {
+	return &netResolver{resolver: net.DefaultResolver}
+}
+
+func (r *netResolver) Resolve(ctx context.Context, host string) ([]Record, error) {
+	addrs, err := r.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(addrs))
+	for _, addr := range addrs {
+		records = append(records, Record{IP: addr.IP})
+	}
+	return records, nil
+}
+
+// NewDiscovery return nil if the cluster is not a dns cluster or has no endpoint of hostname.
+// the endpoint of hostname without id is given the id host:port
+func NewDiscovery(config *model.ClusterConfig, resolver Resolver, updater Updater) *Discovery {
+	discoveryType := config.GetDiscoveryType()
+	if discoveryType != model.StrictDNS && discoveryType != model.LogicalDns {
+		return nil
+	}
+	targets := make([]*model.Endpoint, 0, len(config.Endpoints))
+	for _, e := range config.Endpoints {
+		if net.ParseIP(e.Address.Address) == nil {
+			if e.ID == "" {
+				// the endpoint of hostname is deleted by id after the first successful resolving
+				e.ID = net.JoinHostPort(e.Address.Address, strconv.Itoa(e.Address.Port))
+			}
+			targets = append(targets, copyEndpoint(e))
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	return &Discovery{
+		clusterName: config.Name,
+		logical:     discoveryType == model.LogicalDns,
+		refreshRate: stringutil.ResolveTimeStr2Time(config.DnsRefreshRate, DefaultRefreshRate),
+		respectTTL:  config.RespectDnsTTL,
+		resolver:    resolver,
+		updater:     updater,
+		targets:     targets,
+		done:        make(chan struct{}),
+	}
+}
+
+// Start resolve each hostname in its own goroutine
+func (d *Discovery) Start() {
+	for _, target := range d.targets {
+		go d.watch(target)
+	}
+}
+
+// Stop stop resolving, it doesn't wait for the resolving in progress
+func (d *Discovery) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.done)
+	})
+}
+
+func (d *Discovery) watch(target *model.Endpoint) {
+	// resolved endpoint id -> endpoint, nil before the first successful resolving
+	var current map[string]*model.Endpoint
+	for {
+		interval, next := d.resolve(target, current)
+		if next != nil {
+			current = next
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-d.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// resolve update the endpoints of target, return the interval to the next resolving and the resolved endpoints,
+// the endpoints are nil if resolving failed
+func (d *Discovery) resolve(target *model.Endpoint, current map[string]*model.Endpoint) (time.Duration, map[string]*model.Endpoint) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultResolveTimeout)
+	defer cancel()
+	host := target.Address.Address
+	records, err := d.resolver.Resolve(ctx, host)
+	if err != nil {
+		logger.Warnf("[dubbo-go-pixiu] cluster %s resolve %s failed: %v", d.clusterName, host, err)
+		return d.refreshRate, nil
+	}
+	if d.logical && len(records) > 1 {
+		records = pickLogical(records, current)
+	}
+
+	select {
+	case <-d.done:
+		return d.refreshRate, nil
+	default:
+	}
+
+	interval := d.refreshRate
+	next := make(map[string]*model.Endpoint, len(records))
+	for _, r := range records {
+		e := copyEndpoint(target)
+		e.ID = endpointID(target, r.IP.String())
+		if d.logical {
+			// the id is stable so that the address change doesn't replace the endpoint and reset its state
+			e.ID = endpointID(target, host)
+		}
+		e.Address.Address = r.IP.String()
+		next[e.ID] = e
+		if d.respectTTL && r.TTL > 0 && r.TTL < interval {
+			interval = r.TTL
+		}
+	}
+	for id, e := range next {
+		if old, ok := current[id]; !ok || old.Address.Address != e.Address.Address {
+			d.updater.SetEndpoint(d.clusterName, e)
+		}
+	}
+	for id := range current {
+		if _, ok := next[id]; !ok {
+			d.updater.DeleteEndpoint(d.clusterName, id)
+		}
+	}
+	// the endpoint of hostname is kept until the first successful resolving
+	if current == nil {
+		d.updater.DeleteEndpoint(d.clusterName, target.ID)
+		logger.Infof("[dubbo-go-pixiu] cluster %s resolve %s to %d addresses", d.clusterName, host, len(next))
+	}
+	return interval, next
+}
+
+// pickLogical keep the address in use if it is still resolved, otherwise the first one, so that the
+// round robin of dns doesn't churn the endpoint of LogicalDns
+func pickLogical(records []Record, current map[string]*model.Endpoint) []Record {
+	for _, e := range current {
+		for _, r := range records {
+			if r.IP.String() == e.Address.Address {
+				return []Record{r}
+			}
+		}
+	}
+	return records[:1]
+}
+
+// endpointID the id of resolved endpoint is derived from the endpoint of hostname and the address
+func endpointID(target *model.Endpoint, address string) string {
+	return target.ID + "@" + net.JoinHostPort(address, strconv.Itoa(target.Address.Port))
+}
+
+func copyEndpoint(e *model.Endpoint) *model.Endpoint {
+	return &model.Endpoint{
+		ID:       e.ID,
+		Name:     e.Name,
+		Address:  e.Address,
+		Metadata: e.Metadata,
+		Weight:   e.Weight,
+	}
+}
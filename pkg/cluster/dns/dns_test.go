@@ -0,0 +1,201 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+type fakeResolver struct {
+	mu      sync.Mutex
+	records map[string][]Record
+	err     error
+}
+
+func (r *fakeResolver) set(host string, err error, records ...Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[host] = records
+	r.err = err
+}
+
+func (r *fakeResolver) Resolve(_ context.Context, host string) ([]Record, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.records[host], nil
+}
+
+type fakeUpdater struct {
+	mu        sync.Mutex
+	endpoints map[string]*model.Endpoint
+}
+
+func (u *fakeUpdater) SetEndpoint(_ string, endpoint *model.Endpoint) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.endpoints[endpoint.ID] = endpoint
+}
+
+func (u *fakeUpdater) DeleteEndpoint(_ string, endpointID string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.endpoints, endpointID)
+}
+
+func (u *fakeUpdater) ids() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	ids := make([]string, 0, len(u.endpoints))
+	for id := range u.endpoints {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (u *fakeUpdater) get(id string) *model.Endpoint {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.endpoints[id]
+}
+
+func newCluster(typeStr string, endpoints ...*model.Endpoint) *model.ClusterConfig {
+	return &model.ClusterConfig{
+		Name:           "test",
+		TypeStr:        typeStr,
+		DnsRefreshRate: "20ms",
+		Endpoints:      endpoints,
+	}
+}
+
+func TestNewDiscovery(t *testing.T) {
+	resolver := &fakeResolver{records: map[string][]Record{}}
+	updater := &fakeUpdater{endpoints: map[string]*model.Endpoint{}}
+	host := &model.Endpoint{ID: "1", Address: model.SocketAddress{Address: "svc.local", Port: 8080}}
+	ip := &model.Endpoint{ID: "2", Address: model.SocketAddress{Address: "10.0.0.1", Port: 8080}}
+
+	assert.Nil(t, NewDiscovery(newCluster("Static", host), resolver, updater))
+	assert.Nil(t, NewDiscovery(newCluster("StrictDNS", ip), resolver, updater))
+	d := NewDiscovery(newCluster("StrictDNS", host, ip), resolver, updater)
+	assert.NotNil(t, d)
+	assert.Equal(t, 1, len(d.targets))
+	assert.Equal(t, 20*time.Millisecond, d.refreshRate)
+	assert.True(t, NewDiscovery(newCluster("LogicalDns", host), resolver, updater).logical)
+}
+
+func TestStrictDNS(t *testing.T) {
+	resolver := &fakeResolver{records: map[string][]Record{}}
+	updater := &fakeUpdater{endpoints: map[string]*model.Endpoint{}}
+	host := &model.Endpoint{ID: "1", Address: model.SocketAddress{Address: "svc.local", Port: 8080}, Weight: 3}
+	updater.SetEndpoint("test", host)
+
+	// the endpoint of hostname is kept if resolving failed
+	resolver.set("svc.local", errors.New("no such host"))
+	d := NewDiscovery(newCluster("StrictDNS", host), resolver, updater)
+	d.Start()
+	defer d.Stop()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, []string{"1"}, updater.ids())
+
+	resolver.set("svc.local", nil, Record{IP: net.ParseIP("10.0.0.1")}, Record{IP: net.ParseIP("10.0.0.2")})
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"1@10.0.0.1:8080", "1@10.0.0.2:8080"}, updater.ids())
+	}, time.Second, 10*time.Millisecond)
+	e := updater.endpoints["1@10.0.0.1:8080"]
+	assert.Equal(t, "10.0.0.1", e.Address.Address)
+	assert.Equal(t, 8080, e.Address.Port)
+	assert.Equal(t, uint32(3), e.Weight)
+
+	// the addresses change, e.g. the pods of headless service are recreated
+	resolver.set("svc.local", nil, Record{IP: net.ParseIP("10.0.0.2")}, Record{IP: net.ParseIP("10.0.0.3")})
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"1@10.0.0.2:8080", "1@10.0.0.3:8080"}, updater.ids())
+	}, time.Second, 10*time.Millisecond)
+
+	// no change after stopped
+	d.Stop()
+	time.Sleep(30 * time.Millisecond)
+	resolver.set("svc.local", nil, Record{IP: net.ParseIP("10.0.0.4")})
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, []string{"1@10.0.0.2:8080", "1@10.0.0.3:8080"}, updater.ids())
+}
+
+func TestLogicalDNS(t *testing.T) {
+	resolver := &fakeResolver{records: map[string][]Record{}}
+	updater := &fakeUpdater{endpoints: map[string]*model.Endpoint{}}
+	host := &model.Endpoint{ID: "1", Address: model.SocketAddress{Address: "lb.cloud", Port: 80}}
+	resolver.set("lb.cloud", nil, Record{IP: net.ParseIP("10.0.0.1")}, Record{IP: net.ParseIP("10.0.0.2")})
+
+	d := NewDiscovery(newCluster("LogicalDns", host), resolver, updater)
+	d.Start()
+	defer d.Stop()
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"1@lb.cloud:80"}, updater.ids())
+	}, time.Second, 10*time.Millisecond)
+	e := updater.get("1@lb.cloud:80")
+	assert.Equal(t, "10.0.0.1", e.Address.Address)
+
+	// the address in use is kept while the dns rotates the records
+	resolver.set("lb.cloud", nil, Record{IP: net.ParseIP("10.0.0.2")}, Record{IP: net.ParseIP("10.0.0.1")})
+	time.Sleep(50 * time.Millisecond)
+	assert.Same(t, e, updater.get("1@lb.cloud:80"))
+
+	// the endpoint keeps its id when the address in use is gone
+	resolver.set("lb.cloud", nil, Record{IP: net.ParseIP("10.0.0.3")})
+	assert.Eventually(t, func() bool {
+		e := updater.get("1@lb.cloud:80")
+		return e != nil && e.Address.Address == "10.0.0.3"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"1@lb.cloud:80"}, updater.ids())
+}
+
+func TestRespectDnsTTL(t *testing.T) {
+	resolver := &fakeResolver{records: map[string][]Record{}}
+	updater := &fakeUpdater{endpoints: map[string]*model.Endpoint{}}
+	host := &model.Endpoint{ID: "1", Address: model.SocketAddress{Address: "svc.local", Port: 80}}
+	resolver.set("svc.local", nil, Record{IP: net.ParseIP("10.0.0.1"), TTL: time.Second}, Record{IP: net.ParseIP("10.0.0.2"), TTL: 10 * time.Millisecond})
+
+	config := newCluster("StrictDNS", host)
+	config.DnsRefreshRate = "1m"
+	d := NewDiscovery(config, resolver, updater)
+	interval, endpoints := d.resolve(d.targets[0], nil)
+	assert.Equal(t, time.Minute, interval)
+	assert.Equal(t, 2, len(endpoints))
+
+	config.RespectDnsTTL = true
+	d = NewDiscovery(config, resolver, updater)
+	interval, _ = d.resolve(d.targets[0], nil)
+	assert.Equal(t, 10*time.Millisecond, interval)
+}
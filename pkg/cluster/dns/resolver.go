@@ -0,0 +1,264 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package dns
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	resolvConfPath = "/etc/resolv.conf"
+	// maxUDPSize the udp response larger than it is truncated by the nameserver, the query is retried by tcp
+	maxUDPSize = 1232
+)
+
+var errNoSuchHost = errors.New("no such host")
+
+type (
+	// systemResolver query the nameservers of resolv.conf directly to know the ttl of records,
+	// it falls back to the resolver of go, e.g. for the hostnames of /etc/hosts, whose ttl is unknown
+	systemResolver struct {
+		confPath string
+		fallback Resolver
+	}
+
+	resolvConf struct {
+		servers []string
+		search  []string
+		ndots   int
+	}
+)
+
+// NewResolver the resolver using the system dns config which knows the ttl of records
+func NewResolver() Resolver {
+	return &systemResolver{confPath: resolvConfPath, fallback: NewNetResolver()}
+}
+
+func (r *systemResolver) Resolve(ctx context.Context, host string) ([]Record, error) {
+	conf, err := readResolvConf(r.confPath)
+	if err == nil && len(conf.servers) > 0 {
+		records, err := r.lookup(ctx, conf, host)
+		if err == nil {
+			return records, nil
+		}
+		logger.Debugf("[dubbo-go-pixiu] query nameservers for %s failed, fallback to the resolver of go: %v", host, err)
+	}
+	return r.fallback.Resolve(ctx, host)
+}
+
+// lookup query the A and AAAA records of the candidate names in order, return the records of the first name found
+func (r *systemResolver) lookup(ctx context.Context, conf *resolvConf, host string) ([]Record, error) {
+	err := errNoSuchHost
+	for _, name := range conf.names(host) {
+		var records []Record
+		for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+			rs, qerr := r.query(ctx, conf, name, qtype)
+			if qerr != nil {
+				err = qerr
+				continue
+			}
+			records = append(records, rs...)
+		}
+		if len(records) > 0 {
+			return records, nil
+		}
+	}
+	return nil, err
+}
+
+// query ask the nameservers in order until one of them answers
+func (r *systemResolver) query(ctx context.Context, conf *resolvConf, name string, qtype dnsmessage.Type) ([]Record, error) {
+	var err error
+	for _, server := range conf.servers {
+		var records []Record
+		records, err = exchange(ctx, server, name, qtype)
+		if err == nil || errors.Is(err, errNoSuchHost) {
+			return records, err
+		}
+	}
+	return nil, err
+}
+
+// exchange send the query by udp, and by tcp again if the response is truncated
+func exchange(ctx context.Context, server, name string, qtype dnsmessage.Type) ([]Record, error) {
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, err
+	}
+	id := uint16(rand.Uint32())
+	req := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	b, err := req.Pack()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := roundTrip(ctx, "udp", server, b)
+	if err == nil && resp.Truncated {
+		resp, err = roundTrip(ctx, "tcp", server, b)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.ID != id {
+		return nil, fmt.Errorf("mismatched id of response from %s", server)
+	}
+	switch resp.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, errNoSuchHost
+	default:
+		return nil, fmt.Errorf("nameserver %s responds %s", server, resp.RCode)
+	}
+
+	records := make([]Record, 0, len(resp.Answers))
+	for _, a := range resp.Answers {
+		ttl := time.Duration(a.Header.TTL) * time.Second
+		switch body := a.Body.(type) {
+		case *dnsmessage.AResource:
+			records = append(records, Record{IP: net.IP(body.A[:]), TTL: ttl})
+		case *dnsmessage.AAAAResource:
+			records = append(records, Record{IP: net.IP(body.AAAA[:]), TTL: ttl})
+		}
+	}
+	return records, nil
+}
+
+func roundTrip(ctx context.Context, network, server string, req []byte) (*dnsmessage.Message, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultResolveTimeout)
+	}
+	if err = conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	if network == "tcp" {
+		// the message is prefixed with its length over tcp
+		req = append(binary.BigEndian.AppendUint16(nil, uint16(len(req))), req...)
+		if _, err = conn.Write(req); err != nil {
+			return nil, err
+		}
+		var length [2]byte
+		if _, err = io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		b = make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err = io.ReadFull(conn, b); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err = conn.Write(req); err != nil {
+			return nil, err
+		}
+		b = make([]byte, maxUDPSize)
+		n, err := conn.Read(b)
+		if err != nil {
+			return nil, err
+		}
+		b = b[:n]
+	}
+
+	resp := &dnsmessage.Message{}
+	if err = resp.Unpack(b); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// readResolvConf read the nameservers, search domains and ndots option of resolv.conf
+func readResolvConf(path string) (*resolvConf, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	conf := &resolvConf{ndots: 1}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], ";") {
+			continue
+		}
+		switch fields[0] {
+		case "nameserver":
+			if ip := net.ParseIP(fields[1]); ip != nil {
+				conf.servers = append(conf.servers, net.JoinHostPort(ip.String(), "53"))
+			}
+		case "domain":
+			conf.search = fields[1:2]
+		case "search":
+			conf.search = fields[1:]
+		case "options":
+			for _, opt := range fields[1:] {
+				if strings.HasPrefix(opt, "ndots:") {
+					if n, err := strconv.Atoi(strings.TrimPrefix(opt, "ndots:")); err == nil && n >= 0 {
+						conf.ndots = n
+					}
+				}
+			}
+		}
+	}
+	return conf, scanner.Err()
+}
+
+// names the fully qualified names to query for host, the search domains are tried first if host has less dots than ndots
+func (c *resolvConf) names(host string) []string {
+	if strings.HasSuffix(host, ".") {
+		return []string{host}
+	}
+	names := make([]string, 0, len(c.search)+1)
+	rooted := host + "."
+	if strings.Count(host, ".") >= c.ndots {
+		names = append(names, rooted)
+	}
+	for _, s := range c.search {
+		names = append(names, rooted+strings.TrimSuffix(s, ".")+".")
+	}
+	if strings.Count(host, ".") < c.ndots {
+		names = append(names, rooted)
+	}
+	return names
+}
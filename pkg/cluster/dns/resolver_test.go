@@ -0,0 +1,100 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package dns
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveDNS answer the A queries of name with ip and ttl, the other names do not exist
+func serveDNS(t *testing.T, name string, ip [4]byte, ttl uint32) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		b := make([]byte, maxUDPSize)
+		for {
+			n, addr, err := conn.ReadFrom(b)
+			if err != nil {
+				return
+			}
+			var req dnsmessage.Message
+			if err := req.Unpack(b[:n]); err != nil {
+				continue
+			}
+			q := req.Questions[0]
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: req.ID, Response: true},
+				Questions: req.Questions,
+			}
+			switch {
+			case q.Name.String() != name:
+				resp.RCode = dnsmessage.RCodeNameError
+			case q.Type == dnsmessage.TypeA:
+				resp.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: q.Class, TTL: ttl},
+					Body:   &dnsmessage.AResource{A: ip},
+				}}
+			}
+			out, _ := resp.Pack()
+			_, _ = conn.WriteTo(out, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestSystemResolver(t *testing.T) {
+	server := serveDNS(t, "svc.ns.svc.cluster.local.", [4]byte{10, 0, 0, 1}, 30)
+	conf := &resolvConf{servers: []string{server}, search: []string{"ns.svc.cluster.local", "svc.cluster.local"}, ndots: 5}
+	r := &systemResolver{}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// the name is found with the second search domain
+	records, err := r.lookup(ctx, conf, "svc")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, "10.0.0.1", records[0].IP.String())
+	assert.Equal(t, 30*time.Second, records[0].TTL)
+
+	_, err = r.lookup(ctx, conf, "other")
+	assert.ErrorIs(t, err, errNoSuchHost)
+}
+
+func TestReadResolvConf(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolv.conf")
+	content := "# comment\nnameserver 10.96.0.10\nnameserver fd00::10\nsearch ns.svc.cluster.local cluster.local\noptions ndots:2 timeout:1\n"
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0o600))
+
+	conf, err := readResolvConf(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.96.0.10:53", "[fd00::10]:53"}, conf.servers)
+	assert.Equal(t, 2, conf.ndots)
+	assert.Equal(t, []string{"svc.ns.svc.cluster.local.", "svc.cluster.local.", "svc."}, conf.names("svc"))
+	assert.Equal(t, []string{"a.b.c.", "a.b.c.ns.svc.cluster.local.", "a.b.c.cluster.local."}, conf.names("a.b.c"))
+	assert.Equal(t, []string{"lb.cloud."}, conf.names("lb.cloud."))
+}
@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+)
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// GRPCChecker check health by grpc.health.v1.Health/Check
+type GRPCChecker struct {
+	addr        string
+	serviceName string
+	authority   string
+	timeout     time.Duration
+	// tlsConfig plaintext is used if it is nil
+	tlsConfig *tls.Config
+}
+
+func newGrpcChecker(endpoint *model.Endpoint, timeout time.Duration, tlsConfig *tls.Config, cfg *model.GrpcHealthCheck) *GRPCChecker {
+	if cfg == nil {
+		cfg = &model.GrpcHealthCheck{}
+	}
+	return &GRPCChecker{
+		addr:        endpoint.Address.GetAddress(),
+		serviceName: cfg.ServiceName,
+		authority:   cfg.Authority,
+		timeout:     timeout,
+		tlsConfig:   tlsConfig,
+	}
+}
+
+func (s *GRPCChecker) CheckHealth() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	creds := insecure.NewCredentials()
+	if s.tlsConfig != nil {
+		creds = credentials.NewTLS(s.tlsConfig)
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds), grpc.WithBlock()}
+	if s.authority != "" {
+		opts = append(opts, grpc.WithAuthority(s.authority))
+	}
+	conn, err := grpc.DialContext(ctx, s.addr, opts...)
+	if err != nil {
+		logger.Infof("[health check] grpc checker for host %s dial error: %v", s.addr, err)
+		return false
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: s.serviceName})
+	if err != nil {
+		logger.Infof("[health check] grpc checker for host %s error: %v", s.addr, err)
+		return false
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		logger.Infof("[health check] grpc checker for host %s status: %s", s.addr, resp.GetStatus())
+		return false
+	}
+	return true
+}
@@ -18,7 +18,9 @@
 package healthcheck
 
 import (
+	"crypto/tls"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -29,6 +31,7 @@ import (
 )
 
 import (
+	pixiutls "github.com/apache/dubbo-go-pixiu/pkg/common/tls"
 	"github.com/apache/dubbo-go-pixiu/pkg/logger"
 	"github.com/apache/dubbo-go-pixiu/pkg/model"
 )
@@ -42,9 +45,27 @@ const (
 	DefaultFirstInterval             = 5 * time.Second
 )
 
+const (
+	ProtocolTCP   = "tcp"
+	ProtocolHTTP  = "http"
+	ProtocolHTTPS = "https"
+	ProtocolGRPC  = "grpc"
+)
+
+// Checker check the health of one endpoint
+type Checker interface {
+	// CheckHealth return true if the endpoint is healthy
+	CheckHealth() bool
+}
+
 type HealthChecker struct {
 	checkers      map[string]*EndpointChecker
 	sessionConfig map[string]interface{}
+	protocol      string
+	httpCheck     *model.HttpHealthCheck
+	grpcCheck     *model.GrpcHealthCheck
+	// tlsConfig is not nil if the cluster uses tls
+	tlsConfig *tls.Config
 	// check config
 	timeout            time.Duration
 	intervalBase       time.Duration
@@ -58,8 +79,8 @@ type HealthChecker struct {
 type EndpointChecker struct {
 	endpoint      *model.Endpoint
 	HealthChecker *HealthChecker
-	// TCP checker, can extend to http, grpc, dubbo or other protocol checker
-	tcpChecker    *TCPChecker
+	// tcp, http or grpc checker, can extend to dubbo or other protocol checker
+	checker       Checker
 	resp          chan checkResponse
 	timeout       chan bool
 	checkID       uint64
@@ -109,6 +130,9 @@ func CreateHealthCheck(cluster *model.ClusterConfig, cfg model.HealthCheckConfig
 
 	hc := &HealthChecker{
 		sessionConfig:      cfg.SessionConfig,
+		protocol:           strings.ToLower(cfg.Protocol),
+		httpCheck:          cfg.HttpHealthCheck,
+		grpcCheck:          grpcHealthCheck(cfg),
 		cluster:            cluster,
 		timeout:            timeout,
 		intervalBase:       interval,
@@ -116,6 +140,7 @@ func CreateHealthCheck(cluster *model.ClusterConfig, cfg model.HealthCheckConfig
 		unhealthyThreshold: unhealthyThreshold,
 		initialDelay:       initialDelay,
 		checkers:           make(map[string]*EndpointChecker),
+		tlsConfig:          createTlsConfig(cluster),
 	}
 
 	return hc
@@ -163,7 +188,7 @@ func (hc *HealthChecker) stopCheck(endpoint *model.Endpoint) {
 
 func newChecker(endpoint *model.Endpoint, hc *HealthChecker) *EndpointChecker {
 	c := &EndpointChecker{
-		tcpChecker:    newTcpChecker(endpoint, hc.timeout),
+		checker:       hc.newProtocolChecker(endpoint),
 		endpoint:      endpoint,
 		HealthChecker: hc,
 		resp:          make(chan checkResponse),
@@ -173,6 +198,50 @@ func newChecker(endpoint *model.Endpoint, hc *HealthChecker) *EndpointChecker {
 	return c
 }
 
+// grpcHealthCheck returns the grpc check config, the service name falls back to HealthCheckConfig.ServiceName
+func grpcHealthCheck(cfg model.HealthCheckConfig) *model.GrpcHealthCheck {
+	grpcCheck := model.GrpcHealthCheck{}
+	if cfg.GrpcHealthCheck != nil {
+		grpcCheck = *cfg.GrpcHealthCheck
+	}
+	if grpcCheck.ServiceName == "" {
+		grpcCheck.ServiceName = cfg.ServiceName
+	}
+	return &grpcCheck
+}
+
+// createTlsConfig create the tls config of cluster which is used by https and grpc checker
+func createTlsConfig(cluster *model.ClusterConfig) *tls.Config {
+	if cluster.Tls == nil {
+		return nil
+	}
+	tlsConfig, err := pixiutls.NewUpstreamConfig(cluster.Tls)
+	if err != nil {
+		// still use tls rather than fallback to plaintext, the check will fail if client certificate is required
+		logger.Warnf("[health check] create tls config of cluster %s error: %v", cluster.Name, err)
+		return &tls.Config{ServerName: cluster.Tls.ServerName}
+	}
+	return tlsConfig
+}
+
+// newProtocolChecker create checker according to the protocol of health check config, tcp by default
+func (hc *HealthChecker) newProtocolChecker(endpoint *model.Endpoint) Checker {
+	switch hc.protocol {
+	case ProtocolHTTP:
+		return newHttpChecker(endpoint, hc.timeout, nil, hc.httpCheck)
+	case ProtocolHTTPS:
+		tlsConfig := hc.tlsConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		return newHttpChecker(endpoint, hc.timeout, tlsConfig, hc.httpCheck)
+	case ProtocolGRPC:
+		return newGrpcChecker(endpoint, hc.timeout, hc.tlsConfig, hc.grpcCheck)
+	default:
+		return newTcpChecker(endpoint, hc.timeout)
+	}
+}
+
 func newTcpChecker(endpoint *model.Endpoint, timeout time.Duration) *TCPChecker {
 	return &TCPChecker{
 		addr:    endpoint.Address.GetAddress(),
@@ -262,6 +331,9 @@ func (c *EndpointChecker) HandleTimeout() {
 func (c *EndpointChecker) handleHealth() {
 	c.healthCount = 0
 	c.unHealthCount = 0
+	if c.endpoint.UnHealthy {
+		c.endpoint.StartWarmup()
+	}
 	c.endpoint.UnHealthy = false
 }
 
@@ -279,7 +351,7 @@ func (c *EndpointChecker) OnCheck() {
 	c.checkTimeout = gxtime.AfterFunc(c.HealthChecker.timeout, c.OnTimeout)
 	c.resp <- checkResponse{
 		ID:      id,
-		Healthy: c.tcpChecker.CheckHealth(),
+		Healthy: c.checker.CheckHealth(),
 	}
 }
 
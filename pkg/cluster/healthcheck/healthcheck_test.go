@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func endpointOf(t *testing.T, addr string) *model.Endpoint {
+	host, port, err := net.SplitHostPort(addr)
+	assert.Nil(t, err)
+	p, err := strconv.Atoi(port)
+	assert.Nil(t, err)
+	return &model.Endpoint{ID: "1", Address: model.SocketAddress{Address: host, Port: p}}
+}
+
+func TestHTTPChecker(t *testing.T) {
+	ready := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/actuator/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"status":"DOWN"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"UP"}`))
+	}))
+	defer srv.Close()
+
+	endpoint := endpointOf(t, srv.Listener.Addr().String())
+
+	c := newHttpChecker(endpoint, time.Second, nil, &model.HttpHealthCheck{Path: "/actuator/health"})
+	assert.True(t, c.CheckHealth())
+	ready = false
+	assert.False(t, c.CheckHealth())
+
+	// 503 is accepted, but body must contain UP
+	c = newHttpChecker(endpoint, time.Second, nil, &model.HttpHealthCheck{
+		Path:             "/actuator/health",
+		ExpectedStatuses: []model.StatusRange{{Start: 200, End: 600}},
+		ExpectedBody:     `"UP"`,
+	})
+	assert.False(t, c.CheckHealth())
+	ready = true
+	assert.True(t, c.CheckHealth())
+
+	c = newHttpChecker(endpoint, time.Second, nil, nil)
+	assert.False(t, c.CheckHealth())
+}
+
+func TestGRPCChecker(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	s := grpc.NewServer()
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(s, hs)
+	go func() {
+		_ = s.Serve(l)
+	}()
+	defer s.Stop()
+
+	endpoint := endpointOf(t, l.Addr().String())
+	c := newGrpcChecker(endpoint, time.Second, nil, &model.GrpcHealthCheck{ServiceName: "user"})
+
+	hs.SetServingStatus("user", healthpb.HealthCheckResponse_SERVING)
+	assert.True(t, c.CheckHealth())
+	hs.SetServingStatus("user", healthpb.HealthCheckResponse_NOT_SERVING)
+	assert.False(t, c.CheckHealth())
+}
+
+func TestNewProtocolChecker(t *testing.T) {
+	endpoint := &model.Endpoint{Address: model.SocketAddress{Address: "127.0.0.1", Port: 8080}}
+	cluster := &model.ClusterConfig{Name: "test"}
+
+	hc := CreateHealthCheck(cluster, model.HealthCheckConfig{Protocol: "HTTP"})
+	_, ok := hc.newProtocolChecker(endpoint).(*HTTPChecker)
+	assert.True(t, ok)
+
+	hc = CreateHealthCheck(cluster, model.HealthCheckConfig{Protocol: "grpc"})
+	_, ok = hc.newProtocolChecker(endpoint).(*GRPCChecker)
+	assert.True(t, ok)
+
+	hc = CreateHealthCheck(cluster, model.HealthCheckConfig{})
+	_, ok = hc.newProtocolChecker(endpoint).(*TCPChecker)
+	assert.True(t, ok)
+}
+
+func TestGrpcHealthCheckServiceName(t *testing.T) {
+	endpoint := &model.Endpoint{Address: model.SocketAddress{Address: "127.0.0.1", Port: 8080}}
+	cluster := &model.ClusterConfig{Name: "test"}
+
+	hc := CreateHealthCheck(cluster, model.HealthCheckConfig{Protocol: "grpc", ServiceName: "user"})
+	c := hc.newProtocolChecker(endpoint).(*GRPCChecker)
+	assert.Equal(t, "user", c.serviceName)
+
+	hc = CreateHealthCheck(cluster, model.HealthCheckConfig{
+		Protocol:        "grpc",
+		ServiceName:     "user",
+		GrpcHealthCheck: &model.GrpcHealthCheck{ServiceName: "order"},
+	})
+	c = hc.newProtocolChecker(endpoint).(*GRPCChecker)
+	assert.Equal(t, "order", c.serviceName)
+}
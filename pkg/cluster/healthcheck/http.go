@@ -0,0 +1,156 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+import (
+	"golang.org/x/net/http2"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const (
+	DefaultHttpPath = "/"
+	// maxHttpBodyCheckSize the max size of response body read for body matching
+	maxHttpBodyCheckSize = 64 * 1024
+)
+
+// DefaultExpectedStatuses [200, 400) is treated as healthy by default
+var DefaultExpectedStatuses = []model.StatusRange{{Start: http.StatusOK, End: http.StatusBadRequest}}
+
+type HTTPChecker struct {
+	url          string
+	host         string
+	method       string
+	headers      map[string]string
+	statuses     []model.StatusRange
+	expectedBody []byte
+	client       *http.Client
+}
+
+// newHttpChecker create http checker, https is used if tlsConfig is not nil
+func newHttpChecker(endpoint *model.Endpoint, timeout time.Duration, tlsConfig *tls.Config, cfg *model.HttpHealthCheck) *HTTPChecker {
+	if cfg == nil {
+		cfg = &model.HttpHealthCheck{}
+	}
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+	path := cfg.Path
+	if path == "" {
+		path = DefaultHttpPath
+	}
+	method := cfg.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	statuses := cfg.ExpectedStatuses
+	if len(statuses) == 0 {
+		statuses = DefaultExpectedStatuses
+	}
+	u := url.URL{Scheme: scheme, Host: endpoint.Address.GetAddress(), Path: path}
+	return &HTTPChecker{
+		url:          u.String(),
+		host:         cfg.Host,
+		method:       method,
+		headers:      cfg.Headers,
+		statuses:     statuses,
+		expectedBody: []byte(cfg.ExpectedBody),
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: newHttpCheckTransport(tlsConfig, cfg.UseHttp2),
+		},
+	}
+}
+
+func newHttpCheckTransport(tlsConfig *tls.Config, useHttp2 bool) http.RoundTripper {
+	if !useHttp2 {
+		return &http.Transport{DisableKeepAlives: true, TLSClientConfig: tlsConfig}
+	}
+	if tlsConfig != nil {
+		return &http2.Transport{TLSClientConfig: tlsConfig}
+	}
+	// h2c, http2 without tls
+	return &http2.Transport{
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+		AllowHTTP: true,
+	}
+}
+
+func (s *HTTPChecker) CheckHealth() bool {
+	req, err := http.NewRequest(s.method, s.url, nil)
+	if err != nil {
+		logger.Infof("[health check] http checker for %s new request error: %v", s.url, err)
+		return false
+	}
+	if s.host != "" {
+		req.Host = s.host
+	}
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		logger.Infof("[health check] http checker for %s error: %v", s.url, err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	if !s.matchStatus(resp.StatusCode) {
+		logger.Infof("[health check] http checker for %s unexpected status: %d", s.url, resp.StatusCode)
+		return false
+	}
+	if len(s.expectedBody) == 0 {
+		return true
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHttpBodyCheckSize))
+	if err != nil {
+		logger.Infof("[health check] http checker for %s read body error: %v", s.url, err)
+		return false
+	}
+	if !bytes.Contains(body, s.expectedBody) {
+		logger.Infof("[health check] http checker for %s body not match", s.url)
+		return false
+	}
+	return true
+}
+
+func (s *HTTPChecker) matchStatus(status int) bool {
+	for _, r := range s.statuses {
+		if r.Contains(status) {
+			return true
+		}
+	}
+	return false
+}
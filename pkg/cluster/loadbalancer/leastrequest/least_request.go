@@ -0,0 +1,59 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package leastrequest
+
+import (
+	"math/rand"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func init() {
+	loadbalancer.RegisterLoadBalancer(model.LoadBalancerLeastRequest, LeastRequest{})
+}
+
+// LeastRequest pick the endpoint with the fewest in-flight requests, ties are broken randomly
+type LeastRequest struct{}
+
+func (LeastRequest) Handler(c *model.ClusterConfig, _ model.LbPolicy) *model.Endpoint {
+	var (
+		picked *model.Endpoint
+		least  int64
+		ties   int
+	)
+	for _, e := range c.Endpoints {
+		if !e.IsAvailable() {
+			continue
+		}
+		active := e.ActiveRequests()
+		switch {
+		case picked == nil || active < least:
+			picked, least, ties = e, active, 1
+		case active == least:
+			// reservoir sampling, every endpoint with the least requests has the same chance
+			ties++
+			if rand.Intn(ties) == 0 {
+				picked = e
+			}
+		}
+	}
+	return picked
+}
@@ -18,16 +18,43 @@
 package loadbalancer
 
 import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/util/stringutil"
 	"github.com/apache/dubbo-go-pixiu/pkg/model"
 )
 
+const (
+	DefaultSlowStartAggression       = 1.0
+	DefaultSlowStartMinWeightPercent = 10
+)
+
 type LoadBalancer interface {
 	Handler(c *model.ClusterConfig, policy model.LbPolicy) *model.Endpoint
 }
 
+// ClusterRemover the load balancer keeping state per cluster forgets the cluster when it is removed
+type ClusterRemover interface {
+	// RemoveCluster forget the state of the cluster, including the state of its subsets
+	RemoveCluster(name string)
+}
+
 // LoadBalancerStrategy load balancer strategy mode
 var LoadBalancerStrategy = map[model.LbPolicyType]LoadBalancer{}
 
+// RemoveCluster notify the load balancers keeping state per cluster that the cluster is removed
+func RemoveCluster(name string) {
+	for _, lb := range LoadBalancerStrategy {
+		if r, ok := lb.(ClusterRemover); ok {
+			r.RemoveCluster(name)
+		}
+	}
+}
+
 func RegisterLoadBalancer(name model.LbPolicyType, balancer LoadBalancer) {
 	if _, ok := LoadBalancerStrategy[name]; ok {
 		panic("load balancer register fail " + name)
@@ -41,3 +68,70 @@ func RegisterConsistentHashInit(name model.LbPolicyType, function model.Consiste
 	}
 	model.ConsistentHashInitMap[name] = function
 }
+
+// GenerateHash the hash key of the request for consistent hash load balancers
+func GenerateHash(c *model.ClusterConfig, policy model.LbPolicy) string {
+	if p, ok := policy.(model.HashPolicyLbPolicy); ok {
+		return p.GenerateHashWithPolicy(c.HashPolicy)
+	}
+	return policy.GenerateHash()
+}
+
+// NormalizeWeights the weights of endpoints divided by their greatest common divisor,
+// so that the hash balancers don't create too many virtual nodes for large weights like 100, 200
+func NormalizeWeights(endpoints []*model.Endpoint) []uint32 {
+	weights := make([]uint32, len(endpoints))
+	var divisor uint32
+	for i, e := range endpoints {
+		weights[i] = e.GetWeight()
+		divisor = gcd(divisor, weights[i])
+	}
+	for i := range weights {
+		weights[i] /= divisor
+	}
+	return weights
+}
+
+func gcd(a, b uint32) uint32 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// SlowStartFactor the fraction of its weight the endpoint deserves, it is less than 1 while the endpoint warms up
+func SlowStartFactor(c *model.ClusterConfig, e *model.Endpoint, now time.Time) float64 {
+	if c.SlowStartConfig == nil {
+		return 1
+	}
+	since := e.WarmupSince()
+	if since.IsZero() {
+		return 1
+	}
+	window := stringutil.ResolveTimeStr2Time(c.SlowStartConfig.SlowStartWindow, 0)
+	elapsed := now.Sub(since)
+	if window <= 0 || elapsed >= window {
+		return 1
+	}
+
+	aggression := c.SlowStartConfig.Aggression
+	if aggression <= 0 {
+		aggression = DefaultSlowStartAggression
+	}
+	minPercent := c.SlowStartConfig.MinWeightPercent
+	if minPercent <= 0 || minPercent > 100 {
+		minPercent = DefaultSlowStartMinWeightPercent
+	}
+	factor := math.Pow(math.Max(float64(elapsed)/float64(window), 0), 1/aggression)
+	return math.Max(factor, float64(minPercent)/100)
+}
+
+// AcceptSlowStart decide whether the picked endpoint is used in proportion to its slow start factor,
+// the unweighted balancers pick another endpoint if it is refused
+func AcceptSlowStart(c *model.ClusterConfig, e *model.Endpoint) bool {
+	if c.SlowStartConfig == nil {
+		return true
+	}
+	factor := SlowStartFactor(c, e, time.Now())
+	return factor >= 1 || rand.Float64() < factor
+}
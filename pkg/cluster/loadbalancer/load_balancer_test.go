@@ -0,0 +1,54 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loadbalancer
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestSlowStartFactor(t *testing.T) {
+	c := &model.ClusterConfig{}
+	e := &model.Endpoint{ID: "1"}
+	now := time.Now()
+	assert.Equal(t, 1.0, SlowStartFactor(c, e, now))
+
+	c.SlowStartConfig = &model.SlowStartConfig{SlowStartWindow: "100s"}
+	// the endpoint never warms up, e.g. loaded from static config
+	assert.Equal(t, 1.0, SlowStartFactor(c, e, now))
+
+	e.StartWarmup()
+	since := e.WarmupSince()
+	assert.InDelta(t, 0.1, SlowStartFactor(c, e, since), 1e-9)
+	assert.InDelta(t, 0.5, SlowStartFactor(c, e, since.Add(50*time.Second)), 1e-9)
+	assert.Equal(t, 1.0, SlowStartFactor(c, e, since.Add(100*time.Second)))
+
+	c.SlowStartConfig.MinWeightPercent = 30
+	assert.InDelta(t, 0.3, SlowStartFactor(c, e, since.Add(10*time.Second)), 1e-9)
+
+	c.SlowStartConfig.Aggression = 2
+	assert.InDelta(t, 0.5, SlowStartFactor(c, e, since.Add(25*time.Second)), 1e-9)
+}
@@ -35,7 +35,7 @@ func NewMaglevHash(config model.ConsistentHash, endpoints []*model.Endpoint) mod
 		hosts[i] = endpoint.GetHost()
 	}
 
-	h, err := NewLookUpTable(config.MaglevTableSize, hosts)
+	h, err := NewWeightedLookUpTable(config.MaglevTableSize, hosts, loadbalancer.NormalizeWeights(endpoints))
 	if err == nil {
 		h.Populate()
 		return h
@@ -51,7 +51,7 @@ func NewMaglevHash(config model.ConsistentHash, endpoints []*model.Endpoint) mod
 type MaglevHash struct{}
 
 func (m MaglevHash) Handler(c *model.ClusterConfig, policy model.LbPolicy) *model.Endpoint {
-	dst, err := c.ConsistentHash.Hash.Get(policy.GenerateHash())
+	dst, err := c.ConsistentHash.Hash.Get(loadbalancer.GenerateHash(c, policy))
 	if err != nil {
 		logger.Warnf("[dubbo-go-pixiu] error of getting from maglev hash: %v", err)
 		return nil
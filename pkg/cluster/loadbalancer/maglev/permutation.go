@@ -41,22 +41,31 @@ var defaultTableSize = []int{
 }
 
 type permutation struct {
-	pos   []uint32
-	next  int
-	index int
-	hit   int
+	pos    []uint32
+	next   int
+	index  int
+	hit    int
+	weight uint32
+	// target the slots the endpoint deserves so far, grows by weight / max weight every round
+	target float64
 }
 
 type LookUpTable struct {
 	slots        []string
 	permutations []*permutation
 	buckets      map[int]string
+	weights      map[int]uint32
 	endpointNum  int
 	size         int
 	sync.RWMutex
 }
 
 func NewLookUpTable(tableSize int, hosts []string) (*LookUpTable, error) {
+	return NewWeightedLookUpTable(tableSize, hosts, nil)
+}
+
+// NewWeightedLookUpTable the endpoints occupy the slots in proportion to weights, weight of hosts[i] is weights[i]
+func NewWeightedLookUpTable(tableSize int, hosts []string, weights []uint32) (*LookUpTable, error) {
 	expectN := len(hosts) * 100
 	if tableSize == 0 {
 		// find closet table size
@@ -74,13 +83,18 @@ func NewLookUpTable(tableSize int, hosts []string) (*LookUpTable, error) {
 	}
 
 	buckets := make(map[int]string, len(hosts))
+	bucketWeights := make(map[int]uint32, len(hosts))
 	for i, host := range hosts {
 		buckets[i] = host
+		if i < len(weights) && weights[i] > 0 {
+			bucketWeights[i] = weights[i]
+		}
 	}
 	n := len(buckets)
 
 	return &LookUpTable{
 		buckets:     buckets,
+		weights:     bucketWeights,
 		endpointNum: n,
 		size:        tableSize,
 	}, nil
@@ -98,12 +112,24 @@ func (t *LookUpTable) Populate() {
 func (t *LookUpTable) populate() {
 	t.slots = make([]string, t.size)
 
+	var maxWeight uint32
+	for _, p := range t.permutations {
+		if p.weight > maxWeight {
+			maxWeight = p.weight
+		}
+	}
+
 	full, miss := 0, 0
 	for miss < t.endpointNum && full < t.size {
 		for _, p := range t.permutations {
 			if p.next == t.size {
 				continue
 			}
+			// the endpoint with less weight skips some rounds
+			p.target += float64(p.weight) / float64(maxWeight)
+			if p.target < float64(p.hit+1) {
+				continue
+			}
 			start := p.next
 			for start < t.size && len(t.slots[p.pos[start]]) > 0 {
 				start++
@@ -160,13 +186,18 @@ func (t *LookUpTable) generatePerm(bucket string, i int) {
 	for j = 0; j < m; j++ {
 		pos[j] = (offs + j*skip) % m
 	}
-	t.permutations = append(t.permutations, &permutation{pos, 0, i, 0})
+	weight, ok := t.weights[i]
+	if !ok {
+		weight = 1
+	}
+	t.permutations = append(t.permutations, &permutation{pos: pos, index: i, weight: weight})
 }
 
 func (t *LookUpTable) resetPerms() {
 	for _, p := range t.permutations {
 		p.next = 0
 		p.hit = 0
+		p.target = 0
 	}
 }
 
@@ -266,6 +297,7 @@ func (t *LookUpTable) remove(host string) bool {
 	for i, bucket := range t.buckets {
 		if bucket == host {
 			delete(t.buckets, i)
+			delete(t.weights, i)
 			t.endpointNum--
 			t.removePerm(i)
 			t.resetPerms()
@@ -276,3 +276,19 @@ func checkConsistency(t *testing.T, table *LookUpTable) {
 			"%s with distributions %d not in %d +/- %d", k, v, avg, ConsistencyToleration)
 	}
 }
+
+func TestWeightedLookUpTable(t *testing.T) {
+	nodes := []string{createEndpoint(1), createEndpoint(2), createEndpoint(3)}
+	table, err := NewWeightedLookUpTable(1511, nodes, []uint32{3, 1, 2})
+	assert.Nil(t, err)
+	table.Populate()
+
+	dist := make(map[string]int)
+	for _, slot := range table.slots {
+		dist[slot]++
+	}
+	// 1511 slots are shared as 3:1:2
+	assert.InDelta(t, 755, dist[nodes[0]], ConsistencyToleration)
+	assert.InDelta(t, 252, dist[nodes[1]], ConsistencyToleration)
+	assert.InDelta(t, 504, dist[nodes[2]], ConsistencyToleration)
+}
@@ -0,0 +1,53 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package p2c
+
+import (
+	"math/rand"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func init() {
+	loadbalancer.RegisterLoadBalancer(model.LoadBalancerP2C, P2C{})
+}
+
+// P2C power of two choices, pick two endpoints randomly and choose the one with fewer in-flight requests
+type P2C struct{}
+
+func (P2C) Handler(c *model.ClusterConfig, _ model.LbPolicy) *model.Endpoint {
+	endpoints := c.GetEndpoint(true)
+	switch len(endpoints) {
+	case 0:
+		return nil
+	case 1:
+		return endpoints[0]
+	}
+	i := rand.Intn(len(endpoints))
+	j := rand.Intn(len(endpoints) - 1)
+	if j >= i {
+		j++
+	}
+	if endpoints[j].ActiveRequests() < endpoints[i].ActiveRequests() {
+		return endpoints[j]
+	}
+	return endpoints[i]
+}
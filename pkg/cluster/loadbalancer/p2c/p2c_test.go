@@ -0,0 +1,60 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package p2c
+
+import (
+	"strconv"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestP2C(t *testing.T) {
+	nodes := make([]*model.Endpoint, 0, 3)
+	for i := 1; i <= 3; i++ {
+		name := strconv.Itoa(i)
+		nodes = append(nodes, &model.Endpoint{ID: name, Name: name,
+			Address: model.SocketAddress{Address: "192.168.1." + name, Port: 1000 + i}})
+	}
+	cluster := &model.ClusterConfig{Name: "cluster1", Endpoints: nodes, LbStr: model.LoadBalancerP2C}
+
+	// the busiest endpoint never wins a comparison
+	nodes[0].IncActiveRequests()
+	nodes[0].IncActiveRequests()
+	nodes[1].IncActiveRequests()
+	lb := P2C{}
+	picked := map[string]int{}
+	for i := 0; i < 300; i++ {
+		picked[lb.Handler(cluster, nil).ID]++
+	}
+	assert.Zero(t, picked["1"])
+	assert.Greater(t, picked["3"], picked["2"])
+
+	nodes[1].UnHealthy = true
+	nodes[2].UnHealthy = true
+	assert.Equal(t, "1", lb.Handler(cluster, nil).ID)
+
+	nodes[0].Ejected = true
+	assert.Nil(t, lb.Handler(cluster, nil))
+}
@@ -33,5 +33,14 @@ func init() {
 type Rand struct{}
 
 func (Rand) Handler(c *model.ClusterConfig, _ model.LbPolicy) *model.Endpoint {
-	return c.GetEndpoint(true)[rand.Intn(len(c.Endpoints)-1)]
+	endpoints := c.GetEndpoint(true)
+	if len(endpoints) == 0 {
+		return nil
+	}
+	e := endpoints[rand.Intn(len(endpoints))]
+	// pick again if the warming endpoint is refused
+	for i := 1; i < len(endpoints) && !loadbalancer.AcceptSlowStart(c, e); i++ {
+		e = endpoints[rand.Intn(len(endpoints))]
+	}
+	return e
 }
@@ -19,6 +19,8 @@ package ringhash
 
 import (
 	"math"
+	"strconv"
+	"strings"
 )
 
 import (
@@ -36,10 +38,19 @@ func init() {
 	loadbalancer.RegisterConsistentHashInit(model.LoadBalancerRingHashing, NewRingHash)
 }
 
+const (
+	// DefaultMaxRingSize the default max number of virtual nodes in the ring
+	DefaultMaxRingSize = 8 * 1024 * 1024
+	// defaultReplicaNum the virtual nodes of each ring member if replica num is not configured
+	defaultReplicaNum = 10
+)
+
 func NewRingHash(config model.ConsistentHash, endpoints []*model.Endpoint) model.LbConsistentHash {
 	var ops []consistent.Option
 
+	replicaNum := defaultReplicaNum
 	if config.ReplicaNum != 0 {
+		replicaNum = config.ReplicaNum
 		ops = append(ops, consistent.WithReplicaNum(config.ReplicaNum))
 	}
 
@@ -50,21 +61,60 @@ func NewRingHash(config model.ConsistentHash, endpoints []*model.Endpoint) model
 	}
 
 	h := consistent.NewConsistentHash(ops...)
-	for _, endpoint := range endpoints {
+	maxRingSize := config.MaxRingSize
+	if maxRingSize <= 0 {
+		maxRingSize = DefaultMaxRingSize
+	}
+	weights := ringWeights(endpoints, maxRingSize/replicaNum)
+	for i, endpoint := range endpoints {
 		h.Add(endpoint.GetHost())
+		// the endpoint with bigger weight owns more virtual nodes in the ring
+		for j := uint32(1); j < weights[i]; j++ {
+			h.Add(endpoint.GetHost() + weightSeparator + strconv.FormatUint(uint64(j), 10))
+		}
 	}
 	return h
 }
 
+// ringWeights the number of ring members of each endpoint, the normalized weights are scaled down if there are more
+// than maxMembers in total, every endpoint keeps one member at least
+func ringWeights(endpoints []*model.Endpoint, maxMembers int) []uint32 {
+	weights := loadbalancer.NormalizeWeights(endpoints)
+	var total uint64
+	for _, w := range weights {
+		total += uint64(w)
+	}
+	if maxMembers <= 0 || total <= uint64(maxMembers) {
+		return weights
+	}
+	for i, w := range weights {
+		weights[i] = uint32(uint64(w) * uint64(maxMembers) / total)
+		if weights[i] == 0 {
+			weights[i] = 1
+		}
+	}
+	return weights
+}
+
+// weightSeparator separate the host and the index of its weighted copies in ring members
+const weightSeparator = "#"
+
+// hostOf the host of the ring member
+func hostOf(member string) string {
+	host, _, _ := strings.Cut(member, weightSeparator)
+	return host
+}
+
 type RingHashing struct{}
 
 func (r RingHashing) Handler(c *model.ClusterConfig, policy model.LbPolicy) *model.Endpoint {
-	u := c.ConsistentHash.Hash.Hash(policy.GenerateHash())
+	u := c.ConsistentHash.Hash.Hash(loadbalancer.GenerateHash(c, policy))
 	hash, err := c.ConsistentHash.Hash.GetHash(u)
 	if err != nil {
 		logger.Warnf("[dubbo-go-pixiu] error of getting from ring hash: %v", err)
 		return nil
 	}
+	hash = hostOf(hash)
 
 	endpoints := c.GetEndpoint(true)
 
@@ -24,6 +24,11 @@ import (
 	"testing"
 )
 
+import (
+	"github.com/dubbogo/gost/hash/consistent"
+	"github.com/stretchr/testify/assert"
+)
+
 import (
 	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
 	"github.com/apache/dubbo-go-pixiu/pkg/model"
@@ -60,3 +65,78 @@ func TestHashRing(t *testing.T) {
 	}
 
 }
+
+func TestWeightedHashRing(t *testing.T) {
+	nodes := []*model.Endpoint{
+		{ID: "1", Address: model.SocketAddress{Address: "192.168.1.1", Port: 1001}, Weight: 300},
+		{ID: "2", Address: model.SocketAddress{Address: "192.168.1.2", Port: 1002}, Weight: 100},
+	}
+	cluster := &model.ClusterConfig{
+		Name:           "cluster1",
+		Endpoints:      nodes,
+		LbStr:          model.LoadBalancerRingHashing,
+		ConsistentHash: model.ConsistentHash{ReplicaNum: 50},
+	}
+	cluster.CreateConsistentHash()
+
+	// weights are divided by gcd, so only 3 + 1 members are in the ring
+	assert.Len(t, cluster.ConsistentHash.Hash.(*consistent.Consistent).Members(), 4)
+
+	hashing := RingHashing{}
+	dist := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		path := fmt.Sprintf("/pixiu?total=%d", i)
+		e := hashing.Handler(cluster, &http.HttpContext{Request: &stdHttp.Request{Method: stdHttp.MethodGet, RequestURI: path}})
+		dist[e.ID]++
+	}
+	assert.Greater(t, dist["1"], 2*dist["2"])
+}
+
+func TestHashRingWithHashPolicy(t *testing.T) {
+	nodes := make([]*model.Endpoint, 0, 5)
+	for i := 1; i <= 5; i++ {
+		name := strconv.Itoa(i)
+		nodes = append(nodes, &model.Endpoint{ID: name, Name: name,
+			Address: model.SocketAddress{Address: "192.168.1." + name, Port: 1000 + i}})
+	}
+	cluster := &model.ClusterConfig{
+		Name:           "cluster1",
+		Endpoints:      nodes,
+		LbStr:          model.LoadBalancerRingHashing,
+		ConsistentHash: model.ConsistentHash{ReplicaNum: 10},
+		HashPolicy:     []model.HashPolicy{{Header: "x-user"}},
+	}
+	cluster.CreateConsistentHash()
+
+	hashing := RingHashing{}
+	picked := make(map[string]struct{})
+	for i := 0; i < 20; i++ {
+		r := &stdHttp.Request{Method: stdHttp.MethodGet, RequestURI: fmt.Sprintf("/pixiu?total=%d", i), Header: stdHttp.Header{}}
+		r.Header.Set("x-user", "alice")
+		picked[hashing.Handler(cluster, &http.HttpContext{Request: r}).ID] = struct{}{}
+	}
+	// the same user always goes to the same endpoint
+	assert.Len(t, picked, 1)
+}
+
+func TestHashRingMaxSize(t *testing.T) {
+	nodes := []*model.Endpoint{
+		{ID: "1", Address: model.SocketAddress{Address: "192.168.1.1", Port: 1001}, Weight: 1000003},
+		{ID: "2", Address: model.SocketAddress{Address: "192.168.1.2", Port: 1002}, Weight: 1},
+		{ID: "3", Address: model.SocketAddress{Address: "192.168.1.3", Port: 1003}, Weight: 1},
+	}
+	cluster := &model.ClusterConfig{
+		Name:           "cluster1",
+		Endpoints:      nodes,
+		LbStr:          model.LoadBalancerRingHashing,
+		ConsistentHash: model.ConsistentHash{ReplicaNum: 10, MaxRingSize: 1000},
+	}
+	cluster.CreateConsistentHash()
+
+	// the weights are scaled down to 100 members, the light endpoints keep one member
+	members := cluster.ConsistentHash.Hash.(*consistent.Consistent).Members()
+	assert.LessOrEqual(t, len(members), 102)
+	assert.Contains(t, members, "192.168.1.2:1002")
+	assert.Contains(t, members, "192.168.1.3:1003")
+	assert.Equal(t, []uint32{99, 1, 1}, ringWeights(nodes, 100))
+}
@@ -31,10 +31,18 @@ type RoundRobin struct{}
 func (RoundRobin) Handler(c *model.ClusterConfig, _ model.LbPolicy) *model.Endpoint {
 	endpoints := c.GetEndpoint(true)
 	lens := len(endpoints)
+	if lens == 0 {
+		return nil
+	}
 	if c.PrePickEndpointIndex >= lens {
 		c.PrePickEndpointIndex = 0
 	}
 	e := endpoints[c.PrePickEndpointIndex]
 	c.PrePickEndpointIndex = (c.PrePickEndpointIndex + 1) % lens
+	// skip the warming endpoints by chance, the first one is used if all of them are skipped
+	for i := 1; i < lens && !loadbalancer.AcceptSlowStart(c, e); i++ {
+		e = endpoints[c.PrePickEndpointIndex]
+		c.PrePickEndpointIndex = (c.PrePickEndpointIndex + 1) % lens
+	}
 	return e
 }
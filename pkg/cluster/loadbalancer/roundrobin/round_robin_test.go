@@ -0,0 +1,59 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package roundrobin
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestRoundRobin(t *testing.T) {
+	c := &model.ClusterConfig{
+		Name:      "cluster1",
+		Endpoints: []*model.Endpoint{{ID: "a"}, {ID: "b"}, {ID: "c"}},
+	}
+	picked := make([]string, 0, 6)
+	for i := 0; i < 6; i++ {
+		picked = append(picked, RoundRobin{}.Handler(c, nil).ID)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "a", "b", "c"}, picked)
+}
+
+func TestRoundRobinSlowStart(t *testing.T) {
+	c := &model.ClusterConfig{
+		Name:            "cluster1",
+		SlowStartConfig: &model.SlowStartConfig{SlowStartWindow: "1h"},
+		Endpoints:       []*model.Endpoint{{ID: "a"}, {ID: "b"}},
+	}
+	c.Endpoints[1].StartWarmup()
+
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		counts[RoundRobin{}.Handler(c, nil).ID]++
+	}
+	// the warming endpoint gets about 10% of its share
+	assert.Greater(t, counts["b"], 0)
+	assert.Less(t, counts["b"], 200)
+}
@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package weightedroundrobin
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// slowStartScale the weights are scaled so that the slow start factor takes effect on small weights
+const slowStartScale = 100
+
+func init() {
+	loadbalancer.RegisterLoadBalancer(model.LoadBalancerWeightedRoundRobin, NewWeightedRoundRobin())
+}
+
+// WeightedRoundRobin smooth weighted round robin, the endpoints are picked in proportion to their
+// weights and interleaved evenly, e.g. weights 5, 1, 1 produce a a b a c a a
+type WeightedRoundRobin struct {
+	lock sync.Mutex
+	// currentWeights cluster name -> endpoint id -> current weight
+	currentWeights map[string]map[string]int64
+}
+
+func NewWeightedRoundRobin() *WeightedRoundRobin {
+	return &WeightedRoundRobin{currentWeights: make(map[string]map[string]int64)}
+}
+
+func (w *WeightedRoundRobin) Handler(c *model.ClusterConfig, _ model.LbPolicy) *model.Endpoint {
+	endpoints := c.GetEndpoint(true)
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	current, ok := w.currentWeights[c.Name]
+	if !ok {
+		current = make(map[string]int64, len(endpoints))
+		w.currentWeights[c.Name] = current
+	}
+
+	var (
+		picked *model.Endpoint
+		total  int64
+		now    = time.Now()
+	)
+	for _, e := range endpoints {
+		weight := int64(e.GetWeight())
+		if c.SlowStartConfig != nil {
+			weight = int64(float64(weight*slowStartScale) * loadbalancer.SlowStartFactor(c, e, now))
+		}
+		current[e.ID] += weight
+		total += weight
+		if picked == nil || current[e.ID] > current[picked.ID] {
+			picked = e
+		}
+	}
+	current[picked.ID] -= total
+
+	// forget the endpoints which are removed or unavailable
+	if len(current) > len(endpoints) {
+		available := make(map[string]struct{}, len(endpoints))
+		for _, e := range endpoints {
+			available[e.ID] = struct{}{}
+		}
+		for id := range current {
+			if _, ok := available[id]; !ok {
+				delete(current, id)
+			}
+		}
+	}
+	return picked
+}
+
+// RemoveCluster forget the current weights of the cluster and of its subsets and localities, whose names are prefixed by it
+func (w *WeightedRoundRobin) RemoveCluster(name string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for n := range w.currentWeights {
+		if n == name || strings.HasPrefix(n, name+"[") {
+			delete(w.currentWeights, n)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package weightedroundrobin
+
+import (
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestWeightedRoundRobin(t *testing.T) {
+	cluster := &model.ClusterConfig{
+		Name:  "cluster1",
+		LbStr: model.LoadBalancerWeightedRoundRobin,
+		Endpoints: []*model.Endpoint{
+			{ID: "a", Weight: 5},
+			{ID: "b"},
+			{ID: "c", Weight: 1},
+		},
+	}
+
+	lb := NewWeightedRoundRobin()
+	picked := make([]string, 0, 14)
+	for i := 0; i < 14; i++ {
+		picked = append(picked, lb.Handler(cluster, nil).ID)
+	}
+	assert.Equal(t, "aabacaaaabacaa", strings.Join(picked, ""))
+
+	cluster.Endpoints[0].UnHealthy = true
+	picked = picked[:0]
+	for i := 0; i < 4; i++ {
+		picked = append(picked, lb.Handler(cluster, nil).ID)
+	}
+	assert.Equal(t, []string{"b", "c", "b", "c"}, picked)
+
+	cluster.Endpoints[1].Ejected = true
+	cluster.Endpoints[2].UnHealthy = true
+	assert.Nil(t, lb.Handler(cluster, nil))
+}
+
+func TestWeightedRoundRobinSlowStart(t *testing.T) {
+	cluster := &model.ClusterConfig{
+		Name:            "cluster1",
+		LbStr:           model.LoadBalancerWeightedRoundRobin,
+		SlowStartConfig: &model.SlowStartConfig{SlowStartWindow: "1h"},
+		Endpoints: []*model.Endpoint{
+			{ID: "a"},
+			{ID: "b"},
+		},
+	}
+	cluster.Endpoints[1].StartWarmup()
+
+	lb := NewWeightedRoundRobin()
+	counts := make(map[string]int)
+	for i := 0; i < 110; i++ {
+		counts[lb.Handler(cluster, nil).ID]++
+	}
+	// the warming endpoint starts from 10% of its weight
+	assert.Equal(t, 100, counts["a"])
+	assert.Equal(t, 10, counts["b"])
+}
+
+func TestWeightedRoundRobinRemoveCluster(t *testing.T) {
+	lb := NewWeightedRoundRobin()
+	for _, name := range []string{"cluster1", "cluster1[version=v1]", "cluster10"} {
+		lb.Handler(&model.ClusterConfig{Name: name, Endpoints: []*model.Endpoint{{ID: "a"}}}, nil)
+	}
+	assert.Equal(t, 3, len(lb.currentWeights))
+
+	lb.RemoveCluster("cluster1")
+	assert.Equal(t, 1, len(lb.currentWeights))
+	assert.Contains(t, lb.currentWeights, "cluster10")
+}
@@ -0,0 +1,159 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"sort"
+	"strconv"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// priorityLevels the endpoints of a cluster or subset grouped by priority and locality
+type priorityLevels []*priorityLevel
+
+type priorityLevel struct {
+	priority uint32
+	all      *model.ClusterConfig
+	// regions region -> endpoints of the region
+	regions map[string]*model.ClusterConfig
+	// zones region/zone -> endpoints of the zone
+	zones map[string]*model.ClusterConfig
+}
+
+// updateLocalities recompute the priority levels of the cluster and its subsets
+func (c *Cluster) updateLocalities(config *model.ClusterConfig) {
+	if config.LocalityLbConfig == nil {
+		c.localities = nil
+		return
+	}
+	c.localities = map[string]priorityLevels{config.Name: newPriorityLevels(config)}
+	if c.subsets == nil {
+		return
+	}
+	for _, subset := range c.subsets.bySelector {
+		c.localities[subset.Name] = newPriorityLevels(subset)
+	}
+	if c.subsets.defaultSubset != nil {
+		c.localities[c.subsets.defaultSubset.Name] = newPriorityLevels(c.subsets.defaultSubset)
+	}
+}
+
+// Locality the endpoints of the highest healthy priority level, the endpoints in the same zone or region as
+// local are preferred if enough of them are available. nil means no endpoint is available
+func (c *Cluster) Locality(config *model.ClusterConfig, lbConfig *model.LocalityLbConfig, local *model.Locality) *model.ClusterConfig {
+	levels, ok := c.localities[config.Name]
+	if !ok {
+		return config
+	}
+	factor := lbConfig.OverprovisioningFactor
+	if factor <= 0 {
+		factor = model.DefaultOverprovisioningFactor
+	}
+	var level *priorityLevel
+	for _, l := range levels {
+		available, total := countAvailable(l.all)
+		if available*factor >= total*100 {
+			level = l
+			break
+		}
+		// all priorities are degraded, use the first one which still has available endpoints
+		if level == nil && available > 0 {
+			level = l
+		}
+	}
+	if level == nil {
+		return nil
+	}
+	if local == nil {
+		return level.all
+	}
+
+	threshold := lbConfig.LocalHealthyPercent
+	if threshold <= 0 {
+		threshold = model.DefaultLocalHealthyPercent
+	}
+	healthy := func(cfg *model.ClusterConfig) bool {
+		if cfg == nil {
+			return false
+		}
+		available, total := countAvailable(cfg)
+		return available > 0 && available*100 >= total*threshold
+	}
+	if zone := level.zones[zoneKey(*local)]; local.Zone != "" && healthy(zone) {
+		return zone
+	}
+	if region := level.regions[local.Region]; local.Region != "" && healthy(region) {
+		return region
+	}
+	return level.all
+}
+
+func newPriorityLevels(config *model.ClusterConfig) priorityLevels {
+	endpoints := make(map[uint32][]*model.Endpoint)
+	for _, e := range config.Endpoints {
+		p := e.GetPriority()
+		endpoints[p] = append(endpoints[p], e)
+	}
+	levels := make(priorityLevels, 0, len(endpoints))
+	for p, es := range endpoints {
+		regions := make(map[string][]*model.Endpoint)
+		zones := make(map[string][]*model.Endpoint)
+		for _, e := range es {
+			locality := e.GetLocality()
+			if locality.Region != "" {
+				regions[locality.Region] = append(regions[locality.Region], e)
+			}
+			if locality.Zone != "" {
+				zones[zoneKey(locality)] = append(zones[zoneKey(locality)], e)
+			}
+		}
+		level := &priorityLevel{
+			priority: p,
+			all:      newSubset(config, "priority="+strconv.FormatUint(uint64(p), 10), es),
+			regions:  make(map[string]*model.ClusterConfig, len(regions)),
+			zones:    make(map[string]*model.ClusterConfig, len(zones)),
+		}
+		for region, es := range regions {
+			level.regions[region] = newSubset(level.all, "region="+region, es)
+		}
+		for zone, es := range zones {
+			level.zones[zone] = newSubset(level.all, "zone="+zone, es)
+		}
+		levels = append(levels, level)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i].priority < levels[j].priority
+	})
+	return levels
+}
+
+func countAvailable(config *model.ClusterConfig) (available int, total int) {
+	for _, e := range config.Endpoints {
+		if e.IsAvailable() {
+			available++
+		}
+	}
+	return available, len(config.Endpoints)
+}
+
+func zoneKey(l model.Locality) string {
+	return l.Region + "/" + l.Zone
+}
@@ -0,0 +1,184 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/util/stringutil"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const (
+	DefaultBaseEjectionTime   = 30 * time.Second
+	DefaultMaxEjectionTime    = 300 * time.Second
+	DefaultMaxEjectionPercent = 10
+)
+
+// Result the result of one upstream call, reported by proxy filters
+type Result int
+
+const (
+	// ResultSuccess upstream responded normally
+	ResultSuccess Result = iota
+	// ResultServerError upstream responded 5xx or an equivalent rpc error
+	ResultServerError
+	// ResultConnectFailure gateway failed to connect to upstream
+	ResultConnectFailure
+)
+
+type (
+	// OutlierDetector counts the results of real traffic and decides when to eject an endpoint
+	OutlierDetector struct {
+		consecutive5xx            uint32
+		consecutiveConnectFailure uint32
+		baseEjectionTime          time.Duration
+		maxEjectionTime           time.Duration
+		maxEjectionPercent        int
+
+		mu    sync.Mutex
+		stats map[string]*outlierStat
+	}
+
+	outlierStat struct {
+		consecutive5xx            uint32
+		consecutiveConnectFailure uint32
+		ejectTimes                uint32
+		ejected                   bool
+		lastUnejected             time.Time
+	}
+)
+
+// ResultOfHttpStatus convert http status code to result
+func ResultOfHttpStatus(code int) Result {
+	if code >= http.StatusInternalServerError {
+		return ResultServerError
+	}
+	return ResultSuccess
+}
+
+// NewOutlierDetector create outlier detector, return nil if the config is nil
+func NewOutlierDetector(cfg *model.OutlierDetection) *OutlierDetector {
+	if cfg == nil {
+		return nil
+	}
+	maxPercent := cfg.MaxEjectionPercent
+	if maxPercent <= 0 || maxPercent > 100 {
+		maxPercent = DefaultMaxEjectionPercent
+	}
+	return &OutlierDetector{
+		consecutive5xx:            cfg.Consecutive5xx,
+		consecutiveConnectFailure: cfg.ConsecutiveConnectFailure,
+		baseEjectionTime:          stringutil.ResolveTimeStr2Time(cfg.BaseEjectionTime, DefaultBaseEjectionTime),
+		maxEjectionTime:           stringutil.ResolveTimeStr2Time(cfg.MaxEjectionTime, DefaultMaxEjectionTime),
+		maxEjectionPercent:        maxPercent,
+		stats:                     make(map[string]*outlierStat),
+	}
+}
+
+// Report record the result of a call to endpoint, return the ejection duration and true if the endpoint should be ejected
+func (d *OutlierDetector) Report(endpoint *model.Endpoint, result Result) (time.Duration, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stat, ok := d.stats[endpoint.ID]
+	if !ok {
+		stat = &outlierStat{}
+		d.stats[endpoint.ID] = stat
+	}
+	// results of in-flight requests to an ejected endpoint are meaningless
+	if stat.ejected {
+		return 0, false
+	}
+
+	switch result {
+	case ResultSuccess:
+		stat.consecutive5xx = 0
+		stat.consecutiveConnectFailure = 0
+		// forget the ejection history once the endpoint behaves well long enough
+		if stat.ejectTimes > 0 && time.Since(stat.lastUnejected) > d.maxEjectionTime {
+			stat.ejectTimes = 0
+		}
+		return 0, false
+	case ResultServerError:
+		stat.consecutive5xx++
+	case ResultConnectFailure:
+		stat.consecutiveConnectFailure++
+		// connect failure is also a kind of gateway failure
+		stat.consecutive5xx++
+	}
+
+	if (d.consecutive5xx > 0 && stat.consecutive5xx >= d.consecutive5xx) ||
+		(d.consecutiveConnectFailure > 0 && stat.consecutiveConnectFailure >= d.consecutiveConnectFailure) {
+		return d.ejectionTime(stat), true
+	}
+	return 0, false
+}
+
+// ejectionTime the ejection time grows with the times the endpoint has been ejected
+func (d *OutlierDetector) ejectionTime(stat *outlierStat) time.Duration {
+	t := d.baseEjectionTime * time.Duration(stat.ejectTimes+1)
+	if t > d.maxEjectionTime {
+		t = d.maxEjectionTime
+	}
+	return t
+}
+
+// CanEject check the max ejection percent guard, at least one endpoint can be ejected as envoy does
+func (d *OutlierDetector) CanEject(ejected, total int) bool {
+	if total <= 0 {
+		return false
+	}
+	return ejected == 0 || (ejected+1)*100 <= total*d.maxEjectionPercent
+}
+
+// OnEject mark the endpoint is ejected
+func (d *OutlierDetector) OnEject(endpoint *model.Endpoint) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if stat, ok := d.stats[endpoint.ID]; ok {
+		stat.ejected = true
+		stat.ejectTimes++
+		stat.consecutive5xx = 0
+		stat.consecutiveConnectFailure = 0
+	}
+}
+
+// OnUneject mark the endpoint returns from ejection
+func (d *OutlierDetector) OnUneject(endpoint *model.Endpoint) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if stat, ok := d.stats[endpoint.ID]; ok {
+		stat.ejected = false
+		stat.lastUnejected = time.Now()
+	}
+}
+
+// Remove forget the stat of endpoint
+func (d *OutlierDetector) Remove(endpoint *model.Endpoint) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.stats, endpoint.ID)
+}
@@ -0,0 +1,153 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"sort"
+	"strings"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// subsets the endpoint subsets of cluster divided by metadata, they are guarded by the lock of cluster manager
+type subsets struct {
+	config *model.LbSubsetConfig
+	// bySelector subset key -> subset, the key is like region=hz,version=v2
+	bySelector    map[string]*model.ClusterConfig
+	defaultSubset *model.ClusterConfig
+}
+
+// UpdateSubsets recompute the endpoint subsets
+func (c *Cluster) UpdateSubsets(config *model.ClusterConfig) {
+	if config.LbSubsetConfig == nil {
+		c.subsets = nil
+		return
+	}
+	s := &subsets{
+		config:     config.LbSubsetConfig,
+		bySelector: make(map[string]*model.ClusterConfig),
+	}
+	for _, selector := range config.LbSubsetConfig.SubsetSelectors {
+		if len(selector.Keys) == 0 {
+			continue
+		}
+		groups := make(map[string][]*model.Endpoint)
+		for _, e := range config.Endpoints {
+			if match, ok := labelsOf(e, selector.Keys); ok {
+				key := subsetKey(match)
+				groups[key] = append(groups[key], e)
+			}
+		}
+		for key, endpoints := range groups {
+			s.bySelector[key] = newSubset(config, key, endpoints)
+		}
+	}
+	if config.LbSubsetConfig.FallbackPolicy == model.SubsetFallbackDefaultSubset {
+		endpoints := make([]*model.Endpoint, 0)
+		for _, e := range config.Endpoints {
+			if matchMetadata(e, config.LbSubsetConfig.DefaultSubset) {
+				endpoints = append(endpoints, e)
+			}
+		}
+		s.defaultSubset = newSubset(config, subsetKey(config.LbSubsetConfig.DefaultSubset), endpoints)
+	}
+	c.subsets = s
+}
+
+// Subset the endpoint subset selected by the metadata match, fallback policy is applied
+// if the match is empty or the subset has no available endpoint. nil means no endpoint can be used
+func (c *Cluster) Subset(config *model.ClusterConfig, match map[string]string) *model.ClusterConfig {
+	s := c.subsets
+	if s == nil {
+		return config
+	}
+	if len(match) > 0 {
+		if subset, ok := s.bySelector[subsetKey(match)]; ok && hasAvailable(subset) {
+			return subset
+		}
+	}
+	switch s.config.FallbackPolicy {
+	case model.SubsetFallbackNone:
+		return nil
+	case model.SubsetFallbackDefaultSubset:
+		return s.defaultSubset
+	default:
+		return config
+	}
+}
+
+// newSubset the subset shares the load balance config of cluster, the name is distinguished for stateful balancers
+func newSubset(config *model.ClusterConfig, key string, endpoints []*model.Endpoint) *model.ClusterConfig {
+	subset := &model.ClusterConfig{
+		Name:            config.Name + "[" + key + "]",
+		LbStr:           config.LbStr,
+		HashPolicy:      config.HashPolicy,
+		SlowStartConfig: config.SlowStartConfig,
+		Endpoints:       endpoints,
+		ConsistentHash: model.ConsistentHash{
+			ReplicaNum:      config.ConsistentHash.ReplicaNum,
+			MaxVnodeNum:     config.ConsistentHash.MaxVnodeNum,
+			MaglevTableSize: config.ConsistentHash.MaglevTableSize,
+		},
+	}
+	subset.CreateConsistentHash()
+	return subset
+}
+
+// labelsOf the metadata of the keys, false if any key is missing
+func labelsOf(e *model.Endpoint, keys []string) (map[string]string, bool) {
+	labels := make(map[string]string, len(keys))
+	for _, k := range keys {
+		v, ok := e.Metadata[k]
+		if !ok {
+			return nil, false
+		}
+		labels[k] = v
+	}
+	return labels, true
+}
+
+func matchMetadata(e *model.Endpoint, match map[string]string) bool {
+	for k, v := range match {
+		if e.Metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func hasAvailable(subset *model.ClusterConfig) bool {
+	for _, e := range subset.Endpoints {
+		if e.IsAvailable() {
+			return true
+		}
+	}
+	return false
+}
+
+// subsetKey the sorted labels like region=hz,version=v2
+func subsetKey(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
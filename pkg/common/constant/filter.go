@@ -46,4 +46,13 @@ const (
 	Console = "console"
 
 	DefaultReqTimeout = 10 * time.Second
+
+	DefaultWebSocketIdleTimeout = 5 * time.Minute
+
+	DefaultUDPSessionIdleTimeout = time.Minute
+
+	DefaultTCPConnectTimeout = 5 * time.Second
+	DefaultTCPIdleTimeout    = time.Hour
+
+	DefaultTLSReloadInterval = 30 * time.Second
 )
@@ -27,12 +27,20 @@ const (
 	HeaderKeyAccessControlMaxAge           = "Access-Control-Max-Age"
 	HeaderKeyAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
 
+	HeaderKeyConnection = "Connection"
+	HeaderKeyUpgrade    = "Upgrade"
+
+	HeaderKeyAuthorization = "Authorization"
+
 	HeaderValueJsonUtf8        = "application/json;charset=UTF-8"
 	HeaderValueTextPlain       = "text/plain"
 	HeaderValueApplicationJson = "application/json"
 
 	HeaderValueAll = "*"
 
+	HeaderValueUpgrade   = "upgrade"
+	HeaderValueWebSocket = "websocket"
+
 	PathSlash           = "/"
 	ProtocolSlash       = "://"
 	PathParamIdentifier = ":"
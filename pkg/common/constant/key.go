@@ -21,6 +21,8 @@ const (
 	HTTPConnectManagerFilter  = "dgp.filter.httpconnectionmanager"
 	GRPCConnectManagerFilter  = "dgp.filter.grpcconnectionmanager"
 	DubboConnectManagerFilter = "dgp.filter.network.dubboconnectionmanager"
+	UDPProxyFilter            = "dgp.filter.network.udpproxy"
+	TCPProxyFilter            = "dgp.filter.network.tcpproxy"
 
 	HTTPAuthorityFilter        = "dgp.filter.http.authority"
 	HTTPProxyFilter            = "dgp.filter.http.httpproxy"
@@ -40,6 +42,9 @@ const (
 	HTTPWasmFilter             = "dgp.filter.http.webassembly"
 	HTTPCircuitBreakerFilter   = "dgp.filter.http.circuitbreaker"
 	HTTPAuthJwtFilter          = "dgp.filter.http.auth.jwt"
+	HTTPAuthMTLSFilter         = "dgp.filter.http.auth.mtls"
+	HTTPAuthExtAuthzFilter     = "dgp.filter.http.auth.extauthz"
+	HTTPAuthApiKeyFilter       = "dgp.filter.http.auth.apikey"
 	HTTPCorsFilter             = "dgp.filter.http.cors"
 	HTTPCsrfFilter             = "dgp.filter.http.csrf"
 	HTTPProxyRewriteFilter     = "dgp.filter.http.proxyrewrite"
@@ -48,6 +53,8 @@ const (
 	HTTPTrafficFilter          = "dgp.filter.http.traffic"
 	HTTPPrometheusMetricFilter = "dgp.filter.http.prometheusmetric"
 	HTTPFailInjectFilter       = "dgp.filter.http.faultinjection"
+	HTTPLocalRateLimitFilter   = "dgp.filter.http.localratelimit"
+	HTTPGlobalRateLimitFilter  = "dgp.filter.http.globalratelimit"
 
 	DubboHttpFilter  = "dgp.filter.dubbo.http"
 	DubboProxyFilter = "dgp.filter.dubbo.proxy"
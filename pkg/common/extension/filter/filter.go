@@ -20,6 +20,7 @@ package filter
 import (
 	"context"
 	"fmt"
+	"net"
 	stdHttp "net/http"
 )
 
@@ -97,6 +98,15 @@ type (
 		OnData(data interface{}) (interface{}, error)
 		// OnTripleData triple rpc invocation from triple-server
 		OnTripleData(ctx context.Context, methodName string, arguments []interface{}) (interface{}, error)
+		// OnDatagram handle datagram received from udp listener, conn is used to reply to the client
+		OnDatagram(conn net.PacketConn, addr net.Addr, data []byte) error
+	}
+
+	// ConnectionNetworkFilter network filter which handles the raw byte stream of tcp connection
+	// instead of the packages decoded by getty
+	ConnectionNetworkFilter interface {
+		// OnConnection take over the connection accepted by tcp listener, the filter is responsible to close it
+		OnConnection(conn net.Conn)
 	}
 
 	// EmptyNetworkFilter default empty network filter adapter which offers empty function implements
@@ -145,6 +155,11 @@ func (enf *EmptyNetworkFilter) OnTripleData(ctx context.Context, methodName stri
 	panic("OnTripleData is not implemented")
 }
 
+// OnDatagram empty implement
+func (enf *EmptyNetworkFilter) OnDatagram(conn net.PacketConn, addr net.Addr, data []byte) error {
+	panic("OnDatagram is not implemented")
+}
+
 // ServeHTTP empty implement
 func (enf *EmptyNetworkFilter) ServeHTTP(w stdHttp.ResponseWriter, r *stdHttp.Request) {
 	panic("ServeHTTP is not implemented")
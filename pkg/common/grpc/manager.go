@@ -25,6 +25,7 @@ import (
 	"io"
 	"net"
 	stdHttp "net/http"
+	"sync"
 )
 
 import (
@@ -35,6 +36,7 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
 	router2 "github.com/apache/dubbo-go-pixiu/pkg/common/router"
 	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
@@ -48,19 +50,32 @@ type GrpcConnectionManager struct {
 	filter.EmptyNetworkFilter
 	config            *model.GRPCConnectionManagerConfig
 	routerCoordinator *router2.RouterCoordinator
+	// plainForwarder and forwarders cache the transports of each cluster, so that connections are reused.
+	// the forwarder of cluster is replaced when its tls config changes
+	plainForwarder *HttpForwarder
+	mu             sync.RWMutex
+	forwarders     map[string]*clusterForwarder
+}
+
+// clusterForwarder the forwarder of a cluster and the tls config which it is created with
+type clusterForwarder struct {
+	tlsConfig *tls.Config
+	forwarder *HttpForwarder
 }
 
 // CreateGrpcConnectionManager create grpc connection manager
 func CreateGrpcConnectionManager(hcmc *model.GRPCConnectionManagerConfig) *GrpcConnectionManager {
-	hcm := &GrpcConnectionManager{config: hcmc}
+	hcm := &GrpcConnectionManager{config: hcmc, forwarders: make(map[string]*clusterForwarder)}
 	hcm.routerCoordinator = router2.CreateRouterCoordinator(&hcmc.RouteConfig)
+	hcm.plainForwarder = hcm.newHttpForwarder(nil)
 	return hcm
 }
 
 // ServeHTTP handle request and response
 func (gcm *GrpcConnectionManager) ServeHTTP(w stdHttp.ResponseWriter, r *stdHttp.Request) {
 
-	ra, err := gcm.routerCoordinator.RouteByPathAndName(r.RequestURI, r.Method)
+	hc := &http.HttpContext{Request: r, Writer: w}
+	ra, err := gcm.routerCoordinator.Route(hc)
 	if err != nil {
 		logger.Infof("GrpcConnectionManager can't find route %v", err)
 		gcm.writeStatus(w, status.New(codes.NotFound, fmt.Sprintf("proxy can't find route error = %v", err)))
@@ -71,34 +86,58 @@ func (gcm *GrpcConnectionManager) ServeHTTP(w stdHttp.ResponseWriter, r *stdHttp
 
 	clusterName := ra.Cluster
 	clusterManager := server.GetClusterManager()
-	endpoint := clusterManager.PickEndpoint(clusterName, &http.HttpContext{Request: r})
+	hc.Route = ra
+	endpoint := clusterManager.PickEndpoint(clusterName, hc)
 	if endpoint == nil {
 		logger.Infof("GrpcConnectionManager can't find endpoint in cluster")
 		gcm.writeStatus(w, status.New(codes.Unknown, "can't find endpoint in cluster"))
 		return
 	}
-	ctx := context.Background()
+	tlsConfig, err := clusterManager.UpstreamTlsConfig(clusterName)
+	if err != nil {
+		logger.Errorf("GrpcConnectionManager %v", err)
+		gcm.writeStatus(w, status.New(codes.Unavailable, err.Error()))
+		return
+	}
+
+	release, err := clusterManager.AcquireResource(r.Context(), clusterName, cluster.ResourceRequest)
+	if err != nil {
+		logger.Infof("GrpcConnectionManager %v", err)
+		gcm.writeStatus(w, status.New(codes.Unavailable, err.Error()))
+		return
+	}
+	defer release()
+
+	endpoint.IncActiveRequests()
+	defer endpoint.DecActiveRequests()
+
+	// the new connection is counted by the max connections of cluster
+	ctx := server.WithCluster(context.Background(), clusterName)
 	// timeout
 	ctx, cancel := context.WithTimeout(ctx, gcm.config.Timeout)
 	defer cancel()
 	newReq := r.Clone(ctx)
 	newReq.URL.Scheme = "http"
+	if tlsConfig != nil {
+		newReq.URL.Scheme = "https"
+	}
 	newReq.URL.Host = endpoint.Address.GetAddress()
 
-	// todo: need cache?
-	forwarder := gcm.newHttpForwarder()
-	res, err := forwarder.Forward(newReq)
+	res, err := gcm.forwarder(clusterName, tlsConfig).Forward(newReq)
 
 	if err != nil {
 		logger.Infof("GrpcConnectionManager forward request error %v", err)
 		if err == context.DeadlineExceeded {
+			clusterManager.ReportResult(clusterName, endpoint, cluster.ResultServerError)
 			gcm.writeStatus(w, status.New(codes.DeadlineExceeded, fmt.Sprintf("forward timeout error = %v", err)))
 			return
 		}
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultConnectFailure)
 		gcm.writeStatus(w, status.New(codes.Unknown, fmt.Sprintf("forward error not = %v", err)))
 		return
 	}
 
+	clusterManager.ReportResult(clusterName, endpoint, cluster.ResultOfHttpStatus(res.StatusCode))
 	if err := gcm.response(w, res); err != nil {
 		logger.Infof("GrpcConnectionManager response  error %v", err)
 	}
@@ -142,10 +181,61 @@ func (gcm *GrpcConnectionManager) response(w stdHttp.ResponseWriter, res *stdHtt
 	return nil
 }
 
-func (gcm *GrpcConnectionManager) newHttpForwarder() *HttpForwarder {
+// forwarder return the cached forwarder of cluster for the tls config, the h2c one if tlsConfig is nil
+func (gcm *GrpcConnectionManager) forwarder(clusterName string, tlsConfig *tls.Config) *HttpForwarder {
+	gcm.mu.RLock()
+	f, ok := gcm.forwarders[clusterName]
+	gcm.mu.RUnlock()
+	if ok && f.tlsConfig == tlsConfig {
+		return f.forwarder
+	}
+	if !ok && tlsConfig == nil {
+		return gcm.plainForwarder
+	}
+
+	gcm.mu.Lock()
+	defer gcm.mu.Unlock()
+	f, ok = gcm.forwarders[clusterName]
+	if ok && f.tlsConfig == tlsConfig {
+		return f.forwarder
+	}
+	if ok {
+		delete(gcm.forwarders, clusterName)
+		f.forwarder.transport.CloseIdleConnections()
+	}
+	if tlsConfig == nil {
+		return gcm.plainForwarder
+	}
+	f = &clusterForwarder{tlsConfig: tlsConfig, forwarder: gcm.newHttpForwarder(tlsConfig)}
+	gcm.forwarders[clusterName] = f
+	return f.forwarder
+}
+
+// newHttpForwarder create forwarder which uses tls if tlsConfig is not nil, otherwise h2c. the connections are
+// limited by the max connections of cluster
+func (gcm *GrpcConnectionManager) newHttpForwarder(tlsConfig *tls.Config) *HttpForwarder {
+	dial := server.LimitConnections((&net.Dialer{}).DialContext)
+	if tlsConfig != nil {
+		transport := &http2.Transport{
+			TLSClientConfig: tlsConfig,
+			DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+				conn, err := dial(ctx, network, addr)
+				if err != nil {
+					return nil, err
+				}
+				tlsConn := tls.Client(conn, cfg)
+				if err := tlsConn.HandshakeContext(ctx); err != nil {
+					_ = conn.Close()
+					return nil, err
+				}
+				return tlsConn, nil
+			},
+		}
+		return &HttpForwarder{transport: transport}
+	}
 	transport := &http2.Transport{
-		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
-			return net.Dial(network, addr)
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return dial(ctx, network, addr)
 		},
 		AllowHTTP: true,
 	}
@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	stdHttp "net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+import (
+	"github.com/dubbogo/grpc-go/codes"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestGrpcRoute(t *testing.T) {
+	gcm := CreateGrpcConnectionManager(&model.GRPCConnectionManagerConfig{
+		RouteConfig: model.RouteConfiguration{
+			Routes: []*model.Router{
+				{
+					Match: model.RouterMatch{Prefix: "/helloworld.Greeter/", Methods: []string{stdHttp.MethodPost}},
+					Route: model.RouteAction{Cluster: "greeter"},
+				},
+				{
+					Match: model.RouterMatch{
+						Prefix:  "/helloworld.Greeter/",
+						Methods: []string{stdHttp.MethodPost},
+						Headers: []model.HeaderMatcher{{Name: "X-Env", Values: []string{"canary"}}},
+					},
+					Route: model.RouteAction{Cluster: "canary"},
+				},
+				{
+					Match: model.RouterMatch{Regex: "/routeguide\\.RouteGuide/(GetFeature|ListFeatures)", Methods: []string{stdHttp.MethodPost}},
+					Route: model.RouteAction{Cluster: "route_guide"},
+				},
+			},
+		},
+	})
+
+	tests := []struct {
+		path   string
+		header string
+		expect string
+	}{
+		{path: "/helloworld.Greeter/SayHello", expect: "greeter"},
+		{path: "/helloworld.Greeter/SayHello", header: "canary", expect: "canary"},
+		{path: "/routeguide.RouteGuide/GetFeature", expect: "route_guide"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(stdHttp.MethodPost, tt.path, nil)
+		if tt.header != "" {
+			r.Header.Set("X-Env", tt.header)
+		}
+		ra, err := gcm.routerCoordinator.Route(&http.HttpContext{Request: r})
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expect, ra.Cluster)
+	}
+
+	// no route matched
+	w := httptest.NewRecorder()
+	gcm.ServeHTTP(w, httptest.NewRequest(stdHttp.MethodPost, "/routeguide.RouteGuide/RouteChat", nil))
+	assert.Equal(t, strconv.Itoa(int(codes.NotFound)), w.Header().Get("Grpc-Status"))
+}
@@ -41,6 +41,9 @@ import (
 	"github.com/apache/dubbo-go-pixiu/pkg/model"
 )
 
+// streamBufferSize the buffer size used to copy streaming body
+const streamBufferSize = 32 * 1024
+
 // HttpConnectionManager network filter for http
 type HttpConnectionManager struct {
 	filter.EmptyNetworkFilter
@@ -106,17 +109,75 @@ func (hcm *HttpConnectionManager) handleHTTPRequest(c *pch.HttpContext) {
 
 	//todo timeout
 	filterChain.OnDecode(c)
+	if res, ok := switchingProtocols(c); ok {
+		mergeHeader(c, res)
+		filterChain.OnEncode(c)
+		hcm.serveUpgrade(c, res)
+		c.ResponseWritten(0)
+		return
+	}
 	hcm.buildTargetResponse(c)
 	filterChain.OnEncode(c)
-	hcm.writeResponse(c)
+	c.ResponseWritten(hcm.writeResponse(c))
 }
 
-func (hcm *HttpConnectionManager) writeResponse(c *pch.HttpContext) {
-	if !c.LocalReply() {
-		writer := c.Writer
-		writer.WriteHeader(c.GetStatusCode())
-		if _, err := writer.Write(c.TargetResp.Data); err != nil {
-			logger.Errorf("write response error: %s", err)
+// writeResponse write the response to client and return the size of body written
+func (hcm *HttpConnectionManager) writeResponse(c *pch.HttpContext) int64 {
+	res, streaming := hcm.streamingResponse(c)
+	if streaming {
+		defer res.Body.Close()
+	}
+	if c.LocalReply() {
+		return int64(len(c.GetLocalReplyBody()))
+	}
+
+	writer := c.Writer
+	writer.WriteHeader(c.GetStatusCode())
+	if streaming {
+		n, err := copyWithFlush(writer, res.Body)
+		if err != nil {
+			logger.Errorf("stream response error: %s", err)
+		}
+		return n
+	}
+	n, err := writer.Write(c.TargetResp.Data)
+	if err != nil {
+		logger.Errorf("write response error: %s", err)
+	}
+	return int64(n)
+}
+
+// streamingResponse return the upstream response if its body should be streamed to client
+func (hcm *HttpConnectionManager) streamingResponse(c *pch.HttpContext) (*stdHttp.Response, bool) {
+	if !hcm.config.StreamBody || c.IsBufferResponse() {
+		return nil, false
+	}
+	res, ok := c.SourceResp.(*stdHttp.Response)
+	return res, ok && c.TargetResp == nil
+}
+
+// copyWithFlush copy body to writer and flush after each write, so that SSE or chunked data reach client in time
+func copyWithFlush(w stdHttp.ResponseWriter, body io.Reader) (int64, error) {
+	flusher, _ := w.(stdHttp.Flusher)
+	buf := make([]byte, streamBufferSize)
+	var written int64
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			nw, werr := w.Write(buf[:n])
+			written += int64(nw)
+			if werr != nil {
+				return written, werr
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
 		}
 	}
 }
@@ -128,19 +189,18 @@ func (hcm *HttpConnectionManager) buildTargetResponse(c *pch.HttpContext) {
 
 	switch res := c.SourceResp.(type) {
 	case *stdHttp.Response:
+		if hcm.config.StreamBody && !c.IsBufferResponse() {
+			// body will be copied in writeResponse
+			mergeHeader(c, res)
+			return
+		}
 		body, err := io.ReadAll(res.Body)
 		if err != nil {
 			panic(err)
 		}
 		//close body
 		_ = res.Body.Close()
-		//Merge header
-		remoteHeader := res.Header
-		for k := range remoteHeader {
-			c.AddHeader(k, remoteHeader.Get(k))
-		}
-		//status code
-		c.StatusCode(res.StatusCode)
+		mergeHeader(c, res)
 		c.TargetResp = &client.Response{Data: body}
 	case []byte:
 		c.StatusCode(stdHttp.StatusOK)
@@ -159,6 +219,15 @@ func (hcm *HttpConnectionManager) buildTargetResponse(c *pch.HttpContext) {
 	}
 }
 
+// mergeHeader merge the header and status code of upstream response
+func mergeHeader(c *pch.HttpContext, res *stdHttp.Response) {
+	remoteHeader := res.Header
+	for k := range remoteHeader {
+		c.AddHeader(k, remoteHeader.Get(k))
+	}
+	c.StatusCode(res.StatusCode)
+}
+
 func (hcm *HttpConnectionManager) findRoute(hc *pch.HttpContext) error {
 	ra, err := hcm.routerCoordinator.Route(hc)
 	if err != nil {
@@ -18,10 +18,16 @@
 package http
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 import (
@@ -41,6 +47,10 @@ const (
 	DEMO = "dgp.filters.http.demo"
 	// Kind is the kind of plugin.
 	Kind = DEMO
+
+	STREAM = "dgp.filters.http.stream"
+
+	UPGRADE = "dgp.filters.http.upgrade"
 )
 
 type (
@@ -140,3 +150,183 @@ func TestCreateHttpConnectionManager(t *testing.T) {
 	err = hcm.Handle(c)
 	assert.NoError(t, err)
 }
+
+type (
+	// StreamPlugin mock upstream response for stream body test
+	StreamPlugin struct{}
+	// StreamFilterFactory create StreamFilter
+	StreamFilterFactory struct {
+		conf *StreamConfig
+	}
+	// StreamFilter set upstream response
+	StreamFilter struct {
+		buffer bool
+	}
+	// StreamConfig config of StreamFilter
+	StreamConfig struct {
+		Buffer bool `yaml:"buffer"`
+	}
+)
+
+func (p *StreamPlugin) Kind() string {
+	return STREAM
+}
+
+func (p *StreamPlugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &StreamFilterFactory{conf: &StreamConfig{}}, nil
+}
+
+func (f *StreamFilterFactory) PrepareFilterChain(ctx *contexthttp.HttpContext, chain filter.FilterChain) error {
+	chain.AppendDecodeFilters(&StreamFilter{buffer: f.conf.Buffer})
+	return nil
+}
+
+func (f *StreamFilterFactory) Config() interface{} {
+	return f.conf
+}
+
+func (f *StreamFilterFactory) Apply() error {
+	return nil
+}
+
+// writtenSize the body size reported to the response written callback in stream body test
+var writtenSize int64
+
+func (f *StreamFilter) Decode(ctx *contexthttp.HttpContext) filter.FilterStatus {
+	if f.buffer {
+		ctx.BufferResponse()
+	}
+	ctx.OnResponseWritten(func(size int64) {
+		writtenSize = size
+	})
+	ctx.SourceResp = &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:       io.NopCloser(strings.NewReader("data: 1\n\ndata: 2\n\n")),
+	}
+	return filter.Continue
+}
+
+func TestStreamBody(t *testing.T) {
+	filter.RegisterHttpFilter(&StreamPlugin{})
+
+	for _, buffer := range []bool{false, true} {
+		hcmc := model.HttpConnectionManagerConfig{
+			RouteConfig: model.RouteConfiguration{
+				RouteTrie: trie.NewTrieWithDefault("GET/api/v1/**", model.RouteAction{Cluster: "test"}),
+			},
+			HTTPFilters: []*model.HTTPFilter{
+				{
+					Name:   STREAM,
+					Config: map[string]interface{}{"buffer": buffer},
+				},
+			},
+			StreamBody: true,
+		}
+		hcm := CreateHttpConnectionManager(&hcmc)
+
+		writtenSize = 0
+		request := httptest.NewRequest(http.MethodGet, "http://www.dubbogopixiu.com/api/v1/events", nil)
+		recorder := httptest.NewRecorder()
+		hcm.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "data: 1\n\ndata: 2\n\n", recorder.Body.String())
+		assert.Equal(t, !buffer, recorder.Flushed)
+		assert.Equal(t, int64(recorder.Body.Len()), writtenSize)
+	}
+}
+
+// UpgradePlugin mock upstream which accept websocket handshake and echo the data
+type UpgradePlugin struct{}
+
+func (p *UpgradePlugin) Kind() string {
+	return UPGRADE
+}
+
+func (p *UpgradePlugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return p, nil
+}
+
+func (p *UpgradePlugin) PrepareFilterChain(ctx *contexthttp.HttpContext, chain filter.FilterChain) error {
+	chain.AppendDecodeFilters(p)
+	return nil
+}
+
+func (p *UpgradePlugin) Config() interface{} {
+	return &struct{}{}
+}
+
+func (p *UpgradePlugin) Apply() error {
+	return nil
+}
+
+func (p *UpgradePlugin) Decode(ctx *contexthttp.HttpContext) filter.FilterStatus {
+	if !ctx.IsWebSocketUpgrade() {
+		ctx.SendLocalReply(http.StatusBadRequest, []byte("not websocket"))
+		return filter.Stop
+	}
+	front, back := net.Pipe()
+	go func() {
+		defer back.Close()
+		_, _ = io.Copy(back, back)
+	}()
+	ctx.SourceResp = &http.Response{
+		StatusCode: http.StatusSwitchingProtocols,
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Connection": []string{"Upgrade"},
+			"Upgrade":    []string{"websocket"},
+		},
+		Body: front,
+	}
+	return filter.Continue
+}
+
+func TestWebSocketUpgrade(t *testing.T) {
+	filter.RegisterHttpFilter(&UpgradePlugin{})
+
+	hcmc := model.HttpConnectionManagerConfig{
+		RouteConfig: model.RouteConfiguration{
+			RouteTrie: trie.NewTrieWithDefault("GET/api/v1/**", model.RouteAction{Cluster: "test"}),
+		},
+		HTTPFilters: []*model.HTTPFilter{
+			{
+				Name: UPGRADE,
+			},
+		},
+		WebSocket: &model.WebSocketConfig{IdleTimeout: 200 * time.Millisecond},
+	}
+	hcm := CreateHttpConnectionManager(&hcmc)
+	server := httptest.NewServer(hcm)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	assert.NoError(t, req.Write(conn))
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "websocket", resp.Header.Get("Upgrade"))
+
+	_, err = conn.Write([]byte("ping"))
+	assert.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(br, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+
+	// connection is closed after idle timeout
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = br.ReadByte()
+	assert.Equal(t, io.EOF, err)
+}
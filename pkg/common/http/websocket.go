@@ -0,0 +1,160 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"io"
+	"net"
+	stdHttp "net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	pch "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+// switchingProtocols return the upstream response if the upstream accepted the websocket handshake
+func switchingProtocols(c *pch.HttpContext) (*stdHttp.Response, bool) {
+	if c.LocalReply() {
+		return nil, false
+	}
+	res, ok := c.SourceResp.(*stdHttp.Response)
+	if !ok || res.StatusCode != stdHttp.StatusSwitchingProtocols {
+		return nil, false
+	}
+	if _, ok := res.Body.(io.ReadWriteCloser); !ok {
+		return nil, false
+	}
+	return res, true
+}
+
+// serveUpgrade hijack the client connection and pump data between client and upstream until one side closed or idle timeout
+func (hcm *HttpConnectionManager) serveUpgrade(c *pch.HttpContext, res *stdHttp.Response) {
+	backConn := res.Body.(io.ReadWriteCloser)
+	defer backConn.Close()
+	// the handshake is rejected by filters while encoding
+	if c.LocalReply() {
+		return
+	}
+
+	hijacker, ok := c.Writer.(stdHttp.Hijacker)
+	if !ok {
+		c.SendLocalReply(stdHttp.StatusInternalServerError, []byte("can't switch protocols using non-Hijacker ResponseWriter"))
+		return
+	}
+	conn, brw, err := hijacker.Hijack()
+	if err != nil {
+		logger.Errorf("[dubbo-go-pixiu] hijack websocket connection error: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// write the 101 response to client, headers has been merged into writer
+	res.Header = c.Writer.Header().Clone()
+	res.Body = nil
+	if err = res.Write(brw); err != nil {
+		logger.Errorf("[dubbo-go-pixiu] write websocket handshake response error: %v", err)
+		return
+	}
+	if err = brw.Flush(); err != nil {
+		logger.Errorf("[dubbo-go-pixiu] flush websocket handshake response error: %v", err)
+		return
+	}
+
+	logger.Debugf("[dubbo-go-pixiu] websocket connection established for %s", c.GetUrl())
+	var idleTimeout time.Duration
+	if hcm.config.WebSocket != nil {
+		idleTimeout = hcm.config.WebSocket.IdleTimeout
+	}
+	pumpUpgraded(conn, brw, backConn, idleTimeout)
+}
+
+// pumpUpgraded copy data in both directions, the buffered reader of client is used so that no data is lost
+func pumpUpgraded(conn net.Conn, clientReader io.Reader, backConn io.ReadWriteCloser, idleTimeout time.Duration) {
+	var (
+		lastActive = time.Now().UnixNano()
+		closeOnce  sync.Once
+		done       = make(chan struct{})
+	)
+	closeAll := func() {
+		closeOnce.Do(func() {
+			close(done)
+			_ = conn.Close()
+			_ = backConn.Close()
+		})
+	}
+	active := func() {
+		atomic.StoreInt64(&lastActive, time.Now().UnixNano())
+	}
+
+	if idleTimeout > 0 {
+		go func() {
+			timer := time.NewTimer(idleTimeout)
+			defer timer.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-timer.C:
+					idle := time.Since(time.Unix(0, atomic.LoadInt64(&lastActive)))
+					if idle >= idleTimeout {
+						logger.Debugf("[dubbo-go-pixiu] websocket connection idle timeout %s", idleTimeout)
+						closeAll()
+						return
+					}
+					timer.Reset(idleTimeout - idle)
+				}
+			}
+		}()
+	}
+
+	errCh := make(chan error, 2)
+	go func() {
+		errCh <- copyActive(backConn, clientReader, active)
+	}()
+	go func() {
+		errCh <- copyActive(conn, backConn, active)
+	}()
+	// one side closed, close the other side as well
+	<-errCh
+	closeAll()
+	<-errCh
+}
+
+// copyActive copy from src to dst and mark active for each read
+func copyActive(dst io.Writer, src io.Reader, active func()) error {
+	buf := make([]byte, streamBufferSize)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			active()
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -19,6 +19,7 @@ package router
 
 import (
 	stdHttp "net/http"
+	"net/url"
 	"strings"
 	"sync"
 )
@@ -51,8 +52,8 @@ func CreateRouterCoordinator(routeConfig *model.RouteConfiguration) *RouterCoord
 	if routeConfig.Dynamic {
 		server.GetRouterManager().AddRouterListener(rc)
 	}
-	rc.initTrie()
 	rc.initRegex()
+	rc.initTrie()
 	return rc
 }
 
@@ -64,38 +65,56 @@ func (rm *RouterCoordinator) Route(hc *http.HttpContext) (*model.RouteAction, er
 	return rm.route(hc.Request)
 }
 
+// RouteByPathAndName find routeAction for the rpc without http request like dubbo, the routes are matched in the
+// same order as Route. there is no header or query parameter, so the routes requiring them never match
 func (rm *RouterCoordinator) RouteByPathAndName(path, method string) (*model.RouteAction, error) {
 	rm.rw.RLock()
 	defer rm.rw.RUnlock()
 
-	return rm.activeConfig.RouteByPathAndMethod(path, method)
+	return rm.route(&stdHttp.Request{Method: method, URL: &url.URL{Path: path}, Header: stdHttp.Header{}})
 }
 
 func (rm *RouterCoordinator) route(req *stdHttp.Request) (*model.RouteAction, error) {
-	// match those route that only contains headers first
-	var matched []*model.Router
-	for _, route := range rm.activeConfig.Routes {
-		if len(route.Match.Prefix) > 0 {
-			continue
-		}
-		if route.Match.MatchHeader(req) {
-			matched = append(matched, route)
-		}
+	// match those routes with headers or query parameters first, then those with regex or ignoring case
+	matched := rm.matchInOrder(req, func(m *model.RouterMatch) bool {
+		return m.IsConditional()
+	})
+	if matched == nil {
+		matched = rm.matchInOrder(req, func(m *model.RouterMatch) bool {
+			return m.IsPattern()
+		})
 	}
 
-	// always return the first match of header if got any
-	if len(matched) > 0 {
-		if len(matched[0].Route.Cluster) == 0 {
+	// always return the first match if got any
+	if matched != nil {
+		if len(matched.Route.Cluster) == 0 {
 			return nil, errors.New("action is nil. please check your configuration.")
 		}
-		return &matched[0].Route, nil
+		return &matched.Route, nil
 	}
 
-	// match those route that only contains prefix
-	// TODO: may consider implementing both prefix and header in the future
+	// match those route that only contains prefix or path
+	if rm.activeConfig.RouteTrie.IsEmpty() && len(rm.activeConfig.Routes) > 0 {
+		return nil, errors.Errorf("route failed for %s, no rules matched.", stringutil.GetTrieKey(req.Method, req.URL.Path))
+	}
 	return rm.activeConfig.Route(req)
 }
 
+// matchInOrder the first route in configuration order which is selected by filter and matches the request
+func (rm *RouterCoordinator) matchInOrder(req *stdHttp.Request, filter func(m *model.RouterMatch) bool) *model.Router {
+	for _, route := range rm.activeConfig.Routes {
+		if filter(&route.Match) && route.Match.Match(req) {
+			return route
+		}
+	}
+	return nil
+}
+
+// inTrie the route which has only prefix or path is matched by the trie
+func inTrie(r *model.Router) bool {
+	return !r.Match.IsConditional() && !r.Match.IsPattern()
+}
+
 func getTrieKey(method string, path string, isPrefix bool) string {
 	if isPrefix {
 		if !strings.HasSuffix(path, constant.PathSlash) {
@@ -117,16 +136,9 @@ func (rm *RouterCoordinator) initTrie() {
 
 func (rm *RouterCoordinator) initRegex() {
 	for _, router := range rm.activeConfig.Routes {
-		headers := router.Match.Headers
-		for i := range headers {
-			if headers[i].Regex && len(headers[i].Values) > 0 {
-				// regexp always use first value of header
-				err := headers[i].SetValueRegex(headers[i].Values[0])
-				if err != nil {
-					logger.Errorf("invalid regexp in headers[%d]: %v", i, err)
-					panic(err)
-				}
-			}
+		if err := router.Match.Compile(); err != nil {
+			logger.Errorf("invalid route %s: %v", router.ID, err)
+			panic(err)
 		}
 	}
 }
@@ -139,6 +151,10 @@ func (rm *RouterCoordinator) OnAddRouter(r *model.Router) {
 	if r.Match.Methods == nil {
 		r.Match.Methods = []string{constant.Get, constant.Put, constant.Delete, constant.Post, constant.Options}
 	}
+	if !inTrie(r) {
+		rm.addOrderedRoute(r)
+		return
+	}
 	isPrefix := r.Match.Prefix != ""
 	for _, method := range r.Match.Methods {
 		var key string
@@ -159,6 +175,10 @@ func (rm *RouterCoordinator) OnDeleteRouter(r *model.Router) {
 	if r.Match.Methods == nil {
 		r.Match.Methods = []string{constant.Get, constant.Put, constant.Delete, constant.Post}
 	}
+	if !inTrie(r) {
+		rm.deleteOrderedRoute(r)
+		return
+	}
 	isPrefix := r.Match.Prefix != ""
 	for _, method := range r.Match.Methods {
 		var key string
@@ -170,3 +190,26 @@ func (rm *RouterCoordinator) OnDeleteRouter(r *model.Router) {
 		_, _ = rm.activeConfig.RouteTrie.Remove(key)
 	}
 }
+
+// addOrderedRoute the dynamic route which is not in trie is appended to routes, it is matched in order
+func (rm *RouterCoordinator) addOrderedRoute(r *model.Router) {
+	for _, route := range rm.activeConfig.Routes {
+		if route == r {
+			return
+		}
+	}
+	if err := r.Match.Compile(); err != nil {
+		logger.Errorf("invalid route %s: %v", r.ID, err)
+		return
+	}
+	rm.activeConfig.Routes = append(rm.activeConfig.Routes, r)
+}
+
+func (rm *RouterCoordinator) deleteOrderedRoute(r *model.Router) {
+	for i, route := range rm.activeConfig.Routes {
+		if route == r || (r.ID != "" && route.ID == r.ID) {
+			rm.activeConfig.Routes = append(rm.activeConfig.Routes[:i], rm.activeConfig.Routes[i+1:]...)
+			return
+		}
+	}
+}
@@ -224,3 +224,154 @@ func TestRoute(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteCombined(t *testing.T) {
+	hcmc := model.HttpConnectionManagerConfig{
+		RouteConfig: model.RouteConfiguration{
+			Routes: []*model.Router{
+				{
+					ID: "prefix",
+					Match: model.RouterMatch{
+						Prefix: "/api",
+					},
+					Route: model.RouteAction{Cluster: "prefix"},
+				},
+				{
+					ID: "path",
+					Match: model.RouterMatch{
+						Path: "/api/users",
+					},
+					Route: model.RouteAction{Cluster: "path"},
+				},
+				{
+					ID: "prefix-header",
+					Match: model.RouterMatch{
+						Prefix:  "/api",
+						Headers: []model.HeaderMatcher{{Name: "X-Env", Values: []string{"canary"}}, {Name: "X-Region", Values: []string{"hz"}}},
+					},
+					Route: model.RouteAction{Cluster: "canary"},
+				},
+				{
+					ID: "query",
+					Match: model.RouterMatch{
+						Path:            "/api/users",
+						Methods:         []string{"GET"},
+						QueryParameters: []model.QueryParameterMatcher{{Name: "debug"}, {Name: "version", Values: []string{"v[0-9]+"}, Regex: true}},
+					},
+					Route: model.RouteAction{Cluster: "query"},
+				},
+				{
+					ID: "regex",
+					Match: model.RouterMatch{
+						Regex:   "/api/users/[0-9]+/orders",
+						Methods: []string{"GET"},
+					},
+					Route: model.RouteAction{Cluster: "regex"},
+				},
+				{
+					ID: "ignore-case",
+					Match: model.RouterMatch{
+						Prefix:     "/Static",
+						IgnoreCase: true,
+					},
+					Route: model.RouteAction{Cluster: "static"},
+				},
+			},
+		},
+	}
+	r := CreateRouterCoordinator(&hcmc.RouteConfig)
+
+	testCases := []struct {
+		Name   string
+		URL    string
+		Method string
+		Header map[string]string
+		Expect string
+	}{
+		{Name: "prefix", URL: "/api/orders", Expect: "prefix"},
+		{Name: "exact path over prefix", URL: "/api/users", Expect: "path"},
+		{Name: "prefix and all headers", URL: "/api/orders", Header: map[string]string{"X-Env": "canary", "X-Region": "hz"}, Expect: "canary"},
+		{Name: "prefix and part of headers", URL: "/api/orders", Header: map[string]string{"X-Env": "canary"}, Expect: "prefix"},
+		{Name: "headers but path not matched", URL: "/web", Header: map[string]string{"X-Env": "canary", "X-Region": "hz"}, Expect: "route failed for GET/web, no rules matched."},
+		{Name: "query parameters", URL: "/api/users?debug&version=v2", Expect: "query"},
+		{Name: "query parameter regex not matched", URL: "/api/users?debug&version=beta", Expect: "path"},
+		{Name: "query parameters but wrong method", URL: "/api/users?debug&version=v2", Method: "POST", Expect: "path"},
+		{Name: "regex", URL: "/api/users/12/orders", Expect: "regex"},
+		{Name: "regex must match whole path", URL: "/api/users/12/orders/1", Expect: "prefix"},
+		{Name: "regex not matched", URL: "/api/users/abc/orders", Expect: "prefix"},
+		{Name: "ignore case", URL: "/STATIC/app.js", Expect: "static"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			method := "GET"
+			if len(tc.Method) > 0 {
+				method = tc.Method
+			}
+			request, err := http.NewRequest(method, tc.URL, nil)
+			assert.NoError(t, err)
+			for k, v := range tc.Header {
+				request.Header.Set(k, v)
+			}
+
+			a, err := r.Route(mock.GetMockHTTPContext(request))
+			if err != nil {
+				assert.Equal(t, tc.Expect, err.Error())
+			} else {
+				assert.Equal(t, tc.Expect, a.Cluster)
+			}
+		})
+	}
+
+	// the dynamic route which is not in trie is matched in order
+	dynamic := &model.Router{
+		ID:    "dynamic",
+		Match: model.RouterMatch{Regex: "/web/.*"},
+		Route: model.RouteAction{Cluster: "dynamic"},
+	}
+	r.OnAddRouter(dynamic)
+	request, _ := http.NewRequest("GET", "/web/index.html", nil)
+	a, err := r.Route(mock.GetMockHTTPContext(request))
+	assert.NoError(t, err)
+	assert.Equal(t, "dynamic", a.Cluster)
+
+	r.OnDeleteRouter(dynamic)
+	_, err = r.Route(mock.GetMockHTTPContext(request))
+	assert.Error(t, err)
+}
+
+func TestRouteByPathAndName(t *testing.T) {
+	rc := &model.RouteConfiguration{
+		Routes: []*model.Router{
+			{
+				Match: model.RouterMatch{Prefix: "com.dubbogo.pixiu.UserService", Methods: []string{"GetUserByName"}},
+				Route: model.RouteAction{Cluster: "user"},
+			},
+			{
+				Match: model.RouterMatch{
+					Prefix:  "com.dubbogo.pixiu.UserService",
+					Methods: []string{"GetUserByName"},
+					Headers: []model.HeaderMatcher{{Name: "X-Env", Values: []string{"canary"}}},
+				},
+				Route: model.RouteAction{Cluster: "canary"},
+			},
+			{
+				Match: model.RouterMatch{Regex: "com\\.dubbogo\\.pixiu\\.(Order|Pay)Service", Methods: []string{"Get"}},
+				Route: model.RouteAction{Cluster: "trade"},
+			},
+		},
+	}
+	r := CreateRouterCoordinator(rc)
+
+	// the route requiring headers never matches
+	ra, err := r.RouteByPathAndName("com.dubbogo.pixiu.UserService", "GetUserByName")
+	assert.NoError(t, err)
+	assert.Equal(t, "user", ra.Cluster)
+
+	ra, err = r.RouteByPathAndName("com.dubbogo.pixiu.PayService", "Get")
+	assert.NoError(t, err)
+	assert.Equal(t, "trade", ra.Cluster)
+
+	_, err = r.RouteByPathAndName("com.dubbogo.pixiu.PayService", "Refund")
+	assert.Error(t, err)
+}
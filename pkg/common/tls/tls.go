@@ -0,0 +1,329 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tls
+
+import (
+	stdtls "crypto/tls"
+	"crypto/x509"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/util/stringutil"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+var tlsVersions = map[string]uint16{
+	"TLSv1_0": stdtls.VersionTLS10,
+	"TLSv1_1": stdtls.VersionTLS11,
+	"TLSv1_2": stdtls.VersionTLS12,
+	"TLSv1_3": stdtls.VersionTLS13,
+}
+
+var clientAuthTypes = map[string]stdtls.ClientAuthType{
+	"none":               stdtls.NoClientCert,
+	"request":            stdtls.RequestClientCert,
+	"require":            stdtls.RequireAnyClientCert,
+	"verify_if_given":    stdtls.VerifyClientCertIfGiven,
+	"require_and_verify": stdtls.RequireAndVerifyClientCert,
+}
+
+type (
+	// CertificateManager load the certificates of a listener, choose certificate by SNI
+	// and reload them when the files are changed
+	CertificateManager struct {
+		config *model.TlsConfig
+		rwlock sync.RWMutex
+		certs  []*certEntry
+		done   chan struct{}
+		once   sync.Once
+	}
+
+	certEntry struct {
+		config      *model.TlsCertificate
+		cert        *stdtls.Certificate
+		serverNames []string
+		modTime     time.Time
+	}
+)
+
+// NewCertificateManager load the certificates, the files are checked periodically unless reload_interval is 0s
+func NewCertificateManager(config *model.TlsConfig) (*CertificateManager, error) {
+	if config == nil || len(config.Certificates) == 0 {
+		return nil, errors.New("no tls certificate configured")
+	}
+	config.ReloadInterval = stringutil.ResolveTimeStr2Time(config.ReloadIntervalStr, constant.DefaultTLSReloadInterval)
+
+	m := &CertificateManager{
+		config: config,
+		done:   make(chan struct{}),
+	}
+	for _, c := range config.Certificates {
+		entry, err := loadCertificate(c)
+		if err != nil {
+			return nil, err
+		}
+		m.certs = append(m.certs, entry)
+	}
+	if config.ReloadInterval > 0 {
+		go m.watch()
+	}
+	return m, nil
+}
+
+// TLSConfig create tls config which select certificate by SNI
+func (m *CertificateManager) TLSConfig() (*stdtls.Config, error) {
+	cfg := &stdtls.Config{
+		GetCertificate: m.GetCertificate,
+	}
+	var err error
+	if cfg.MinVersion, err = parseVersion(m.config.MinVersion); err != nil {
+		return nil, err
+	}
+	if cfg.MaxVersion, err = parseVersion(m.config.MaxVersion); err != nil {
+		return nil, err
+	}
+	if cfg.CipherSuites, err = parseCipherSuites(m.config.CipherSuites); err != nil {
+		return nil, err
+	}
+	if cfg.ClientAuth, err = parseClientAuth(m.config.ClientAuth); err != nil {
+		return nil, err
+	}
+	if m.config.ClientCACertFile != "" {
+		if cfg.ClientCAs, err = LoadCertPool(m.config.ClientCACertFile); err != nil {
+			return nil, err
+		}
+	} else if cfg.ClientAuth >= stdtls.VerifyClientCertIfGiven {
+		return nil, errors.Errorf("client_ca_cert_file is required by client_auth %s", m.config.ClientAuth)
+	}
+	return cfg, nil
+}
+
+// GetCertificate return the certificate matching the SNI, the first certificate is the default one
+func (m *CertificateManager) GetCertificate(hello *stdtls.ClientHelloInfo) (*stdtls.Certificate, error) {
+	m.rwlock.RLock()
+	defer m.rwlock.RUnlock()
+
+	name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if name != "" {
+		// exact match takes precedence over wildcard
+		for _, entry := range m.certs {
+			for _, sn := range entry.serverNames {
+				if sn == name {
+					return entry.cert, nil
+				}
+			}
+		}
+		for _, entry := range m.certs {
+			for _, sn := range entry.serverNames {
+				if matchWildcard(sn, name) {
+					return entry.cert, nil
+				}
+			}
+		}
+	}
+	return m.certs[0].cert, nil
+}
+
+// DefaultCertificate return the config of the default certificate
+func (m *CertificateManager) DefaultCertificate() *model.TlsCertificate {
+	return m.config.Certificates[0]
+}
+
+// Close stop reloading certificates
+func (m *CertificateManager) Close() {
+	m.once.Do(func() {
+		close(m.done)
+	})
+}
+
+func (m *CertificateManager) watch() {
+	ticker := time.NewTicker(m.config.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.reload()
+		}
+	}
+}
+
+// reload the certificates whose files are changed, the old one is kept if failed
+func (m *CertificateManager) reload() {
+	m.rwlock.RLock()
+	certs := make([]*certEntry, len(m.certs))
+	copy(certs, m.certs)
+	m.rwlock.RUnlock()
+
+	changed := false
+	for i, entry := range certs {
+		modTime, err := lastModTime(entry.config)
+		if err != nil {
+			logger.Warnf("[dubbo-go-pixiu] stat tls certificate %s failed: %v", entry.config.CertFile, err)
+			continue
+		}
+		if !modTime.After(entry.modTime) {
+			continue
+		}
+		newEntry, err := loadCertificate(entry.config)
+		if err != nil {
+			logger.Warnf("[dubbo-go-pixiu] reload tls certificate %s failed: %v", entry.config.CertFile, err)
+			continue
+		}
+		logger.Infof("[dubbo-go-pixiu] tls certificate %s reloaded", entry.config.CertFile)
+		certs[i] = newEntry
+		changed = true
+	}
+
+	if changed {
+		m.rwlock.Lock()
+		m.certs = certs
+		m.rwlock.Unlock()
+	}
+}
+
+func loadCertificate(c *model.TlsCertificate) (*certEntry, error) {
+	modTime, err := lastModTime(c)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := stdtls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "load tls certificate %s", c.CertFile)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse tls certificate %s", c.CertFile)
+	}
+	cert.Leaf = leaf
+
+	serverNames := c.ServerNames
+	if len(serverNames) == 0 {
+		serverNames = leaf.DNSNames
+		if len(serverNames) == 0 && leaf.Subject.CommonName != "" {
+			serverNames = []string{leaf.Subject.CommonName}
+		}
+	}
+	names := make([]string, 0, len(serverNames))
+	for _, sn := range serverNames {
+		names = append(names, strings.ToLower(sn))
+	}
+	return &certEntry{
+		config:      c,
+		cert:        &cert,
+		serverNames: names,
+		modTime:     modTime,
+	}, nil
+}
+
+// lastModTime the latest modify time of cert file and key file
+func lastModTime(c *model.TlsCertificate) (time.Time, error) {
+	certInfo, err := os.Stat(c.CertFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	keyInfo, err := os.Stat(c.KeyFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if keyInfo.ModTime().After(certInfo.ModTime()) {
+		return keyInfo.ModTime(), nil
+	}
+	return certInfo.ModTime(), nil
+}
+
+// matchWildcard check whether name matches pattern like *.example.com, only one label is matched by *
+func matchWildcard(pattern, name string) bool {
+	if !strings.HasPrefix(pattern, "*.") {
+		return false
+	}
+	suffix := pattern[1:]
+	if !strings.HasSuffix(name, suffix) {
+		return false
+	}
+	label := name[:len(name)-len(suffix)]
+	return label != "" && !strings.Contains(label, ".")
+}
+
+func parseVersion(v string) (uint16, error) {
+	if v == "" {
+		return 0, nil
+	}
+	version, ok := tlsVersions[v]
+	if !ok {
+		return 0, errors.Errorf("unknown tls version %s", v)
+	}
+	return version, nil
+}
+
+func parseClientAuth(v string) (stdtls.ClientAuthType, error) {
+	if v == "" {
+		return stdtls.NoClientCert, nil
+	}
+	clientAuth, ok := clientAuthTypes[v]
+	if !ok {
+		return 0, errors.Errorf("unknown tls client auth %s", v)
+	}
+	return clientAuth, nil
+}
+
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	suites := make(map[string]uint16)
+	for _, cs := range stdtls.CipherSuites() {
+		suites[cs.Name] = cs.ID
+	}
+	for _, cs := range stdtls.InsecureCipherSuites() {
+		suites[cs.Name] = cs.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := suites[name]
+		if !ok {
+			return nil, errors.Errorf("unknown tls cipher suite %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// LoadCertPool load the CA certificates in pem file
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	caBytes, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read ca cert file %s", file)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return nil, errors.Errorf("no certificate found in ca cert file %s", file)
+	}
+	return pool, nil
+}
@@ -0,0 +1,163 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	stdtls "crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// writeCertificate write a self-signed certificate for the dns names
+func writeCertificate(t *testing.T, dir, name string, dnsNames ...string) *model.TlsCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return &model.TlsCertificate{CertFile: certFile, KeyFile: keyFile}
+}
+
+func TestCertificateManager(t *testing.T) {
+	dir := t.TempDir()
+	def := writeCertificate(t, dir, "default", "default.com")
+	api := writeCertificate(t, dir, "api", "api.example.com")
+	wildcard := writeCertificate(t, dir, "wildcard", "*.example.com")
+	wildcard.ServerNames = []string{"*.example.com", "example.org"}
+
+	cfg := &model.TlsConfig{
+		Certificates:      []*model.TlsCertificate{def, wildcard, api},
+		MinVersion:        "TLSv1_2",
+		CipherSuites:      []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+		ReloadIntervalStr: "0s",
+	}
+	m, err := NewCertificateManager(cfg)
+	assert.NoError(t, err)
+	defer m.Close()
+
+	tlsConfig, err := m.TLSConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(stdtls.VersionTLS12), tlsConfig.MinVersion)
+	assert.Equal(t, []uint16{stdtls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, tlsConfig.CipherSuites)
+
+	tests := []struct {
+		serverName string
+		expected   string
+	}{
+		{"api.example.com", "api.example.com"},
+		{"API.example.com.", "api.example.com"},
+		{"www.example.com", "*.example.com"},
+		{"example.org", "*.example.com"},
+		{"a.b.example.com", "default.com"},
+		{"unknown.com", "default.com"},
+		{"", "default.com"},
+	}
+	for _, tt := range tests {
+		cert, err := m.GetCertificate(&stdtls.ClientHelloInfo{ServerName: tt.serverName})
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, cert.Leaf.Subject.CommonName, tt.serverName)
+	}
+
+	// certificate is replaced after files changed
+	newApi := writeCertificate(t, dir, "api", "api.example.com", "api2.example.com")
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(newApi.CertFile, future, future))
+	m.reload()
+	cert, err := m.GetCertificate(&stdtls.ClientHelloInfo{ServerName: "api2.example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"api.example.com", "api2.example.com"}, cert.Leaf.DNSNames)
+
+	// broken file keeps the old certificate
+	assert.NoError(t, os.WriteFile(def.CertFile, []byte("broken"), 0o600))
+	future = future.Add(time.Minute)
+	assert.NoError(t, os.Chtimes(def.CertFile, future, future))
+	m.reload()
+	cert, err = m.GetCertificate(&stdtls.ClientHelloInfo{ServerName: "unknown.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "default.com", cert.Leaf.Subject.CommonName)
+}
+
+func TestTLSConfigError(t *testing.T) {
+	_, err := NewCertificateManager(&model.TlsConfig{})
+	assert.Error(t, err)
+
+	_, err = parseVersion("TLSv2")
+	assert.Error(t, err)
+	_, err = parseCipherSuites([]string{"unknown"})
+	assert.Error(t, err)
+}
+
+func TestTLSConfigClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	cert := writeCertificate(t, dir, "server", "example.com")
+	client := writeCertificate(t, dir, "client", "client")
+
+	m, err := NewCertificateManager(&model.TlsConfig{
+		Certificates:      []*model.TlsCertificate{cert},
+		ReloadIntervalStr: "0s",
+		ClientAuth:        "require_and_verify",
+		ClientCACertFile:  client.CertFile,
+	})
+	assert.NoError(t, err)
+	defer m.Close()
+	cfg, err := m.TLSConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, stdtls.RequireAndVerifyClientCert, cfg.ClientAuth)
+	assert.NotNil(t, cfg.ClientCAs)
+
+	m.config.ClientCACertFile = ""
+	_, err = m.TLSConfig()
+	assert.Error(t, err)
+	m.config.ClientAuth = "request"
+	cfg, err = m.TLSConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, stdtls.RequestClientCert, cfg.ClientAuth)
+
+	_, err = parseClientAuth("optional")
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tls
+
+import (
+	stdtls "crypto/tls"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// NewUpstreamConfig create the client tls config to connect to upstream cluster
+func NewUpstreamConfig(config *model.UpstreamTlsConfig) (*stdtls.Config, error) {
+	cfg := &stdtls.Config{
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	var err error
+	if cfg.MinVersion, err = parseVersion(config.MinVersion); err != nil {
+		return nil, err
+	}
+
+	if config.CACertFile != "" {
+		if cfg.RootCAs, err = LoadCertPool(config.CACertFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		cert, err := stdtls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "load client certificate %s", config.CertFile)
+		}
+		cfg.Certificates = []stdtls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tls
+
+import (
+	stdtls "crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestNewUpstreamConfig(t *testing.T) {
+	dir := t.TempDir()
+	serverCert := writeCertificate(t, dir, "server", "upstream.local")
+	clientCert := writeCertificate(t, dir, "client", "pixiu")
+
+	// upstream requires client certificate signed by client ca
+	clientPem, err := os.ReadFile(clientCert.CertFile)
+	assert.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	assert.True(t, clientCAs.AppendCertsFromPEM(clientPem))
+	cert, err := stdtls.LoadX509KeyPair(serverCert.CertFile, serverCert.KeyFile)
+	assert.NoError(t, err)
+
+	upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	upstream.TLS = &stdtls.Config{
+		Certificates: []stdtls.Certificate{cert},
+		ClientAuth:   stdtls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	upstream.StartTLS()
+	defer upstream.Close()
+
+	request := func(cfg *model.UpstreamTlsConfig) (string, error) {
+		tlsConfig, err := NewUpstreamConfig(cfg)
+		if err != nil {
+			return "", err
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		resp, err := client.Get(upstream.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		buf := make([]byte, 16)
+		n, _ := resp.Body.Read(buf)
+		return string(buf[:n]), nil
+	}
+
+	// mutual tls with SNI override
+	body, err := request(&model.UpstreamTlsConfig{
+		CACertFile: serverCert.CertFile,
+		CertFile:   clientCert.CertFile,
+		KeyFile:    clientCert.KeyFile,
+		ServerName: "upstream.local",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "pixiu", body)
+
+	// the certificate of upstream doesn't match 127.0.0.1
+	_, err = request(&model.UpstreamTlsConfig{
+		CACertFile: serverCert.CertFile,
+		CertFile:   clientCert.CertFile,
+		KeyFile:    clientCert.KeyFile,
+	})
+	assert.Error(t, err)
+
+	// skip verify still needs client certificate
+	_, err = request(&model.UpstreamTlsConfig{InsecureSkipVerify: true})
+	assert.Error(t, err)
+	body, err = request(&model.UpstreamTlsConfig{
+		InsecureSkipVerify: true,
+		CertFile:           clientCert.CertFile,
+		KeyFile:            clientCert.KeyFile,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "pixiu", body)
+
+	_, err = NewUpstreamConfig(&model.UpstreamTlsConfig{CACertFile: clientCert.KeyFile})
+	assert.Error(t, err)
+}
@@ -17,6 +17,10 @@
 
 package xds
 
+import (
+	"strconv"
+)
+
 import (
 	"github.com/dubbo-go-pixiu/pixiu-api/pkg/api"
 	xdspb "github.com/dubbo-go-pixiu/pixiu-api/pkg/xds/model"
@@ -166,11 +170,26 @@ func (c *CdsManager) makeEndpoints(endpoints []*xdspb.Endpoint) []*model.Endpoin
 			Name:     endpoint.Name,
 			Address:  c.makeAddress(endpoint),
 			Metadata: endpoint.Metadata,
+			Weight:   c.makeWeight(endpoint),
 		}
 	}
 	return r
 }
 
+// makeWeight the pixiu xds endpoint carries the weight in metadata
+func (c *CdsManager) makeWeight(endpoint *xdspb.Endpoint) uint32 {
+	w, ok := endpoint.Metadata[model.EndpointWeightKey]
+	if !ok {
+		return 0
+	}
+	weight, err := strconv.ParseUint(w, 10, 32)
+	if err != nil {
+		logger.Warnf("[dubbo-go-pixiu] invalid weight %s of endpoint %s: %v", w, endpoint.Id, err)
+		return 0
+	}
+	return uint32(weight)
+}
+
 func (c *CdsManager) makeAddress(endpoint *xdspb.Endpoint) model.SocketAddress {
 	if endpoint == nil || endpoint.Address == nil {
 		return model.SocketAddress{}
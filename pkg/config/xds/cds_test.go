@@ -198,3 +198,16 @@ func TestCdsManager_makeCluster(t *testing.T) {
 	assert.Equal(cluster.Endpoints[0].Address.Address, modelCluster.Endpoints[0].Address.Address)
 	assert.Equal(cluster.Endpoints[0].Address.Port, int64(modelCluster.Endpoints[0].Address.Port))
 }
+
+func TestCdsManager_makeWeight(t *testing.T) {
+	c := &CdsManager{}
+	endpoints := c.makeEndpoints([]*pixiupb.Endpoint{
+		{Id: "1", Metadata: map[string]string{"weight": "20"}},
+		{Id: "2", Metadata: map[string]string{"weight": "-1"}},
+		{Id: "3"},
+	})
+	assert := require.New(t)
+	assert.Equal(uint32(20), endpoints[0].Weight)
+	assert.Equal(uint32(0), endpoints[1].Weight)
+	assert.Equal(model.DefaultEndpointWeight, endpoints[2].GetWeight())
+}
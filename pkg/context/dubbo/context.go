@@ -22,6 +22,7 @@ import (
 )
 
 import (
+	"dubbo.apache.org/dubbo-go/v3/common/constant"
 	"dubbo.apache.org/dubbo-go/v3/protocol"
 	"dubbo.apache.org/dubbo-go/v3/protocol/invocation"
 )
@@ -61,7 +62,45 @@ func (c *RpcContext) SetRoute(route *model.RouteAction) {
 	c.Route = route
 }
 
+// Context the context of the invocation which carries its timeout, background if it is not set
+func (c *RpcContext) Context() context.Context {
+	if c.Ctx == nil {
+		return context.Background()
+	}
+	return c.Ctx
+}
+
+// GenerateHash the hash key by the hash policies of route, the method and service are hashed by default
+// MetadataMatch the endpoint metadata which the route selects the subset of cluster by
+func (c *RpcContext) MetadataMatch() map[string]string {
+	if c.Route == nil {
+		return nil
+	}
+	return c.Route.MetadataMatch
+}
+
 func (c *RpcContext) GenerateHash() string {
+	return c.GenerateHashWithPolicy(nil)
+}
+
+// GenerateHashWithPolicy the hash key by the hash policies of route or the given ones of cluster,
+// only the header policy which hashes on the attachment is supported by rpc invocation
+func (c *RpcContext) GenerateHashWithPolicy(policies []model.HashPolicy) string {
 	req := c.RpcInvocation
-	return req.MethodName() + "." + req.Invoker().GetURL().String()
+	if c.Route != nil && len(c.Route.HashPolicy) > 0 {
+		policies = c.Route.HashPolicy
+	}
+	for _, policy := range policies {
+		if policy.Header == "" {
+			continue
+		}
+		if v, ok := req.GetAttachment(policy.Header); ok && v != "" {
+			return v
+		}
+	}
+	if req.Invoker() != nil {
+		return req.MethodName() + "." + req.Invoker().GetURL().String()
+	}
+	path, _ := req.GetAttachment(constant.PathKey)
+	return req.MethodName() + "." + path
 }
@@ -0,0 +1,67 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// GetJwtClaim get the claim of the verified jwt token formatted by ClaimString, nested claim is separated by '.'
+func (hc *HttpContext) GetJwtClaim(name string) (string, bool) {
+	v, ok := LookupClaim(hc.jwtClaims, name)
+	if !ok || v == nil {
+		return "", false
+	}
+	return ClaimString(v), true
+}
+
+// LookupClaim get the claim by name, nested claim is separated by '.'
+func LookupClaim(claims map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := claims[name]; ok {
+		return v, true
+	}
+
+	var current interface{} = claims
+	for _, key := range strings.Split(name, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// ClaimString format the claim for comparing and forwarding, objects and arrays are encoded as json
+func ClaimString(claim interface{}) string {
+	switch v := claim.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
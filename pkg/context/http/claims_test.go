@@ -0,0 +1,51 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetJwtClaim(t *testing.T) {
+	hc := &HttpContext{}
+	_, ok := hc.GetJwtClaim("sub")
+	assert.False(t, ok)
+
+	hc.SetJwtClaims(map[string]interface{}{
+		"sub":      "alice",
+		"org.name": "flat",
+		"user":     map[string]interface{}{"id": float64(42), "admin": true, "roles": []interface{}{"a", "b"}},
+	})
+	for name, want := range map[string]string{
+		"sub":        "alice",
+		"org.name":   "flat",
+		"user.id":    "42",
+		"user.admin": "true",
+		"user.roles": `["a","b"]`,
+	} {
+		v, ok := hc.GetJwtClaim(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, want, v, name)
+	}
+	_, ok = hc.GetJwtClaim("user.name")
+	assert.False(t, ok)
+}
@@ -30,6 +30,7 @@ import (
 
 import (
 	"github.com/dubbo-go-pixiu/pixiu-api/pkg/router"
+	"golang.org/x/net/http/httpguts"
 )
 
 import (
@@ -58,6 +59,17 @@ type HttpContext struct {
 	statusCode int
 	// localReplyBody: happen error
 	localReplyBody []byte
+	// bufferResponse the response body must be buffered in TargetResp even if body streaming is enabled
+	bufferResponse bool
+	// responseWritten the callbacks called with the body size after the response is written to client
+	responseWritten []func(size int64)
+	// clientIdentity the identity of the verified client certificate
+	clientIdentity string
+	// jwtClaims the claims of the verified jwt token
+	jwtClaims map[string]interface{}
+	// consumer the name of the caller identified by the api key, and its rate limit tier
+	consumer     string
+	consumerTier string
 	// the response context will return.
 	TargetResp *client.Response
 	// client call response.
@@ -101,6 +113,12 @@ func (hc *HttpContext) Reset() {
 	hc.statusCode = 0
 	hc.localReply = false
 	hc.localReplyBody = nil
+	hc.bufferResponse = false
+	hc.responseWritten = nil
+	hc.clientIdentity = ""
+	hc.jwtClaims = nil
+	hc.consumer = ""
+	hc.consumerTier = ""
 }
 
 // RouteEntry set route
@@ -162,6 +180,12 @@ func (hc *HttpContext) GetClientIP() string {
 	return ""
 }
 
+// IsWebSocketUpgrade check whether the request is a websocket handshake
+func (hc *HttpContext) IsWebSocketUpgrade() bool {
+	return httpguts.HeaderValuesContainsToken(hc.Request.Header[constant.HeaderKeyConnection], constant.HeaderValueUpgrade) &&
+		strings.EqualFold(hc.Request.Header.Get(constant.HeaderKeyUpgrade), constant.HeaderValueWebSocket)
+}
+
 // GetApplicationName get application name
 func (hc *HttpContext) GetApplicationName() string {
 	if u, err := url.Parse(hc.Request.RequestURI); err == nil {
@@ -171,6 +195,50 @@ func (hc *HttpContext) GetApplicationName() string {
 	return ""
 }
 
+// SetClientIdentity set the identity of the verified client certificate
+func (hc *HttpContext) SetClientIdentity(identity string) {
+	hc.clientIdentity = identity
+}
+
+// GetClientIdentity get the identity of the verified client certificate, empty if the client is not authenticated
+func (hc *HttpContext) GetClientIdentity() string {
+	return hc.clientIdentity
+}
+
+// SetJwtClaims set the claims of the verified jwt token
+func (hc *HttpContext) SetJwtClaims(claims map[string]interface{}) {
+	hc.jwtClaims = claims
+}
+
+// GetJwtClaims get the claims of the verified jwt token, nil if no token is verified
+func (hc *HttpContext) GetJwtClaims() map[string]interface{} {
+	return hc.jwtClaims
+}
+
+// SetConsumer set the name and the rate limit tier of the authenticated consumer
+func (hc *HttpContext) SetConsumer(name, tier string) {
+	hc.consumer = name
+	hc.consumerTier = tier
+}
+
+// GetConsumer get the name of the authenticated consumer, empty if the consumer is not authenticated
+func (hc *HttpContext) GetConsumer() string {
+	return hc.consumer
+}
+
+// GetConsumerTier get the rate limit tier of the authenticated consumer
+func (hc *HttpContext) GetConsumerTier() string {
+	return hc.consumerTier
+}
+
+// MetadataMatch the endpoint metadata which the route selects the subset of cluster by
+func (hc *HttpContext) MetadataMatch() map[string]string {
+	if hc.Route == nil {
+		return nil
+	}
+	return hc.Route.MetadataMatch
+}
+
 // SendLocalReply Means that the request was interrupted and Response will be sent directly
 // Even if it’s currently in to Decode stage
 func (hc *HttpContext) SendLocalReply(status int, body []byte) {
@@ -215,6 +283,30 @@ func (hc *HttpContext) API(api router.API) {
 	hc.Api = &api
 }
 
+// BufferResponse the filters which need to read or modify the response body should call it before the upstream is invoked,
+// then the body is fully buffered in TargetResp even if the http connection manager streams bodies
+func (hc *HttpContext) BufferResponse() {
+	hc.bufferResponse = true
+}
+
+// IsBufferResponse whether some filter requires the response body to be buffered
+func (hc *HttpContext) IsBufferResponse() bool {
+	return hc.bufferResponse
+}
+
+// OnResponseWritten register fn which is called with the body size after the response is written to client,
+// filters use it to observe the streamed response body which is not buffered in TargetResp
+func (hc *HttpContext) OnResponseWritten(fn func(size int64)) {
+	hc.responseWritten = append(hc.responseWritten, fn)
+}
+
+// ResponseWritten call the registered callbacks, the http connection manager calls it once the response is written
+func (hc *HttpContext) ResponseWritten(size int64) {
+	for _, fn := range hc.responseWritten {
+		fn(size)
+	}
+}
+
 // GetAPI get api
 func (hc *HttpContext) GetAPI() *router.API {
 	return hc.Api
@@ -231,8 +323,3 @@ func (hc *HttpContext) AppendFilterFunc(ff ...FilterFunc) {
 		hc.Filters = append(hc.Filters, v)
 	}
 }
-
-func (hc *HttpContext) GenerateHash() string {
-	req := hc.Request
-	return req.Method + "." + req.RequestURI
-}
@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+import (
+	jwt4 "github.com/golang-jwt/jwt/v4"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const bearerPrefix = "Bearer "
+
+// GenerateHash the hash key by the hash policies of route, the request line is hashed by default
+func (hc *HttpContext) GenerateHash() string {
+	return hc.GenerateHashWithPolicy(nil)
+}
+
+// GenerateHashWithPolicy the hash key by the hash policies of route or the given ones of cluster,
+// the first policy which finds a key wins
+func (hc *HttpContext) GenerateHashWithPolicy(policies []model.HashPolicy) string {
+	if hc.Route != nil && len(hc.Route.HashPolicy) > 0 {
+		policies = hc.Route.HashPolicy
+	}
+	for i := range policies {
+		if key, ok := hc.hashKey(&policies[i]); ok {
+			return key
+		}
+	}
+	req := hc.Request
+	return req.Method + "." + req.RequestURI
+}
+
+func (hc *HttpContext) hashKey(policy *model.HashPolicy) (string, bool) {
+	req := hc.Request
+	switch {
+	case policy.Header != "":
+		v := req.Header.Get(policy.Header)
+		return v, v != ""
+	case policy.Cookie != nil:
+		return hc.hashCookie(policy.Cookie)
+	case policy.QueryParameter != "":
+		v := req.URL.Query().Get(policy.QueryParameter)
+		return v, v != ""
+	case policy.SourceIP:
+		ip := hc.GetClientIP()
+		return ip, ip != ""
+	case policy.JwtClaim != "":
+		return hc.jwtClaim(policy.JwtClaim)
+	}
+	return "", false
+}
+
+// hashCookie the value of cookie, generate one for the client if ttl is set
+func (hc *HttpContext) hashCookie(c *model.HashCookie) (string, bool) {
+	if cookie, err := hc.Request.Cookie(c.Name); err == nil && cookie.Value != "" {
+		return cookie.Value, true
+	}
+	if c.TTL == "" || hc.Writer == nil {
+		return "", false
+	}
+	ttl, err := time.ParseDuration(c.TTL)
+	if err != nil {
+		logger.Warnf("[dubbo-go-pixiu] invalid ttl %s of hash cookie %s: %v", c.TTL, c.Name, err)
+		return "", false
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", false
+	}
+	cookie := &http.Cookie{
+		Name:     c.Name,
+		Value:    hex.EncodeToString(b),
+		Path:     c.Path,
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+	}
+	hc.AddHeader("Set-Cookie", cookie.String())
+	// the endpoint picked again for retry gets the same key
+	hc.Request.AddCookie(cookie)
+	return cookie.Value, true
+}
+
+// jwtClaim the claim of the token verified by the jwt filter ahead. Without jwt filter, the bearer token is parsed
+// but its signature is NOT verified, so the client can choose the key, which is acceptable for load balancing only
+func (hc *HttpContext) jwtClaim(name string) (string, bool) {
+	claims := hc.GetJwtClaims()
+	if claims == nil {
+		claims = hc.unverifiedJwtClaims()
+	}
+	if claims == nil {
+		return "", false
+	}
+
+	v, ok := LookupClaim(claims, name)
+	if !ok || v == nil {
+		return "", false
+	}
+	return ClaimString(v), true
+}
+
+// unverifiedJwtClaims parse the claims of bearer token without verifying the signature, nil if there is no token
+func (hc *HttpContext) unverifiedJwtClaims() map[string]interface{} {
+	auth := hc.Request.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, bearerPrefix) {
+		return nil
+	}
+	claims := jwt4.MapClaims{}
+	if _, _, err := jwt4.NewParser().ParseUnverified(auth[len(bearerPrefix):], claims); err != nil {
+		return nil
+	}
+	return claims
+}
@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+import (
+	jwt4 "github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func newHashContext(target string) *HttpContext {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	r.RemoteAddr = "10.0.0.1:12345"
+	return &HttpContext{Request: r, Writer: httptest.NewRecorder()}
+}
+
+func TestGenerateHashWithPolicy(t *testing.T) {
+	policies := []model.HashPolicy{
+		{Header: "x-user"},
+		{QueryParameter: "uid"},
+		{SourceIP: true},
+	}
+
+	hc := newHashContext("/api?uid=7")
+	hc.Request.Header.Set("x-user", "alice")
+	assert.Equal(t, "alice", hc.GenerateHashWithPolicy(policies))
+
+	// fallback to the next policy
+	hc = newHashContext("/api?uid=7")
+	assert.Equal(t, "7", hc.GenerateHashWithPolicy(policies))
+	hc = newHashContext("/api")
+	assert.Equal(t, "10.0.0.1", hc.GenerateHashWithPolicy(policies))
+
+	// the request line is hashed without policy
+	assert.Equal(t, "GET./api", hc.GenerateHash())
+	assert.Equal(t, "GET./api", hc.GenerateHashWithPolicy([]model.HashPolicy{{Header: "x-user"}}))
+
+	// the policies of route take precedence
+	hc = newHashContext("/api?uid=7")
+	hc.Route = &model.RouteAction{HashPolicy: []model.HashPolicy{{QueryParameter: "uid"}}}
+	hc.Request.Header.Set("x-user", "alice")
+	assert.Equal(t, "7", hc.GenerateHashWithPolicy(policies))
+	assert.Equal(t, "7", hc.GenerateHash())
+}
+
+func TestGenerateHashCookie(t *testing.T) {
+	policies := []model.HashPolicy{{Cookie: &model.HashCookie{Name: "session", Path: "/", TTL: "1h"}}}
+
+	hc := newHashContext("/api")
+	hc.Request.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	assert.Equal(t, "abc", hc.GenerateHashWithPolicy(policies))
+	assert.Empty(t, hc.Writer.Header().Get("Set-Cookie"))
+
+	// generate cookie and keep it for the following picks
+	hc = newHashContext("/api")
+	key := hc.GenerateHashWithPolicy(policies)
+	assert.Len(t, key, 32)
+	assert.Equal(t, key, hc.GenerateHashWithPolicy(policies))
+	setCookie := hc.Writer.Header().Values("Set-Cookie")
+	assert.Len(t, setCookie, 1)
+	assert.True(t, strings.HasPrefix(setCookie[0], "session="+key))
+	assert.Contains(t, setCookie[0], "Max-Age=3600")
+
+	// no ttl, no cookie generated
+	hc = newHashContext("/api")
+	assert.Equal(t, "GET./api", hc.GenerateHashWithPolicy([]model.HashPolicy{{Cookie: &model.HashCookie{Name: "session"}}}))
+}
+
+func TestGenerateHashJwtClaim(t *testing.T) {
+	token, err := jwt4.NewWithClaims(jwt4.SigningMethodHS256, jwt4.MapClaims{
+		"sub":  "alice",
+		"user": map[string]interface{}{"id": 42},
+	}).SignedString([]byte("secret"))
+	assert.NoError(t, err)
+
+	hc := newHashContext("/api")
+	hc.Request.Header.Set("Authorization", "Bearer "+token)
+	assert.Equal(t, "alice", hc.GenerateHashWithPolicy([]model.HashPolicy{{JwtClaim: "sub"}}))
+	assert.Equal(t, "42", hc.GenerateHashWithPolicy([]model.HashPolicy{{JwtClaim: "user.id"}}))
+	assert.Equal(t, "GET./api", hc.GenerateHashWithPolicy([]model.HashPolicy{{JwtClaim: "user.name"}}))
+
+	hc.Request.Header.Set("Authorization", "Bearer invalid")
+	assert.Equal(t, "GET./api", hc.GenerateHashWithPolicy([]model.HashPolicy{{JwtClaim: "sub"}}))
+
+	// the claims verified by jwt filter are preferred
+	hc.SetJwtClaims(map[string]interface{}{"sub": "bob"})
+	assert.Equal(t, "bob", hc.GenerateHashWithPolicy([]model.HashPolicy{{JwtClaim: "sub"}}))
+}
@@ -91,10 +91,21 @@ func (f *Filter) Encode(c *http.HttpContext) filter.FilterStatus {
 	latency := time.Since(f.start)
 	// build access_log message
 	accessLogMsg := buildAccessLogMsg(c, latency)
+	if c.TargetResp == nil && !c.LocalReply() {
+		// the response body is streamed to client, log its size once it is written
+		c.OnResponseWritten(func(size int64) {
+			f.write(accessLogMsg + fmt.Sprintf(" response size [ %d ]", size))
+		})
+		return filter.Continue
+	}
+	f.write(accessLogMsg)
+	return filter.Continue
+}
+
+func (f *Filter) write(accessLogMsg string) {
 	if len(accessLogMsg) > 0 {
 		f.alw.Writer(AccessLogData{AccessLogConfig: *f.conf, AccessLogMsg: accessLogMsg})
 	}
-	return filter.Continue
 }
 
 // Config return config of filter
@@ -125,6 +136,11 @@ func buildAccessLogMsg(c *http.HttpContext, cost time.Duration) string {
 	builder.WriteString(" -> ")
 	builder.WriteString(req.Host)
 	builder.WriteString(" - ")
+	if consumer := c.GetConsumer(); consumer != "" {
+		builder.WriteString("consumer [ ")
+		builder.WriteString(consumer)
+		builder.WriteString(" ] ")
+	}
 	if len(valueStr) > 0 {
 		builder.WriteString("request params: [")
 		builder.WriteString(valueStr)
@@ -137,13 +153,15 @@ func buildAccessLogMsg(c *http.HttpContext, cost time.Duration) string {
 		builder.WriteString(fmt.Sprintf("invoke err [ %v", err))
 		builder.WriteString("] ")
 	}
-	resp := c.TargetResp.Data
-	if err != nil {
-		builder.WriteString(" response can not convert to string")
-		builder.WriteString("] ")
-	} else {
-		builder.WriteString(fmt.Sprintf(" response [ %+v", string(resp)))
-		builder.WriteString("] ")
+	if c.TargetResp != nil {
+		resp := c.TargetResp.Data
+		if err != nil {
+			builder.WriteString(" response can not convert to string")
+			builder.WriteString("] ")
+		} else {
+			builder.WriteString(fmt.Sprintf(" response [ %+v", string(resp)))
+			builder.WriteString("] ")
+		}
 	}
 	// builder.WriteString("\n")
 	return builder.String()
@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package apikey
+
+import (
+	"encoding/json"
+	"fmt"
+	stdHttp "net/http"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	Kind = constant.HTTPAuthApiKeyFilter
+)
+
+const (
+	defaultKeyHeader     = "X-API-Key"
+	defaultForwardHeader = "X-Consumer"
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg    *Config
+		store  *consumerStore
+		errMsg []byte
+	}
+
+	Filter struct {
+		cfg    *Config
+		store  *consumerStore
+		errMsg []byte
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	store, err := newConsumerStore(factory.cfg.Consumers)
+	if err != nil {
+		return err
+	}
+	if factory.cfg.Nacos != nil {
+		if factory.cfg.Nacos.DataId == "" {
+			return fmt.Errorf("nacos data_id of api key consumers is empty")
+		}
+		if err = store.watch(factory.cfg.Nacos); err != nil {
+			return fmt.Errorf("load api key consumers from nacos: %w", err)
+		}
+	}
+	factory.store = store
+
+	if factory.cfg.KeyHeader == "" {
+		factory.cfg.KeyHeader = defaultKeyHeader
+	}
+	if factory.cfg.ForwardHeader == "" {
+		factory.cfg.ForwardHeader = defaultForwardHeader
+	}
+	if factory.cfg.ErrMsg == "" {
+		factory.cfg.ErrMsg = "api key invalid"
+	}
+	errMsg, _ := json.Marshal(http.ErrResponse{Message: factory.cfg.ErrMsg})
+	factory.errMsg = errMsg
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, store: factory.store, errMsg: factory.errMsg}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	// never trust the consumer header sent by client
+	ctx.Request.Header.Del(f.cfg.ForwardHeader)
+
+	key := f.extractKey(ctx)
+	if key == "" {
+		ctx.SendLocalReply(stdHttp.StatusUnauthorized, f.errMsg)
+		return filter.Stop
+	}
+
+	consumer := f.store.lookup(key)
+	if consumer == nil {
+		logger.Debugf("[dubbo-go-pixiu] api key of %s is rejected", ctx.GetClientIP())
+		ctx.SendLocalReply(stdHttp.StatusUnauthorized, f.errMsg)
+		return filter.Stop
+	}
+
+	if !consumer.allowed(ctx.GetUrl()) {
+		logger.Debugf("[dubbo-go-pixiu] consumer %s is not allowed to access %s", consumer.Name, ctx.GetUrl())
+		ctx.SendLocalReply(stdHttp.StatusForbidden, constant.Default403Body)
+		return filter.Stop
+	}
+
+	if f.cfg.HideCredentials {
+		f.hideKey(ctx)
+	}
+	ctx.SetConsumer(consumer.Name, consumer.Tier)
+	ctx.Request.Header.Set(f.cfg.ForwardHeader, consumer.Name)
+	return filter.Continue
+}
+
+// extractKey get the key from the header first, then the query parameter
+func (f *Filter) extractKey(ctx *http.HttpContext) string {
+	if key := ctx.Request.Header.Get(f.cfg.KeyHeader); key != "" {
+		return key
+	}
+	if f.cfg.KeyQuery != "" {
+		return ctx.Request.URL.Query().Get(f.cfg.KeyQuery)
+	}
+	return ""
+}
+
+func (f *Filter) hideKey(ctx *http.HttpContext) {
+	ctx.Request.Header.Del(f.cfg.KeyHeader)
+	if f.cfg.KeyQuery == "" {
+		return
+	}
+	query := ctx.Request.URL.Query()
+	if _, ok := query[f.cfg.KeyQuery]; !ok {
+		return
+	}
+	query.Del(f.cfg.KeyQuery)
+	ctx.Request.URL.RawQuery = query.Encode()
+	if ctx.Request.RequestURI != "" {
+		ctx.Request.RequestURI = ctx.Request.URL.RequestURI()
+	}
+}
@@ -0,0 +1,209 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package apikey
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+import (
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func digest(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func decode(f *Filter, target string, header http.Header) (*contexthttp.HttpContext, filter.FilterStatus) {
+	request, _ := http.NewRequest("GET", target, nil)
+	for k, vs := range header {
+		request.Header[k] = vs
+	}
+	request.Header.Set("X-Consumer", "spoofed")
+	ctx := mock.GetMockHTTPContext(request)
+	return ctx, f.Decode(ctx)
+}
+
+func TestFilter(t *testing.T) {
+	store, err := newConsumerStore([]Consumer{
+		{Name: "partner-a", Keys: []string{digest("key-a1"), digest("key-a2")}, Tier: "gold"},
+		{Name: "partner-b", Keys: []string{digest("key-b")}, Tier: "free", AllowedRoutes: []string{"/api/orders"}},
+	})
+	assert.NoError(t, err)
+	f := &Filter{
+		cfg:    &Config{KeyHeader: "X-Api-Key", KeyQuery: "apikey", ForwardHeader: "X-Consumer", HideCredentials: true},
+		store:  store,
+		errMsg: []byte("api key invalid"),
+	}
+
+	ctx, status := decode(f, "/api/users", http.Header{"X-Api-Key": {"key-a2"}})
+	assert.Equal(t, filter.Continue, status)
+	assert.Equal(t, "partner-a", ctx.GetConsumer())
+	assert.Equal(t, "gold", ctx.GetConsumerTier())
+	assert.Equal(t, "partner-a", ctx.Request.Header.Get("X-Consumer"))
+	assert.Empty(t, ctx.Request.Header.Get("X-Api-Key"))
+
+	ctx, status = decode(f, "/api/orders/1?apikey=key-b&page=2", nil)
+	assert.Equal(t, filter.Continue, status)
+	assert.Equal(t, "partner-b", ctx.GetConsumer())
+	assert.Equal(t, "page=2", ctx.Request.URL.RawQuery)
+
+	// not in the allowed routes
+	ctx, status = decode(f, "/api/users?apikey=key-b", nil)
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, http.StatusForbidden, ctx.GetStatusCode())
+
+	ctx, status = decode(f, "/api/users", http.Header{"X-Api-Key": {"unknown"}})
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, http.StatusUnauthorized, ctx.GetStatusCode())
+
+	ctx, status = decode(f, "/api/users", nil)
+	assert.Equal(t, filter.Stop, status)
+	assert.Empty(t, ctx.GetConsumer())
+	assert.Empty(t, ctx.Request.Header.Get("X-Consumer"))
+}
+
+func TestAllowedRoutes(t *testing.T) {
+	c := &Consumer{Name: "partner", AllowedRoutes: []string{"/api/orders", "/api/users/"}}
+	assert.True(t, c.allowed("/api/orders"))
+	assert.True(t, c.allowed("/api/orders/1"))
+	assert.False(t, c.allowed("/api/orders-admin"))
+	assert.False(t, c.allowed("/api/ordersx/1"))
+	assert.True(t, c.allowed("/api/users/1"))
+	assert.False(t, c.allowed("/api/users-admin"))
+	assert.False(t, c.allowed("/api"))
+
+	c.AllowedRoutes = nil
+	assert.True(t, c.allowed("/anything"))
+}
+
+type fakeConfigClient struct {
+	content  string
+	onChange func(namespace, group, dataId, data string)
+}
+
+func (c *fakeConfigClient) GetConfig(vo.ConfigParam) (string, error) {
+	return c.content, nil
+}
+
+func (c *fakeConfigClient) ListenConfig(param vo.ConfigParam) error {
+	c.onChange = param.OnChange
+	return nil
+}
+
+func TestNacos(t *testing.T) {
+	client := &fakeConfigClient{content: `
+consumers:
+  - name: partner-c
+    keys:
+      - ` + digest("key-c") + `
+    tier: silver
+`}
+	origin := newConfigClient
+	newConfigClient = func(*Nacos) (configClient, error) {
+		return client, nil
+	}
+	defer func() {
+		newConfigClient = origin
+	}()
+
+	store, err := newConsumerStore([]Consumer{{Name: "partner-a", Keys: []string{digest("key-a")}}})
+	assert.NoError(t, err)
+	assert.NoError(t, store.watch(&Nacos{Address: "127.0.0.1:8848", DataId: "pixiu-consumers"}))
+	f := &Filter{cfg: &Config{KeyHeader: "X-Api-Key", ForwardHeader: "X-Consumer"}, store: store}
+
+	ctx, status := decode(f, "/api", http.Header{"X-Api-Key": {"key-c"}})
+	assert.Equal(t, filter.Continue, status)
+	assert.Equal(t, "silver", ctx.GetConsumerTier())
+	_, status = decode(f, "/api", http.Header{"X-Api-Key": {"key-a"}})
+	assert.Equal(t, filter.Continue, status)
+
+	// the key of partner-c is rotated
+	client.onChange("", "DEFAULT_GROUP", "pixiu-consumers", "consumers: [{name: partner-c, keys: ["+digest("key-c2")+"]}]")
+	_, status = decode(f, "/api", http.Header{"X-Api-Key": {"key-c"}})
+	assert.Equal(t, filter.Stop, status)
+	_, status = decode(f, "/api", http.Header{"X-Api-Key": {"key-c2"}})
+	assert.Equal(t, filter.Continue, status)
+
+	// the invalid config is ignored
+	client.onChange("", "DEFAULT_GROUP", "pixiu-consumers", "consumers: [{name: partner-c, keys: [plain-key]}]")
+	_, status = decode(f, "/api", http.Header{"X-Api-Key": {"key-c2"}})
+	assert.Equal(t, filter.Continue, status)
+}
+
+func TestApply(t *testing.T) {
+	p := &Plugin{}
+	factory, err := p.CreateFilterFactory()
+	assert.NoError(t, err)
+	*factory.Config().(*Config) = Config{Consumers: []Consumer{{Name: "partner", Keys: []string{"plain-key"}}}}
+	assert.Error(t, factory.Apply())
+
+	*factory.Config().(*Config) = Config{Consumers: []Consumer{{Name: "a", Keys: []string{digest("k")}}, {Name: "b", Keys: []string{digest("k")}}}}
+	assert.Error(t, factory.Apply())
+
+	// the data id is required
+	*factory.Config().(*Config) = Config{Nacos: &Nacos{Address: "127.0.0.1:8848"}}
+	assert.Error(t, factory.Apply())
+
+	*factory.Config().(*Config) = Config{Consumers: []Consumer{{Name: "partner-a", Keys: []string{digest("key-a")}}}}
+	assert.NoError(t, factory.Apply())
+	f := factory.(*FilterFactory)
+	assert.Equal(t, defaultKeyHeader, f.cfg.KeyHeader)
+	assert.Equal(t, defaultForwardHeader, f.cfg.ForwardHeader)
+	assert.Equal(t, `{"message":"api key invalid"}`, string(f.errMsg))
+	assert.Equal(t, "partner-a", f.store.lookup("key-a").Name)
+}
+
+func TestApplyNacos(t *testing.T) {
+	client := &fakeConfigClient{content: "consumers: [{name: partner-c, keys: [" + digest("key-c") + "]}]"}
+	var watched *Nacos
+	origin := newConfigClient
+	newConfigClient = func(cfg *Nacos) (configClient, error) {
+		watched = cfg
+		return client, nil
+	}
+	defer func() {
+		newConfigClient = origin
+	}()
+
+	p := &Plugin{}
+	factory, err := p.CreateFilterFactory()
+	assert.NoError(t, err)
+	nacosCfg := &Nacos{Address: "127.0.0.1:8848", DataId: "pixiu-consumers"}
+	*factory.Config().(*Config) = Config{Nacos: nacosCfg}
+	assert.NoError(t, factory.Apply())
+	assert.Same(t, nacosCfg, watched)
+	assert.NotNil(t, client.onChange)
+	f := factory.(*FilterFactory)
+	assert.Equal(t, "partner-c", f.store.lookup("key-c").Name)
+
+	// the consumers in nacos are invalid
+	client.content = "consumers: [{name: partner-c, keys: [plain-key]}]"
+	assert.Error(t, factory.Apply())
+}
@@ -0,0 +1,58 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package apikey
+
+type (
+	// Config describe the config of FilterFactory, the consumers come from the config and the nacos config center
+	Config struct {
+		// KeyHeader the header carrying the key, default is X-API-Key
+		KeyHeader string `yaml:"key_header" json:"key_header" mapstructure:"key_header"`
+		// KeyQuery the query parameter carrying the key if the header is absent, disabled if empty
+		KeyQuery string `yaml:"key_query" json:"key_query,omitempty" mapstructure:"key_query"`
+		// HideCredentials remove the key from the upstream request
+		HideCredentials bool `yaml:"hide_credentials" json:"hide_credentials,omitempty" mapstructure:"hide_credentials"`
+		// ForwardHeader the header to forward the consumer name upstream, default is X-Consumer
+		ForwardHeader string     `yaml:"forward_header" json:"forward_header" mapstructure:"forward_header"`
+		ErrMsg        string     `yaml:"err_msg" json:"err_msg" mapstructure:"err_msg"`
+		Consumers     []Consumer `yaml:"consumers" json:"consumers" mapstructure:"consumers"`
+		Nacos         *Nacos     `yaml:"nacos" json:"nacos,omitempty" mapstructure:"nacos"`
+	}
+
+	// Consumer the caller owning the keys
+	Consumer struct {
+		Name string `yaml:"name" json:"name" mapstructure:"name"`
+		// Keys the hex encoded sha256 digests of keys, like the output of `echo -n $KEY | sha256sum`,
+		// the plain keys are never stored
+		Keys []string `yaml:"keys" json:"keys" mapstructure:"keys"`
+		// Tier the rate limit tier, used by the match of rate limit filters
+		Tier string `yaml:"tier" json:"tier,omitempty" mapstructure:"tier"`
+		// AllowedRoutes the path prefixes the consumer is allowed to access, matched on the path segment boundary, empty allows all
+		AllowedRoutes []string `yaml:"allowed_routes" json:"allowed_routes,omitempty" mapstructure:"allowed_routes"`
+	}
+
+	// Nacos the consumers are kept in the nacos config center as yaml like `consumers: [...]`,
+	// and are reloaded when the config changes
+	Nacos struct {
+		Address   string `yaml:"address" json:"address" mapstructure:"address"` // comma separated host:port
+		Namespace string `yaml:"namespace" json:"namespace,omitempty" mapstructure:"namespace"`
+		DataId    string `yaml:"data_id" json:"data_id" mapstructure:"data_id"`
+		Group     string `default:"DEFAULT_GROUP" yaml:"group" json:"group" mapstructure:"group"`
+		Timeout   string `default:"10s" yaml:"timeout" json:"timeout" mapstructure:"timeout"`
+		Username  string `yaml:"username" json:"username,omitempty" mapstructure:"username"`
+		Password  string `yaml:"password" json:"password,omitempty" mapstructure:"password"`
+	}
+)
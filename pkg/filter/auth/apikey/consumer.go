@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package apikey
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+import (
+	"github.com/nacos-group/nacos-sdk-go/vo"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/yaml"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+	"github.com/apache/dubbo-go-pixiu/pkg/remote/nacos"
+)
+
+// configClient the part of nacos config client used to load the consumers
+type configClient interface {
+	GetConfig(param vo.ConfigParam) (string, error)
+	ListenConfig(param vo.ConfigParam) error
+}
+
+// newConfigClient create the nacos config client, replaced in tests
+var newConfigClient = func(cfg *Nacos) (configClient, error) {
+	return nacos.NewNacosConfigClient(&model.RemoteConfig{
+		Address:  cfg.Address,
+		Timeout:  cfg.Timeout,
+		Username: cfg.Username,
+		Password: cfg.Password,
+	}, cfg.Namespace)
+}
+
+// consumers the consumers indexed by the digest of keys
+type consumers map[string]*Consumer
+
+// consumerStore the consumers are replaced as a whole when the nacos config changes
+type consumerStore struct {
+	static    []Consumer
+	consumers atomic.Value // consumers
+}
+
+func newConsumerStore(static []Consumer) (*consumerStore, error) {
+	s := &consumerStore{static: static}
+	if err := s.update(nil); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// update index the static consumers and the remote ones
+func (s *consumerStore) update(remote []Consumer) error {
+	index := consumers{}
+	for _, list := range [][]Consumer{s.static, remote} {
+		for i := range list {
+			c := &list[i]
+			if c.Name == "" {
+				return fmt.Errorf("consumer name is empty")
+			}
+			for _, key := range c.Keys {
+				digest := strings.ToLower(key)
+				if b, err := hex.DecodeString(digest); err != nil || len(b) != sha256.Size {
+					return fmt.Errorf("key of consumer %s is not a sha256 digest", c.Name)
+				}
+				if owner, ok := index[digest]; ok && owner.Name != c.Name {
+					return fmt.Errorf("key of consumer %s is owned by %s", c.Name, owner.Name)
+				}
+				index[digest] = c
+			}
+		}
+	}
+	s.consumers.Store(index)
+	return nil
+}
+
+// lookup the consumer owning the plain key
+func (s *consumerStore) lookup(key string) *Consumer {
+	sum := sha256.Sum256([]byte(key))
+	return s.consumers.Load().(consumers)[hex.EncodeToString(sum[:])]
+}
+
+// watch load the consumers from nacos and reload them when the config changes, the consumers
+// are kept if the changed config is invalid
+func (s *consumerStore) watch(cfg *Nacos) error {
+	client, err := newConfigClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	param := vo.ConfigParam{DataId: cfg.DataId, Group: cfg.Group}
+	content, err := client.GetConfig(param)
+	if err != nil {
+		return err
+	}
+	if err = s.load(content); err != nil {
+		return err
+	}
+
+	param.OnChange = func(_, _, dataId, data string) {
+		if err := s.load(data); err != nil {
+			logger.Warnf("[dubbo-go-pixiu] api key consumers in nacos %s are invalid, keep the previous ones: %v", dataId, err)
+			return
+		}
+		logger.Infof("[dubbo-go-pixiu] api key consumers in nacos %s are reloaded", dataId)
+	}
+	return client.ListenConfig(param)
+}
+
+func (s *consumerStore) load(content string) error {
+	remote := struct {
+		Consumers []Consumer `yaml:"consumers"`
+	}{}
+	if err := yaml.UnmarshalYML([]byte(content), &remote); err != nil {
+		return err
+	}
+	return s.update(remote.Consumers)
+}
+
+// allowed whether the consumer is allowed to access the path, the prefix matches on the path segment
+// boundary, i.e. /api/orders allows /api/orders and /api/orders/1 but not /api/orders-admin
+func (c *Consumer) allowed(path string) bool {
+	if len(c.AllowedRoutes) == 0 {
+		return true
+	}
+	for _, prefix := range c.AllowedRoutes {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
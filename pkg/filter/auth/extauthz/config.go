@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package extauthz
+
+type (
+	// Config describe the config of FilterFactory, one of HttpService and GrpcService is required
+	Config struct {
+		HttpService *HttpService `yaml:"http_service" json:"http_service,omitempty" mapstructure:"http_service"`
+		GrpcService *GrpcService `yaml:"grpc_service" json:"grpc_service,omitempty" mapstructure:"grpc_service"`
+		// Timeout of each call to the authorization service, default 200ms
+		Timeout string `default:"200ms" yaml:"timeout" json:"timeout,omitempty" mapstructure:"timeout"`
+		// FailureModeAllow allow the request if the authorization service fails, otherwise 403 is returned
+		FailureModeAllow bool `yaml:"failure_mode_allow" json:"failure_mode_allow,omitempty" mapstructure:"failure_mode_allow"`
+		// WithRequestBody send the prefix of request body to the authorization service
+		WithRequestBody *BufferSettings `yaml:"with_request_body" json:"with_request_body,omitempty" mapstructure:"with_request_body"`
+		// Cache the decisions, disabled if nil
+		Cache *CacheConfig `yaml:"cache" json:"cache,omitempty" mapstructure:"cache"`
+	}
+
+	// HttpService the authorization service receives the request with the same method, the path prefixed
+	// by PathPrefix and the allowed headers, the request is allowed if 200 is answered
+	HttpService struct {
+		// Cluster the cluster of authorization service, the endpoint is picked by its load balancer
+		Cluster string `yaml:"cluster" json:"cluster,omitempty" mapstructure:"cluster"`
+		// Address the address of authorization service, used when cluster is empty
+		Address    string `yaml:"address" json:"address,omitempty" mapstructure:"address"`
+		PathPrefix string `yaml:"path_prefix" json:"path_prefix,omitempty" mapstructure:"path_prefix"`
+		// AllowedHeaders the request headers sent to the authorization service besides Authorization
+		AllowedHeaders []string `yaml:"allowed_headers" json:"allowed_headers,omitempty" mapstructure:"allowed_headers"`
+		// AllowedUpstreamHeaders the headers of authorization response added to the upstream request if allowed
+		AllowedUpstreamHeaders []string `yaml:"allowed_upstream_headers" json:"allowed_upstream_headers,omitempty" mapstructure:"allowed_upstream_headers"`
+		// AllowedClientHeaders the headers of authorization response sent to client if denied, empty means all
+		AllowedClientHeaders []string `yaml:"allowed_client_headers" json:"allowed_client_headers,omitempty" mapstructure:"allowed_client_headers"`
+	}
+
+	// GrpcService the authorization service speaking envoy.service.auth.v3, all the request headers are sent
+	GrpcService struct {
+		Cluster string `yaml:"cluster" json:"cluster,omitempty" mapstructure:"cluster"`
+		Address string `yaml:"address" json:"address,omitempty" mapstructure:"address"`
+	}
+
+	// BufferSettings the request larger than MaxRequestBytes is rejected with 413 unless AllowPartialMessage
+	BufferSettings struct {
+		MaxRequestBytes     int  `yaml:"max_request_bytes" json:"max_request_bytes" mapstructure:"max_request_bytes"`
+		AllowPartialMessage bool `yaml:"allow_partial_message" json:"allow_partial_message,omitempty" mapstructure:"allow_partial_message"`
+	}
+
+	// CacheConfig the decision is cached by everything sent to the authorization service, i.e. the method, the host,
+	// the uri, the headers sent and the body prefix. only HttpService supports it, the check request of GrpcService
+	// carries the source address and all the headers, so the decision would never be reused
+	CacheConfig struct {
+		TTL string `default:"30s" yaml:"ttl" json:"ttl,omitempty" mapstructure:"ttl"`
+		// MaxEntries the least recently used decision is evicted, default 10000
+		MaxEntries int `yaml:"max_entries" json:"max_entries,omitempty" mapstructure:"max_entries"`
+	}
+)
@@ -0,0 +1,303 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package extauthz
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	stdHttp "net/http"
+	"time"
+)
+
+import (
+	lru "github.com/hashicorp/golang-lru"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/util/stringutil"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+const (
+	Kind = constant.HTTPAuthExtAuthzFilter
+)
+
+const (
+	defaultTimeout         = 200 * time.Millisecond
+	defaultCacheTTL        = 30 * time.Second
+	defaultCacheMaxEntries = 10000
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg        *Config
+		authorizer authorizer
+		cache      *lru.Cache
+		cacheTTL   time.Duration
+		failMsg    []byte
+		// connections to the grpc authorization service
+		connections *server.GrpcConnections
+	}
+
+	Filter struct {
+		cfg        *Config
+		authorizer authorizer
+		cache      *lru.Cache
+		cacheTTL   time.Duration
+		failMsg    []byte
+	}
+
+	// authorizer ask the authorization service whether the request is allowed
+	authorizer interface {
+		check(ctx *http.HttpContext, body []byte) (*decision, error)
+		// cacheKey the key of decision covers everything sent by check, so that a decision is never
+		// reused for the request the service may answer differently. empty means not cacheable
+		cacheKey(ctx *http.HttpContext, body []byte) string
+	}
+
+	// decision the answer of authorization service
+	decision struct {
+		allowed bool
+		// status and body are sent to client if denied
+		status int
+		body   []byte
+		// upstreamHeaders are set on the upstream request if allowed
+		upstreamHeaders stdHttp.Header
+		// appendHeaders are added to the upstream request if allowed
+		appendHeaders stdHttp.Header
+		removeHeaders []string
+		// clientHeaders are added to the response
+		clientHeaders stdHttp.Header
+		expires       time.Time
+	}
+)
+
+func (p Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}, connections: server.NewGrpcConnections()}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	cfg := factory.cfg
+	timeout := stringutil.ResolveTimeStr2Time(cfg.Timeout, defaultTimeout)
+
+	switch {
+	case cfg.HttpService != nil:
+		if cfg.HttpService.Cluster == "" && cfg.HttpService.Address == "" {
+			return fmt.Errorf("ext authz http service requires cluster or address")
+		}
+		factory.authorizer = newHttpAuthorizer(cfg.HttpService, timeout)
+	case cfg.GrpcService != nil:
+		if cfg.GrpcService.Cluster == "" && cfg.GrpcService.Address == "" {
+			return fmt.Errorf("ext authz grpc service requires cluster or address")
+		}
+		factory.authorizer = newGrpcAuthorizer(cfg.GrpcService, timeout, factory.connections)
+	default:
+		return fmt.Errorf("ext authz requires http_service or grpc_service")
+	}
+
+	if cfg.WithRequestBody != nil && cfg.WithRequestBody.MaxRequestBytes <= 0 {
+		return fmt.Errorf("ext authz max_request_bytes must be positive")
+	}
+	if cfg.Cache != nil && cfg.GrpcService != nil {
+		return fmt.Errorf("ext authz cache is not supported with grpc_service")
+	}
+
+	factory.cache = nil
+	if cfg.Cache != nil {
+		maxEntries := cfg.Cache.MaxEntries
+		if maxEntries <= 0 {
+			maxEntries = defaultCacheMaxEntries
+		}
+		cache, err := lru.New(maxEntries)
+		if err != nil {
+			return err
+		}
+		factory.cache = cache
+		factory.cacheTTL = stringutil.ResolveTimeStr2Time(cfg.Cache.TTL, defaultCacheTTL)
+	}
+
+	factory.failMsg, _ = json.Marshal(http.ErrResponse{Message: "authorization service unavailable"})
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{
+		cfg:        factory.cfg,
+		authorizer: factory.authorizer,
+		cache:      factory.cache,
+		cacheTTL:   factory.cacheTTL,
+		failMsg:    factory.failMsg,
+	}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+// Decode ask the authorization service before routing, the decision is taken from cache if possible
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	body, ok := f.bodyPrefix(ctx)
+	if !ok {
+		ctx.SendLocalReply(stdHttp.StatusRequestEntityTooLarge, []byte("request body too large"))
+		return filter.Stop
+	}
+
+	key := f.cacheKey(ctx, body)
+	d := f.cached(key)
+	if d == nil {
+		var err error
+		d, err = f.authorizer.check(ctx, body)
+		if err != nil {
+			logger.Warnf("[dubbo-go-pixiu] ext authz check %s error: %v", ctx.GetUrl(), err)
+			if f.cfg.FailureModeAllow {
+				return filter.Continue
+			}
+			ctx.SendLocalReply(stdHttp.StatusForbidden, f.failMsg)
+			return filter.Stop
+		}
+		f.store(key, d)
+	}
+
+	for k, vs := range d.clientHeaders {
+		for _, v := range vs {
+			ctx.AddHeader(k, v)
+		}
+	}
+
+	if !d.allowed {
+		ctx.SendLocalReply(d.status, d.body)
+		return filter.Stop
+	}
+
+	for _, k := range d.removeHeaders {
+		ctx.Request.Header.Del(k)
+	}
+	for k, vs := range d.upstreamHeaders {
+		ctx.Request.Header[k] = append([]string(nil), vs...)
+	}
+	for k, vs := range d.appendHeaders {
+		for _, v := range vs {
+			ctx.Request.Header.Add(k, v)
+		}
+	}
+	return filter.Continue
+}
+
+// bodyPrefix read the prefix of body sent to the authorization service, the body of request is kept intact,
+// return false if the body is too large and partial message is not allowed
+func (f *Filter) bodyPrefix(ctx *http.HttpContext) ([]byte, bool) {
+	settings := f.cfg.WithRequestBody
+	if settings == nil || ctx.Request.Body == nil || ctx.Request.Body == stdHttp.NoBody {
+		return nil, true
+	}
+
+	origin := ctx.Request.Body
+	prefix, err := io.ReadAll(io.LimitReader(origin, int64(settings.MaxRequestBytes)+1))
+	ctx.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), origin), origin}
+	if err != nil {
+		logger.Warnf("[dubbo-go-pixiu] ext authz read body error: %v", err)
+	}
+
+	if len(prefix) > settings.MaxRequestBytes {
+		if !settings.AllowPartialMessage {
+			return nil, false
+		}
+		prefix = prefix[:settings.MaxRequestBytes]
+	}
+	return prefix, true
+}
+
+func (f *Filter) cacheKey(ctx *http.HttpContext, body []byte) string {
+	if f.cache == nil {
+		return ""
+	}
+	return f.authorizer.cacheKey(ctx, body)
+}
+
+func (f *Filter) cached(key string) *decision {
+	if f.cache == nil || key == "" {
+		return nil
+	}
+	v, ok := f.cache.Get(key)
+	if !ok {
+		return nil
+	}
+	d := v.(*decision)
+	if time.Now().After(d.expires) {
+		f.cache.Remove(key)
+		return nil
+	}
+	return d
+}
+
+func (f *Filter) store(key string, d *decision) {
+	if f.cache == nil || key == "" {
+		return
+	}
+	d.expires = time.Now().Add(f.cacheTTL)
+	f.cache.Add(key, d)
+}
+
+// upstream the address of authorization service, and the tls config of its cluster which is nil for plaintext
+func upstream(ctx *http.HttpContext, cluster, address string) (string, *tls.Config, error) {
+	if cluster == "" {
+		return address, nil, nil
+	}
+	clusterManager := server.GetClusterManager()
+	endpoint := clusterManager.PickEndpoint(cluster, ctx)
+	if endpoint == nil {
+		return "", nil, fmt.Errorf("no endpoint available in cluster %s", cluster)
+	}
+	tlsConfig, err := clusterManager.UpstreamTlsConfig(cluster)
+	if err != nil {
+		return "", nil, err
+	}
+	return endpoint.Address.GetAddress(), tlsConfig, nil
+}
+
+// digest the sha256 of b in hex
+func digest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
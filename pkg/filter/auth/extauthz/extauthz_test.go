@@ -0,0 +1,282 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package extauthz
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/stretchr/testify/assert"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+// newFilter the filter asking the authorizer, the decisions are cached for a minute if cfg.Cache is set
+func newFilter(t *testing.T, cfg *Config, a authorizer) *Filter {
+	f := &Filter{cfg: cfg, authorizer: a, failMsg: []byte(`{"message":"authorization service unavailable"}`)}
+	if cfg.Cache != nil {
+		cache, err := lru.New(16)
+		assert.NoError(t, err)
+		f.cache = cache
+		f.cacheTTL = time.Minute
+	}
+	return f
+}
+
+func newHttpFilter(t *testing.T, cfg *Config) *Filter {
+	return newFilter(t, cfg, newHttpAuthorizer(cfg.HttpService, time.Second))
+}
+
+// decodeRequest the filter stops the request if a local reply is sent
+func decodeRequest(f *Filter, request *http.Request) (*contexthttp.HttpContext, filter.FilterStatus) {
+	ctx := mock.GetMockHTTPContext(request)
+	return ctx, f.Decode(ctx)
+}
+
+func decode(f *Filter, token, body string) (*contexthttp.HttpContext, filter.FilterStatus) {
+	request, _ := http.NewRequest("POST", "/api/users?id=1", strings.NewReader(body))
+	request.RemoteAddr = "10.0.0.1:5000"
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	request.Header.Set("X-User", "spoofed")
+	return decodeRequest(f, request)
+}
+
+func TestHttpService(t *testing.T) {
+	var calls int32
+	var received string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		assert.Equal(t, "/authz/api/users?id=1", r.URL.RequestURI())
+		b, _ := io.ReadAll(r.Body)
+		received = string(b)
+		switch r.Header.Get("Authorization") {
+		case "Bearer good":
+			w.Header().Set("X-User", "alice")
+			w.Header().Set("X-Internal", "secret")
+		case "Bearer broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte("unauthorized"))
+		}
+	}))
+	defer ts.Close()
+
+	service := &HttpService{
+		Address:                ts.Listener.Addr().String(),
+		PathPrefix:             "/authz",
+		AllowedUpstreamHeaders: []string{"X-User"},
+	}
+	f := newHttpFilter(t, &Config{HttpService: service, Cache: &CacheConfig{}})
+
+	ctx, s := decode(f, "good", "")
+	assert.Equal(t, filter.Continue, s)
+	assert.Equal(t, "alice", ctx.Request.Header.Get("X-User"))
+	assert.Empty(t, ctx.Request.Header.Get("X-Internal"))
+
+	// the decision is cached
+	_, s = decode(f, "good", "")
+	assert.Equal(t, filter.Continue, s)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	ctx, s = decode(f, "bad", "")
+	assert.Equal(t, filter.Stop, s)
+	assert.Equal(t, http.StatusUnauthorized, ctx.GetStatusCode())
+	assert.Equal(t, "unauthorized", string(ctx.GetLocalReplyBody()))
+	assert.Equal(t, "Bearer", ctx.Writer.Header().Get("WWW-Authenticate"))
+
+	ctx, s = decode(f, "broken", "")
+	assert.Equal(t, filter.Stop, s)
+	assert.Equal(t, http.StatusForbidden, ctx.GetStatusCode())
+
+	f = newHttpFilter(t, &Config{HttpService: service, FailureModeAllow: true})
+	_, s = decode(f, "broken", "")
+	assert.Equal(t, filter.Continue, s)
+
+	// body prefix
+	f = newHttpFilter(t, &Config{HttpService: service, WithRequestBody: &BufferSettings{MaxRequestBytes: 4}})
+	ctx, s = decode(f, "good", "hello world")
+	assert.Equal(t, filter.Stop, s)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, ctx.GetStatusCode())
+
+	f = newHttpFilter(t, &Config{HttpService: service, WithRequestBody: &BufferSettings{MaxRequestBytes: 4, AllowPartialMessage: true}})
+	ctx, s = decode(f, "good", "hello world")
+	assert.Equal(t, filter.Continue, s)
+	assert.Equal(t, "hell", received)
+	b, _ := io.ReadAll(ctx.Request.Body)
+	assert.Equal(t, "hello world", string(b))
+}
+
+func TestCacheKeyCoversAllowedHeaders(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if c, err := r.Cookie("session"); err != nil || c.Value != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+
+	f := newHttpFilter(t, &Config{
+		HttpService: &HttpService{Address: ts.Listener.Addr().String(), AllowedHeaders: []string{"Cookie"}},
+		Cache:       &CacheConfig{},
+	})
+	withCookie, _ := http.NewRequest("GET", "/api/users", nil)
+	withCookie.AddCookie(&http.Cookie{Name: "session", Value: "good"})
+	_, s := decodeRequest(f, withCookie)
+	assert.Equal(t, filter.Continue, s)
+
+	// the allow of the cookie authenticated request is not reused by the one without cookie
+	withoutCookie, _ := http.NewRequest("GET", "/api/users", nil)
+	ctx, s := decodeRequest(f, withoutCookie)
+	assert.Equal(t, filter.Stop, s)
+	assert.Equal(t, http.StatusUnauthorized, ctx.GetStatusCode())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestHttpClientByTlsConfig(t *testing.T) {
+	a := newHttpAuthorizer(&HttpService{Address: "127.0.0.1:9000"}, time.Second)
+	assert.Same(t, a.plain, a.client(nil))
+
+	tlsConfig := &tls.Config{}
+	client := a.client(tlsConfig)
+	assert.NotSame(t, a.plain, client)
+	assert.Same(t, tlsConfig, client.Transport.(*http.Transport).TLSClientConfig)
+	assert.Same(t, client, a.client(tlsConfig))
+
+	// the client is replaced when the tls config of cluster changes
+	assert.NotSame(t, client, a.client(&tls.Config{}))
+}
+
+type authService struct {
+}
+
+func (a *authService) Check(_ context.Context, req *authv3.CheckRequest) (*authv3.CheckResponse, error) {
+	request := req.GetAttributes().GetRequest().GetHttp()
+	if request.GetHeaders()["authorization"] != "Bearer good" || request.GetPath() != "/api/users?id=1" {
+		return &authv3.CheckResponse{
+			Status: status.New(codes.PermissionDenied, "denied").Proto(),
+			HttpResponse: &authv3.CheckResponse_DeniedResponse{DeniedResponse: &authv3.DeniedHttpResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode_Unauthorized},
+				Headers: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "WWW-Authenticate", Value: "Bearer"}}},
+				Body:    "denied",
+			}},
+		}, nil
+	}
+	return &authv3.CheckResponse{
+		Status: status.New(codes.OK, "").Proto(),
+		HttpResponse: &authv3.CheckResponse_OkResponse{OkResponse: &authv3.OkHttpResponse{
+			Headers:         []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "X-User", Value: req.GetAttributes().GetSource().GetAddress().GetSocketAddress().GetAddress()}}},
+			HeadersToRemove: []string{"X-Remove"},
+		}},
+	}, nil
+}
+
+func TestGrpcService(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	srv := grpc.NewServer()
+	authv3.RegisterAuthorizationServer(srv, &authService{})
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	defer srv.Stop()
+
+	cfg := &Config{GrpcService: &GrpcService{Address: lis.Addr().String()}}
+	f := newFilter(t, cfg, newGrpcAuthorizer(cfg.GrpcService, time.Second, server.NewGrpcConnections()))
+
+	check := func(remoteAddr string) (*http.Request, filter.FilterStatus) {
+		request, _ := http.NewRequest("GET", "/api/users?id=1", nil)
+		request.RemoteAddr = remoteAddr
+		request.Header.Set("Authorization", "Bearer good")
+		request.Header.Set("X-Remove", "1")
+		_, s := decodeRequest(f, request)
+		return request, s
+	}
+	request, s := check("10.0.0.1:5000")
+	assert.Equal(t, filter.Continue, s)
+	assert.Equal(t, "10.0.0.1", request.Header.Get("X-User"))
+	assert.Empty(t, request.Header.Get("X-Remove"))
+
+	// the source address is sent to the service
+	request, s = check("10.0.0.2:5000")
+	assert.Equal(t, filter.Continue, s)
+	assert.Equal(t, "10.0.0.2", request.Header.Get("X-User"))
+
+	ctx, s := decode(f, "bad", "")
+	assert.Equal(t, filter.Stop, s)
+	assert.Equal(t, http.StatusUnauthorized, ctx.GetStatusCode())
+	assert.Equal(t, "Bearer", ctx.Writer.Header().Get("WWW-Authenticate"))
+}
+
+func TestApply(t *testing.T) {
+	p := &Plugin{}
+	factory, err := p.CreateFilterFactory()
+	assert.NoError(t, err)
+	assert.Error(t, factory.Apply())
+
+	*factory.Config().(*Config) = Config{HttpService: &HttpService{}}
+	assert.Error(t, factory.Apply())
+
+	*factory.Config().(*Config) = Config{GrpcService: &GrpcService{Address: "127.0.0.1:9000"}, WithRequestBody: &BufferSettings{}}
+	assert.Error(t, factory.Apply())
+
+	// the decision of grpc service is not cacheable
+	*factory.Config().(*Config) = Config{GrpcService: &GrpcService{Address: "127.0.0.1:9000"}, Cache: &CacheConfig{}}
+	assert.Error(t, factory.Apply())
+
+	*factory.Config().(*Config) = Config{HttpService: &HttpService{Address: "127.0.0.1:9000"}, Cache: &CacheConfig{}}
+	assert.NoError(t, factory.Apply())
+	f := factory.(*FilterFactory)
+	assert.Equal(t, defaultTimeout, f.authorizer.(*httpAuthorizer).plain.Timeout)
+	assert.NotNil(t, f.cache)
+	assert.Equal(t, defaultCacheTTL, f.cacheTTL)
+	assert.Equal(t, `{"message":"authorization service unavailable"}`, string(f.failMsg))
+
+	*factory.Config().(*Config) = Config{GrpcService: &GrpcService{Address: "127.0.0.1:9000"}, Timeout: "1s"}
+	assert.NoError(t, factory.Apply())
+	assert.Equal(t, time.Second, f.authorizer.(*grpcAuthorizer).timeout)
+	assert.Nil(t, f.cache)
+}
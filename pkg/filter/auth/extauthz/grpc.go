@@ -0,0 +1,159 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package extauthz
+
+import (
+	"context"
+	"net"
+	stdHttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+import (
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+
+	"google.golang.org/grpc/codes"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+type grpcAuthorizer struct {
+	cfg         *GrpcService
+	timeout     time.Duration
+	connections *server.GrpcConnections
+}
+
+func newGrpcAuthorizer(cfg *GrpcService, timeout time.Duration, connections *server.GrpcConnections) *grpcAuthorizer {
+	return &grpcAuthorizer{cfg: cfg, timeout: timeout, connections: connections}
+}
+
+// check the request is allowed if the status of answer is OK
+func (a *grpcAuthorizer) check(ctx *http.HttpContext, body []byte) (*decision, error) {
+	addr, tlsConfig, err := upstream(ctx, a.cfg.Cluster, a.cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := a.connections.Get(addr, tlsConfig, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	parent := ctx.Ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	callCtx, cancel := context.WithTimeout(parent, a.timeout)
+	defer cancel()
+	resp, err := authv3.NewAuthorizationClient(conn).Check(callCtx, checkRequest(ctx, body))
+	if err != nil {
+		return nil, err
+	}
+
+	if codes.Code(resp.GetStatus().GetCode()) == codes.OK {
+		ok := resp.GetOkResponse()
+		d := &decision{
+			allowed:         true,
+			upstreamHeaders: stdHttp.Header{},
+			appendHeaders:   stdHttp.Header{},
+			removeHeaders:   ok.GetHeadersToRemove(),
+			clientHeaders:   toHeader(ok.GetResponseHeadersToAdd()),
+		}
+		for _, h := range ok.GetHeaders() {
+			if h.GetAppend().GetValue() {
+				d.appendHeaders.Add(h.GetHeader().GetKey(), h.GetHeader().GetValue())
+			} else {
+				d.upstreamHeaders.Add(h.GetHeader().GetKey(), h.GetHeader().GetValue())
+			}
+		}
+		return d, nil
+	}
+
+	denied := resp.GetDeniedResponse()
+	status := int(denied.GetStatus().GetCode())
+	if status == 0 {
+		status = stdHttp.StatusForbidden
+	}
+	return &decision{status: status, body: []byte(denied.GetBody()), clientHeaders: toHeader(denied.GetHeaders())}, nil
+}
+
+// cacheKey the check request carries the source address and all the request headers, so it is never cached
+func (a *grpcAuthorizer) cacheKey(*http.HttpContext, []byte) string {
+	return ""
+}
+
+func checkRequest(ctx *http.HttpContext, body []byte) *authv3.CheckRequest {
+	r := ctx.Request
+	headers := make(map[string]string, len(r.Header))
+	for k, vs := range r.Header {
+		headers[strings.ToLower(k)] = strings.Join(vs, ",")
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	httpRequest := &authv3.AttributeContext_HttpRequest{
+		Method:   r.Method,
+		Headers:  headers,
+		Path:     r.URL.RequestURI(),
+		Host:     r.Host,
+		Scheme:   scheme,
+		Query:    r.URL.RawQuery,
+		Size:     r.ContentLength,
+		Protocol: r.Proto,
+	}
+	// string field of protobuf must be valid utf8
+	if utf8.Valid(body) {
+		httpRequest.Body = string(body)
+	} else {
+		httpRequest.RawBody = body
+	}
+
+	return &authv3.CheckRequest{Attributes: &authv3.AttributeContext{
+		Source:  &authv3.AttributeContext_Peer{Address: socketAddress(r.RemoteAddr)},
+		Request: &authv3.AttributeContext_Request{Time: timestamppb.Now(), Http: httpRequest},
+	}}
+}
+
+func socketAddress(addr string) *corev3.Address {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil
+	}
+	p, _ := strconv.ParseUint(port, 10, 32)
+	return &corev3.Address{Address: &corev3.Address_SocketAddress{SocketAddress: &corev3.SocketAddress{
+		Address:       host,
+		PortSpecifier: &corev3.SocketAddress_PortValue{PortValue: uint32(p)},
+	}}}
+}
+
+func toHeader(options []*corev3.HeaderValueOption) stdHttp.Header {
+	header := stdHttp.Header{}
+	for _, h := range options {
+		header.Add(h.GetHeader().GetKey(), h.GetHeader().GetValue())
+	}
+	return header
+}
@@ -0,0 +1,188 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package extauthz
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	stdHttp "net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+// maxResponseBytes the max size of the body answered by the authorization service
+const maxResponseBytes = 64 * 1024
+
+// the headers describe the response of authorization service itself, never sent to client
+var skippedClientHeaders = map[string]bool{
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+	"Date":              true,
+}
+
+type httpAuthorizer struct {
+	cfg     *HttpService
+	timeout time.Duration
+	plain   *stdHttp.Client
+
+	// tlsClient is created with tlsConfig of the cluster, and replaced when the tls config changes
+	mu        sync.Mutex
+	tlsConfig *tls.Config
+	tlsClient *stdHttp.Client
+}
+
+func newHttpAuthorizer(cfg *HttpService, timeout time.Duration) *httpAuthorizer {
+	return &httpAuthorizer{cfg: cfg, timeout: timeout, plain: newClient(timeout, nil)}
+}
+
+func newClient(timeout time.Duration, tlsConfig *tls.Config) *stdHttp.Client {
+	client := &stdHttp.Client{
+		Timeout: timeout,
+		CheckRedirect: func(*stdHttp.Request, []*stdHttp.Request) error {
+			return stdHttp.ErrUseLastResponse
+		},
+	}
+	if tlsConfig != nil {
+		transport := stdHttp.DefaultTransport.(*stdHttp.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		client.Transport = transport
+	}
+	return client
+}
+
+// client the client for the tls config, plaintext one if tlsConfig is nil
+func (a *httpAuthorizer) client(tlsConfig *tls.Config) *stdHttp.Client {
+	if tlsConfig == nil {
+		return a.plain
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.tlsConfig != tlsConfig {
+		if a.tlsClient != nil {
+			a.tlsClient.CloseIdleConnections()
+		}
+		a.tlsConfig = tlsConfig
+		a.tlsClient = newClient(a.timeout, tlsConfig)
+	}
+	return a.tlsClient
+}
+
+// check the request is allowed if 200 is answered, 5xx is regarded as the failure of authorization service
+func (a *httpAuthorizer) check(ctx *http.HttpContext, body []byte) (*decision, error) {
+	addr, tlsConfig, err := upstream(ctx, a.cfg.Cluster, a.cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+
+	parent := ctx.Ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := stdHttp.NewRequestWithContext(parent, ctx.GetMethod(), scheme+"://"+addr+a.cfg.PathPrefix+ctx.Request.URL.RequestURI(), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Host = ctx.Request.Host
+	for _, h := range a.headers() {
+		for _, v := range ctx.Request.Header.Values(h) {
+			req.Header.Add(h, v)
+		}
+	}
+
+	resp, err := a.client(tlsConfig).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= stdHttp.StatusInternalServerError {
+		return nil, fmt.Errorf("authorization service answered %d", resp.StatusCode)
+	}
+	if resp.StatusCode == stdHttp.StatusOK {
+		return &decision{allowed: true, upstreamHeaders: pick(resp.Header, a.cfg.AllowedUpstreamHeaders)}, nil
+	}
+
+	clientHeaders := pick(resp.Header, a.cfg.AllowedClientHeaders)
+	if len(a.cfg.AllowedClientHeaders) == 0 {
+		clientHeaders = stdHttp.Header{}
+		for k, vs := range resp.Header {
+			if !skippedClientHeaders[k] {
+				clientHeaders[k] = vs
+			}
+		}
+	}
+	return &decision{status: resp.StatusCode, body: respBody, clientHeaders: clientHeaders}, nil
+}
+
+// cacheKey the method, the host, the uri, the headers and the body sent to the authorization service
+func (a *httpAuthorizer) cacheKey(ctx *http.HttpContext, body []byte) string {
+	var sb strings.Builder
+	sb.WriteString(ctx.GetMethod())
+	sb.WriteByte(' ')
+	sb.WriteString(ctx.Request.Host)
+	sb.WriteString(ctx.Request.URL.RequestURI())
+	for _, h := range a.headers() {
+		sb.WriteByte('\n')
+		sb.WriteString(stdHttp.CanonicalHeaderKey(h))
+		sb.WriteByte(':')
+		sb.WriteString(strings.Join(ctx.Request.Header.Values(h), ","))
+	}
+	if body != nil {
+		sb.WriteByte('\n')
+		sb.WriteString(digest(body))
+	}
+	return sb.String()
+}
+
+// headers the request headers sent to the authorization service
+func (a *httpAuthorizer) headers() []string {
+	return append([]string{constant.HeaderKeyAuthorization}, a.cfg.AllowedHeaders...)
+}
+
+// pick the headers in names
+func pick(header stdHttp.Header, names []string) stdHttp.Header {
+	picked := stdHttp.Header{}
+	for _, name := range names {
+		if vs := header.Values(name); len(vs) > 0 {
+			picked[stdHttp.CanonicalHeaderKey(name)] = vs
+		}
+	}
+	return picked
+}
@@ -17,6 +17,10 @@
 
 package jwt
 
+import (
+	"time"
+)
+
 import (
 	"github.com/MicahParks/keyfunc"
 )
@@ -49,11 +53,30 @@ type (
 
 	Providers struct {
 		Name                 string      `yaml:"name" json:"name" mapstructure:"name"`                                                       // jwt name
-		ForwardPayloadHeader string      `yaml:"forward_payload_header" json:"forward_payload_header" mapstructure:"forward_payload_header"` // header add issuer
+		ForwardPayloadHeader string      `yaml:"forward_payload_header" json:"forward_payload_header" mapstructure:"forward_payload_header"` // header carries the base64url encoded payload of verified token
 		FromHeaders          FromHeaders `yaml:"from_headers" json:"from_headers" mapstructure:"from_headers"`                               // from header get token
 		Issuer               string      `yaml:"issuer" json:"issuer" mapstructure:"issuer"`                                                 // jwt issuer
 		Local                *Local      `yaml:"local_jwks" json:"local_jwks" mapstructure:"local_jwks"`                                     // local jwks
 		Remote               *Remote     `yaml:"remote_jwks" json:"remote_jwks" mapstructure:"remote_jwks"`                                  // remote jwks
+
+		Audiences      []string        `yaml:"audiences" json:"audiences,omitempty" mapstructure:"audiences"`                      // accepted aud, any of them matches
+		ClockSkew      string          `default:"60s" yaml:"clock_skew" json:"clock_skew,omitempty" mapstructure:"clock_skew"`     // tolerance of exp, nbf and iat
+		RequiredClaims []ClaimMatcher  `yaml:"required_claims" json:"required_claims,omitempty" mapstructure:"required_claims"`    // claims the token must carry
+		ClaimToHeaders []ClaimToHeader `yaml:"claim_to_headers" json:"claim_to_headers,omitempty" mapstructure:"claim_to_headers"` // claims forward to upstream
+		FromCookies    []string        `yaml:"from_cookies" json:"from_cookies,omitempty" mapstructure:"from_cookies"`             // from cookie get token
+		FromParams     []string        `yaml:"from_params" json:"from_params,omitempty" mapstructure:"from_params"`                // from query param get token
+	}
+
+	// ClaimMatcher the claim must be present, and equal to one of the values if any
+	ClaimMatcher struct {
+		Name   string   `yaml:"name" json:"name" mapstructure:"name"`                 // claim name, nested claim separated by '.'
+		Values []string `yaml:"values" json:"values,omitempty" mapstructure:"values"` // accepted values
+	}
+
+	// ClaimToHeader copy the claim of verified token to the header of upstream request
+	ClaimToHeader struct {
+		HeaderName string `yaml:"header_name" json:"header_name" mapstructure:"header_name"`
+		ClaimName  string `yaml:"claim_name" json:"claim_name" mapstructure:"claim_name"` // nested claim separated by '.'
 	}
 
 	Local struct {
@@ -76,4 +99,10 @@ type Provider struct {
 	issuer               string
 	forwardPayloadHeader string
 	headers              FromHeaders
+	audiences            []string
+	clockSkew            time.Duration
+	requiredClaims       []ClaimMatcher
+	claimToHeaders       []ClaimToHeader
+	cookies              []string
+	params               []string
 }
@@ -33,12 +33,15 @@ import (
 import (
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/util/stringutil"
 	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
 	"github.com/apache/dubbo-go-pixiu/pkg/logger"
 )
 
 const (
 	Kind = constant.HTTPAuthJwtFilter
+
+	defaultClockSkew = 60 * time.Second
 )
 
 func init() {
@@ -114,10 +117,7 @@ func (f *Filter) validAny(rule Rules, ctx *http.HttpContext) bool {
 	providerName := rule.Requires.RequiresAny.ProviderName
 
 	if provider, ok := f.providerJwks[providerName]; ok {
-		ctx.Request.Header.Set(provider.forwardPayloadHeader, provider.issuer)
-		if key := ctx.Request.Header.Get(provider.headers.Name); key != "" {
-			return checkToken(key, provider.headers.ValuePrefix, providerName, provider)
-		}
+		return verify(ctx, providerName, provider)
 	}
 
 	return false
@@ -128,11 +128,8 @@ func (f *Filter) validAll(rule Rules, ctx *http.HttpContext) bool {
 
 	for _, requirement := range rule.Requires.RequiresAll {
 		if provider, ok := f.providerJwks[requirement.ProviderName]; ok {
-			ctx.Request.Header.Set(provider.forwardPayloadHeader, provider.issuer)
-			if key := ctx.Request.Header.Get(provider.headers.Name); key != "" {
-				if checkToken(key, provider.headers.ValuePrefix, requirement.ProviderName, provider) {
-					return true
-				}
+			if verify(ctx, requirement.ProviderName, provider) {
+				return true
 			}
 		}
 	}
@@ -140,6 +137,69 @@ func (f *Filter) validAll(rule Rules, ctx *http.HttpContext) bool {
 	return false
 }
 
+// verify extract the token of provider from request and check it, the claims of verified token
+// are forwarded to upstream and stored in the context
+func verify(ctx *http.HttpContext, providerName string, provider Provider) bool {
+	// the forwarded headers only come from verified token, never trust the ones of client
+	if provider.forwardPayloadHeader != "" {
+		ctx.Request.Header.Del(provider.forwardPayloadHeader)
+	}
+	for _, c := range provider.claimToHeaders {
+		ctx.Request.Header.Del(c.HeaderName)
+	}
+
+	token, ok := extractToken(ctx, providerName, provider)
+	if !ok {
+		return false
+	}
+
+	claims, ok := checkToken(token, providerName, provider)
+	if !ok {
+		return false
+	}
+
+	if provider.forwardPayloadHeader != "" {
+		if parts := strings.Split(token, "."); len(parts) == 3 {
+			ctx.Request.Header.Set(provider.forwardPayloadHeader, parts[1])
+		}
+	}
+	for _, c := range provider.claimToHeaders {
+		if v, ok := http.LookupClaim(claims, c.ClaimName); ok {
+			ctx.Request.Header.Set(c.HeaderName, http.ClaimString(v))
+		}
+	}
+	ctx.SetJwtClaims(claims)
+	return true
+}
+
+// extractToken get the token from header first, then query params and cookies
+func extractToken(ctx *http.HttpContext, providerName string, provider Provider) (string, bool) {
+	if value := ctx.Request.Header.Get(provider.headers.Name); value != "" {
+		if !strings.HasPrefix(value, provider.headers.ValuePrefix) {
+			logger.Warn("header value prefix mismatch provider：", providerName)
+			return "", false
+		}
+		return value[len(provider.headers.ValuePrefix):], true
+	}
+
+	if len(provider.params) > 0 {
+		query := ctx.Request.URL.Query()
+		for _, name := range provider.params {
+			if value := query.Get(name); value != "" {
+				return value, true
+			}
+		}
+	}
+
+	for _, name := range provider.cookies {
+		if cookie, err := ctx.Request.Cookie(name); err == nil && cookie.Value != "" {
+			return cookie.Value, true
+		}
+	}
+
+	return "", false
+}
+
 func (factory *FilterFactory) Apply() error {
 
 	if len(factory.cfg.Providers) == 0 {
@@ -154,9 +214,7 @@ func (factory *FilterFactory) Apply() error {
 			if err != nil {
 				logger.Warnf("failed to create JWKs from JSON. provider：%s Error: %s", provider.Name, err.Error())
 			} else {
-				provider.FromHeaders.setDefault()
-				factory.providerJwks[provider.Name] = Provider{jwk: jwks, headers: provider.FromHeaders,
-					issuer: provider.Issuer, forwardPayloadHeader: provider.ForwardPayloadHeader}
+				factory.providerJwks[provider.Name] = newProvider(provider, jwks)
 				continue
 			}
 		}
@@ -174,9 +232,7 @@ func (factory *FilterFactory) Apply() error {
 			if err != nil {
 				logger.Warnf("failed to create JWKs from resource at the given URL. provider：%s Error: %s", provider.Name, err.Error())
 			} else {
-				provider.FromHeaders.setDefault()
-				factory.providerJwks[provider.Name] = Provider{jwk: jwks, headers: provider.FromHeaders,
-					issuer: provider.Issuer, forwardPayloadHeader: provider.ForwardPayloadHeader}
+				factory.providerJwks[provider.Name] = newProvider(provider, jwks)
 			}
 		}
 	}
@@ -205,19 +261,100 @@ func (h *FromHeaders) setDefault() {
 	}
 }
 
-func checkToken(value, prefix, providerName string, provider Provider) bool {
-	if !strings.HasPrefix(value, prefix) {
-		logger.Warn("header value prefix mismatch provider：", providerName)
-		return false
+func newProvider(provider Providers, jwks *keyfunc.JWKS) Provider {
+	provider.FromHeaders.setDefault()
+	return Provider{
+		jwk:                  jwks,
+		headers:              provider.FromHeaders,
+		issuer:               provider.Issuer,
+		forwardPayloadHeader: provider.ForwardPayloadHeader,
+		audiences:            provider.Audiences,
+		clockSkew:            stringutil.ResolveTimeStr2Time(provider.ClockSkew, defaultClockSkew),
+		requiredClaims:       provider.RequiredClaims,
+		claimToHeaders:       provider.ClaimToHeaders,
+		cookies:              provider.FromCookies,
+		params:               provider.FromParams,
 	}
+}
 
-	token, err := jwt4.Parse(value[len(prefix):], provider.jwk.Keyfunc)
+// checkToken verify the signature and the claims of token, return the claims if the token is valid
+func checkToken(value, providerName string, provider Provider) (jwt4.MapClaims, bool) {
+	claims := jwt4.MapClaims{}
+	// the time based claims are verified below with the clock skew of provider
+	_, err := jwt4.ParseWithClaims(value, claims, provider.jwk.Keyfunc, jwt4.WithoutClaimsValidation())
 	if err != nil {
 		logger.Warnf("failed to parse JWKs from JSON. provider：%s Error: %s", providerName, err.Error())
+		return nil, false
+	}
+
+	if err = verifyClaims(claims, provider, time.Now()); err != nil {
+		logger.Warnf("jwt claims invalid. provider：%s Error: %s", providerName, err.Error())
+		return nil, false
+	}
+
+	return claims, true
+}
+
+func verifyClaims(claims jwt4.MapClaims, provider Provider, now time.Time) error {
+	skew := int64(provider.clockSkew / time.Second)
+	if !claims.VerifyExpiresAt(now.Unix()-skew, false) {
+		return fmt.Errorf("token is expired")
+	}
+	if !claims.VerifyNotBefore(now.Unix()+skew, false) {
+		return fmt.Errorf("token is not valid yet")
+	}
+	if !claims.VerifyIssuedAt(now.Unix()+skew, false) {
+		return fmt.Errorf("token used before issued")
+	}
+
+	if provider.issuer != "" && !claims.VerifyIssuer(provider.issuer, true) {
+		return fmt.Errorf("issuer mismatch, want %s", provider.issuer)
+	}
+
+	if len(provider.audiences) > 0 {
+		matched := false
+		for _, aud := range provider.audiences {
+			if claims.VerifyAudience(aud, true) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("audience mismatch, want one of %v", provider.audiences)
+		}
+	}
+
+	for _, required := range provider.requiredClaims {
+		v, ok := http.LookupClaim(claims, required.Name)
+		if !ok {
+			return fmt.Errorf("claim %s is required", required.Name)
+		}
+		if len(required.Values) > 0 && !matchClaim(v, required.Values) {
+			return fmt.Errorf("claim %s mismatch, want one of %v", required.Name, required.Values)
+		}
+	}
+
+	return nil
+}
+
+// matchClaim whether the claim equals to one of values, any element matches if the claim is an array
+func matchClaim(claim interface{}, values []string) bool {
+	if list, ok := claim.([]interface{}); ok {
+		for _, item := range list {
+			if matchClaim(item, values) {
+				return true
+			}
+		}
 		return false
 	}
 
-	return token.Valid
+	s := http.ClaimString(claim)
+	for _, value := range values {
+		if s == value {
+			return true
+		}
+	}
+	return false
 }
 
 func (factory *FilterFactory) Config() interface{} {
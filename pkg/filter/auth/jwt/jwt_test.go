@@ -0,0 +1,230 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/MicahParks/keyfunc"
+	jwt4 "github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func newJwks(t *testing.T) (*rsa.PrivateKey, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	jwks, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	assert.NoError(t, err)
+	return key, string(jwks)
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, claims jwt4.MapClaims) string {
+	token := jwt4.NewWithClaims(jwt4.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+	return signed
+}
+
+// newFilter the filter which requires the provider named test for the requests of /api
+func newFilter(t *testing.T, provider Providers, jwksJSON string) *Filter {
+	jwks, err := keyfunc.NewJSON(json.RawMessage(jwksJSON))
+	assert.NoError(t, err)
+	return &Filter{
+		cfg: &Config{
+			Rules: []Rules{{Match: Match{Prefix: "/api"}, Requires: Requires{RequiresAny: Requirement{ProviderName: "test"}}}},
+		},
+		errMsg:       []byte(`{"message":"token invalid"}`),
+		providerJwks: map[string]Provider{"test": newProvider(provider, jwks)},
+	}
+}
+
+func decodeRequest(f *Filter, request *http.Request) (filter.FilterStatus, *contexthttp.HttpContext) {
+	request.RequestURI = request.URL.RequestURI()
+	ctx := mock.GetMockHTTPContext(request)
+	return f.Decode(ctx), ctx
+}
+
+func TestClaimValidation(t *testing.T) {
+	key, jwks := newJwks(t)
+	now := time.Now()
+
+	f := newFilter(t, Providers{
+		Issuer:         "https://issuer.example.org",
+		Audiences:      []string{"pixiu", "gateway"},
+		RequiredClaims: []ClaimMatcher{{Name: "scope", Values: []string{"read"}}, {Name: "tenant.id"}},
+	}, jwks)
+
+	decode := func(claims jwt4.MapClaims) filter.FilterStatus {
+		request, _ := http.NewRequest("GET", "/api/users", nil)
+		request.Header.Set("Authorization", "Bearer "+sign(t, key, claims))
+		status, _ := decodeRequest(f, request)
+		return status
+	}
+	valid := func() jwt4.MapClaims {
+		return jwt4.MapClaims{
+			"iss":    "https://issuer.example.org",
+			"aud":    []string{"web", "pixiu"},
+			"exp":    now.Add(time.Hour).Unix(),
+			"scope":  []string{"read", "write"},
+			"tenant": map[string]interface{}{"id": "t1"},
+		}
+	}
+
+	assert.Equal(t, filter.Continue, decode(valid()))
+
+	claims := valid()
+	claims["iss"] = "https://other.example.org"
+	assert.Equal(t, filter.Stop, decode(claims))
+
+	claims = valid()
+	claims["aud"] = "web"
+	assert.Equal(t, filter.Stop, decode(claims))
+
+	claims = valid()
+	claims["exp"] = now.Add(-time.Hour).Unix()
+	assert.Equal(t, filter.Stop, decode(claims))
+
+	// expired within the clock skew
+	claims = valid()
+	claims["exp"] = now.Add(-30 * time.Second).Unix()
+	assert.Equal(t, filter.Continue, decode(claims))
+
+	claims = valid()
+	claims["nbf"] = now.Add(time.Hour).Unix()
+	assert.Equal(t, filter.Stop, decode(claims))
+
+	claims = valid()
+	claims["nbf"] = now.Add(30 * time.Second).Unix()
+	assert.Equal(t, filter.Continue, decode(claims))
+
+	claims = valid()
+	claims["scope"] = "write"
+	assert.Equal(t, filter.Stop, decode(claims))
+
+	claims = valid()
+	delete(claims, "tenant")
+	assert.Equal(t, filter.Stop, decode(claims))
+
+	// signed by unknown key
+	other, _ := newJwks(t)
+	request, _ := http.NewRequest("GET", "/api/users", nil)
+	request.Header.Set("Authorization", "Bearer "+sign(t, other, valid()))
+	status, ctx := decodeRequest(f, request)
+	assert.Equal(t, filter.Stop, status)
+	assert.True(t, ctx.LocalReply())
+	assert.Equal(t, http.StatusUnauthorized, ctx.GetStatusCode())
+}
+
+func TestForwardAndTokenSources(t *testing.T) {
+	key, jwks := newJwks(t)
+	f := newFilter(t, Providers{
+		ForwardPayloadHeader: "X-Jwt-Payload",
+		ClaimToHeaders: []ClaimToHeader{
+			{HeaderName: "X-User", ClaimName: "sub"},
+			{HeaderName: "X-Tenant", ClaimName: "tenant.id"},
+			{HeaderName: "X-Admin", ClaimName: "admin"},
+		},
+		FromParams:  []string{"access_token"},
+		FromCookies: []string{"session"},
+	}, jwks)
+	token := sign(t, key, jwt4.MapClaims{"sub": "alice", "tenant": map[string]interface{}{"id": "t1"}, "admin": true})
+
+	decode := func(request *http.Request) (filter.FilterStatus, map[string]interface{}) {
+		request.Header.Set("X-User", "spoofed")
+		status, ctx := decodeRequest(f, request)
+		return status, ctx.GetJwtClaims()
+	}
+
+	request, _ := http.NewRequest("GET", "/api/users?access_token="+token, nil)
+	status, claims := decode(request)
+	assert.Equal(t, filter.Continue, status)
+	assert.Equal(t, "alice", claims["sub"])
+	assert.Equal(t, "alice", request.Header.Get("X-User"))
+	assert.Equal(t, "t1", request.Header.Get("X-Tenant"))
+	assert.Equal(t, "true", request.Header.Get("X-Admin"))
+
+	payload, err := base64.RawURLEncoding.DecodeString(request.Header.Get("X-Jwt-Payload"))
+	assert.NoError(t, err)
+	forwarded := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(payload, &forwarded))
+	assert.Equal(t, "alice", forwarded["sub"])
+
+	request, _ = http.NewRequest("GET", "/api/users", nil)
+	request.AddCookie(&http.Cookie{Name: "session", Value: token})
+	status, _ = decode(request)
+	assert.Equal(t, filter.Continue, status)
+
+	// no token, the spoofed header is not forwarded
+	request, _ = http.NewRequest("GET", "/api/users", nil)
+	status, claims = decode(request)
+	assert.Equal(t, filter.Stop, status)
+	assert.Nil(t, claims)
+	assert.Empty(t, request.Header.Get("X-User"))
+
+	// header takes precedence and must carry the prefix
+	request, _ = http.NewRequest("GET", "/api/users?access_token="+token, nil)
+	request.Header.Set("Authorization", token)
+	status, _ = decode(request)
+	assert.Equal(t, filter.Stop, status)
+}
+
+func TestApply(t *testing.T) {
+	_, jwks := newJwks(t)
+	p := &Plugin{}
+	factory, err := p.CreateFilterFactory()
+	assert.NoError(t, err)
+	assert.Error(t, factory.Apply())
+
+	// the provider whose keys can not be loaded is skipped
+	*factory.Config().(*Config) = Config{Providers: []Providers{{Name: "test", Local: &Local{InlineString: "invalid"}}}}
+	assert.Error(t, factory.Apply())
+
+	*factory.Config().(*Config) = Config{Providers: []Providers{{Name: "test", Local: &Local{InlineString: jwks}}}}
+	assert.NoError(t, factory.Apply())
+	f := factory.(*FilterFactory)
+	assert.Equal(t, `{"message":"token invalid"}`, string(f.errMsg))
+	provider, ok := f.providerJwks["test"]
+	assert.True(t, ok)
+	assert.Equal(t, "Authorization", provider.headers.Name)
+	assert.Equal(t, "Bearer ", provider.headers.ValuePrefix)
+	assert.Equal(t, defaultClockSkew, provider.clockSkew)
+}
@@ -0,0 +1,201 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	stdHttp "net/http"
+	"strings"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	pixiutls "github.com/apache/dubbo-go-pixiu/pkg/common/tls"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+const (
+	Kind = constant.HTTPAuthMTLSFilter
+)
+
+const (
+	// IdentitySubject the distinguished name of certificate subject, like CN=client,O=org
+	IdentitySubject = "subject"
+	// IdentityCommonName the common name of certificate subject
+	IdentityCommonName = "common_name"
+	// IdentitySAN the first dns name, email address or uri in subject alternative names
+	IdentitySAN = "san"
+	// IdentitySpiffe the spiffe id in uri subject alternative names, like spiffe://example.org/ns/default/sa/client
+	IdentitySpiffe = "spiffe"
+)
+
+const defaultForwardHeader = "X-Client-Identity"
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg    *Config
+		roots  *x509.CertPool
+		errMsg []byte
+	}
+
+	Filter struct {
+		cfg    *Config
+		roots  *x509.CertPool
+		errMsg []byte
+	}
+
+	// Config describe the config of FilterFactory, the listener must request client certificate
+	// by setting tls client_auth
+	Config struct {
+		// CACertFile the CA bundle to verify client certificates
+		CACertFile string `yaml:"ca_cert_file" json:"ca_cert_file" mapstructure:"ca_cert_file"`
+		// Identity subject, common_name, san or spiffe, default is subject
+		Identity string `yaml:"identity" json:"identity" mapstructure:"identity"`
+		// ForwardHeader the header to forward the verified identity upstream, default is X-Client-Identity
+		ForwardHeader string `yaml:"forward_header" json:"forward_header" mapstructure:"forward_header"`
+		ErrMsg        string `yaml:"err_msg" json:"err_msg" mapstructure:"err_msg"`
+	}
+)
+
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	if factory.cfg.CACertFile == "" {
+		return fmt.Errorf("ca_cert_file is required")
+	}
+	roots, err := pixiutls.LoadCertPool(factory.cfg.CACertFile)
+	if err != nil {
+		return err
+	}
+	factory.roots = roots
+
+	switch factory.cfg.Identity {
+	case "":
+		factory.cfg.Identity = IdentitySubject
+	case IdentitySubject, IdentityCommonName, IdentitySAN, IdentitySpiffe:
+	default:
+		return fmt.Errorf("unknown identity %s", factory.cfg.Identity)
+	}
+	if factory.cfg.ForwardHeader == "" {
+		factory.cfg.ForwardHeader = defaultForwardHeader
+	}
+	if factory.cfg.ErrMsg == "" {
+		factory.cfg.ErrMsg = "client certificate invalid"
+	}
+	errMsg, _ := json.Marshal(http.ErrResponse{Message: factory.cfg.ErrMsg})
+	factory.errMsg = errMsg
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{cfg: factory.cfg, roots: factory.roots, errMsg: factory.errMsg}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	// never trust the identity header sent by client
+	ctx.Request.Header.Del(f.cfg.ForwardHeader)
+
+	cert, err := f.verify(ctx.Request)
+	if err != nil {
+		logger.Debugf("[dubbo-go-pixiu] client certificate of %s is rejected: %v", ctx.GetClientIP(), err)
+		ctx.SendLocalReply(stdHttp.StatusUnauthorized, f.errMsg)
+		return filter.Stop
+	}
+
+	identity := identityOf(cert, f.cfg.Identity)
+	if identity == "" {
+		logger.Debugf("[dubbo-go-pixiu] no %s identity in client certificate %s", f.cfg.Identity, cert.Subject)
+		ctx.SendLocalReply(stdHttp.StatusForbidden, constant.Default403Body)
+		return filter.Stop
+	}
+
+	ctx.SetClientIdentity(identity)
+	ctx.Request.Header.Set(f.cfg.ForwardHeader, identity)
+	return filter.Continue
+}
+
+// verify the client certificate chain of request, return the leaf certificate
+func (f *Filter) verify(r *stdHttp.Request) (*x509.Certificate, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil, fmt.Errorf("no client certificate")
+	}
+	certs := r.TLS.PeerCertificates
+	opts := x509.VerifyOptions{
+		Roots:         f.roots,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, c := range certs[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	if _, err := certs[0].Verify(opts); err != nil {
+		return nil, err
+	}
+	return certs[0], nil
+}
+
+func identityOf(cert *x509.Certificate, identity string) string {
+	switch identity {
+	case IdentityCommonName:
+		return cert.Subject.CommonName
+	case IdentitySAN:
+		if len(cert.DNSNames) > 0 {
+			return cert.DNSNames[0]
+		}
+		if len(cert.EmailAddresses) > 0 {
+			return cert.EmailAddresses[0]
+		}
+		if len(cert.URIs) > 0 {
+			return cert.URIs[0].String()
+		}
+	case IdentitySpiffe:
+		for _, uri := range cert.URIs {
+			if strings.EqualFold(uri.Scheme, "spiffe") {
+				return uri.String()
+			}
+		}
+	default:
+		return cert.Subject.String()
+	}
+	return ""
+}
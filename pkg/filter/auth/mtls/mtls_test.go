@@ -0,0 +1,159 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+)
+
+func newCertificate(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+func newCA(t *testing.T, name string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	return newCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+}
+
+func newClient(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, usage x509.ExtKeyUsage) *x509.Certificate {
+	spiffe, _ := url.Parse("spiffe://example.org/ns/default/sa/partner")
+	cert, _ := newCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "partner", Organization: []string{"example"}},
+		DNSNames:     []string{"partner.example.org"},
+		URIs:         []*url.URL{spiffe},
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}, ca, caKey)
+	return cert
+}
+
+func TestFilter(t *testing.T) {
+	ca, caKey := newCA(t, "partner-ca")
+	other, otherKey := newCA(t, "other-ca")
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	// decode return the request and whether it is rejected, and the verified identity
+	decode := func(f *Filter, certs ...*x509.Certificate) (*http.Request, bool, string) {
+		request, _ := http.NewRequest("GET", "/api", nil)
+		request.Header.Set("X-Client-Identity", "spoofed")
+		if len(certs) > 0 {
+			request.TLS = &tls.ConnectionState{PeerCertificates: certs}
+		}
+		ctx := mock.GetMockHTTPContext(request)
+		status := f.Decode(ctx)
+		return request, status == filter.Stop, ctx.GetClientIdentity()
+	}
+
+	f := &Filter{cfg: &Config{Identity: IdentitySubject, ForwardHeader: "X-Client-Identity"}, roots: roots}
+	client := newClient(t, ca, caKey, x509.ExtKeyUsageClientAuth)
+
+	request, rejected, identity := decode(f, client)
+	assert.False(t, rejected)
+	assert.Equal(t, "CN=partner,O=example", identity)
+	assert.Equal(t, identity, request.Header.Get("X-Client-Identity"))
+
+	// no certificate
+	request, rejected, _ = decode(f)
+	assert.True(t, rejected)
+	assert.Empty(t, request.Header.Get("X-Client-Identity"))
+
+	// signed by untrusted CA
+	_, rejected, _ = decode(f, newClient(t, other, otherKey, x509.ExtKeyUsageClientAuth))
+	assert.True(t, rejected)
+
+	// certificate not for client auth
+	_, rejected, _ = decode(f, newClient(t, ca, caKey, x509.ExtKeyUsageServerAuth))
+	assert.True(t, rejected)
+
+	f = &Filter{cfg: &Config{Identity: IdentitySpiffe, ForwardHeader: "X-Spiffe-Id"}, roots: roots}
+	request, rejected, identity = decode(f, client)
+	assert.False(t, rejected)
+	assert.Equal(t, "spiffe://example.org/ns/default/sa/partner", identity)
+	assert.Equal(t, identity, request.Header.Get("X-Spiffe-Id"))
+	assert.Equal(t, "spoofed", request.Header.Get("X-Client-Identity"))
+
+	f = &Filter{cfg: &Config{Identity: IdentitySAN, ForwardHeader: "X-Client-Identity"}, roots: roots}
+	_, _, identity = decode(f, client)
+	assert.Equal(t, "partner.example.org", identity)
+
+	f = &Filter{cfg: &Config{Identity: IdentityCommonName, ForwardHeader: "X-Client-Identity"}, roots: roots}
+	_, _, identity = decode(f, client)
+	assert.Equal(t, "partner", identity)
+}
+
+func TestApply(t *testing.T) {
+	p := &Plugin{}
+	factory, _ := p.CreateFilterFactory()
+	assert.Error(t, factory.Apply())
+
+	ca, _ := newCA(t, "partner-ca")
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0600))
+	*factory.Config().(*Config) = Config{CACertFile: caFile}
+	assert.NoError(t, factory.Apply())
+	f := factory.(*FilterFactory)
+	assert.Equal(t, IdentitySubject, f.cfg.Identity)
+	assert.Equal(t, "X-Client-Identity", f.cfg.ForwardHeader)
+	assert.Equal(t, `{"message":"client certificate invalid"}`, string(f.errMsg))
+	assert.NotNil(t, f.roots)
+
+	*factory.Config().(*Config) = Config{CACertFile: caFile, Identity: "email"}
+	assert.Error(t, factory.Apply())
+
+	*factory.Config().(*Config) = Config{CACertFile: filepath.Join(t.TempDir(), "missing.pem")}
+	assert.Error(t, factory.Apply())
+}
@@ -72,9 +72,18 @@ func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain fi
 
 func (f *Filter) Decode(c *http.HttpContext) filter.FilterStatus {
 	for _, r := range f.cfg.Rules {
-		item := c.GetClientIP()
-		if r.Limit == App {
+		var item string
+		switch r.Limit {
+		case App:
 			item = c.GetApplicationName()
+		case Identity:
+			// the identity is set by the mtls filter
+			item = c.GetClientIdentity()
+		case Consumer:
+			// the consumer is set by the api key filter
+			item = c.GetConsumer()
+		default:
+			item = c.GetClientIP()
 		}
 
 		result := passCheck(item, r)
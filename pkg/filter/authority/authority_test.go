@@ -55,3 +55,36 @@ func TestAuth(t *testing.T) {
 	filterStatus := f.Decode(ctx)
 	assert.Equal(t, filterStatus, filter.Continue)
 }
+
+func TestAuthIdentity(t *testing.T) {
+	rules := AuthorityConfiguration{
+		[]AuthorityRule{{Strategy: Whitelist, Limit: Identity, Items: []string{"spiffe://example.org/ns/default/sa/partner"}}},
+	}
+	f := &Filter{cfg: &rules}
+
+	request, _ := http.NewRequest("GET", "/", nil)
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.SetClientIdentity("spiffe://example.org/ns/default/sa/partner")
+	assert.Equal(t, filter.Continue, f.Decode(ctx))
+
+	request, _ = http.NewRequest("GET", "/", nil)
+	ctx = mock.GetMockHTTPContext(request)
+	assert.Equal(t, filter.Stop, f.Decode(ctx))
+}
+
+func TestAuthConsumer(t *testing.T) {
+	rules := AuthorityConfiguration{
+		[]AuthorityRule{{Strategy: Blacklist, Limit: Consumer, Items: []string{"suspended-partner"}}},
+	}
+	f := &Filter{cfg: &rules}
+
+	request, _ := http.NewRequest("GET", "/", nil)
+	ctx := mock.GetMockHTTPContext(request)
+	ctx.SetConsumer("partner", "gold")
+	assert.Equal(t, filter.Continue, f.Decode(ctx))
+
+	request, _ = http.NewRequest("GET", "/", nil)
+	ctx = mock.GetMockHTTPContext(request)
+	ctx.SetConsumer("suspended-partner", "gold")
+	assert.Equal(t, filter.Stop, f.Decode(ctx))
+}
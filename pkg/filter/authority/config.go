@@ -25,8 +25,10 @@ const (
 
 // LimitType limit type const
 const (
-	IP  LimitType = 0
-	App LimitType = 1
+	IP       LimitType = 0
+	App      LimitType = 1
+	Identity LimitType = 2
+	Consumer LimitType = 3
 )
 
 var (
@@ -46,12 +48,16 @@ var (
 	LimitTypeName = map[int32]string{
 		0: "IP",
 		1: "App",
+		2: "Identity",
+		3: "Consumer",
 	}
 
 	// LimitTypeValue key string, value int32 for LimitType
 	LimitTypeValue = map[string]int32{
-		"IP":  0,
-		"App": 1,
+		"IP":       0,
+		"App":      1,
+		"Identity": 2,
+		"Consumer": 3,
 	}
 )
 
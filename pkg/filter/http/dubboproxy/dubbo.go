@@ -36,6 +36,7 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
 	pixiuHttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
@@ -116,6 +117,13 @@ func (f *Filter) Decode(hc *pixiuHttp.HttpContext) filter.FilterStatus {
 		hc.SendLocalReply(http.StatusServiceUnavailable, bt)
 		return filter.Stop
 	}
+	// the getty client of dubbo protocol only uses the global tls config of dubbo-go
+	if clusterManager.UpstreamTlsFiles(clusterName) != nil {
+		logger.Warnf("[dubbo-go-pixiu] cluster %s tls is not supported by dubbo protocol", clusterName)
+		bt, _ := json.Marshal(pixiuHttp.ErrResponse{Message: "cluster tls is not supported by dubbo protocol"})
+		hc.SendLocalReply(http.StatusServiceUnavailable, bt)
+		return filter.Stop
+	}
 
 	// http://host/{application}/{service}/{method} or https://host/{application}/{service}/{method}
 	rawPath := hc.Request.URL.Path
@@ -208,6 +216,7 @@ func (f *Filter) Decode(hc *pixiuHttp.HttpContext) filter.FilterStatus {
 	// TODO: will print many Error when failed to connect server
 	invoker := dubboProtocol.Refer(url)
 	if invoker == nil {
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultConnectFailure)
 		logger.Info("[dubbo-go-pixiu] dubbo protocol refer error")
 		bt, _ := json.Marshal(pixiuHttp.ErrResponse{Message: "dubbo protocol refer error"})
 		hc.SendLocalReply(http.StatusServiceUnavailable, bt)
@@ -219,10 +228,21 @@ func (f *Filter) Decode(hc *pixiuHttp.HttpContext) filter.FilterStatus {
 
 	invCtx, cancel := context.WithTimeout(context.Background(), hc.Timeout)
 	defer cancel()
+	release, err := clusterManager.AcquireResource(invCtx, clusterName, cluster.ResourceRequest)
+	if err != nil {
+		logger.Debugf("[dubbo-go-pixiu] %v", err)
+		bt, _ := json.Marshal(pixiuHttp.ErrResponse{Message: err.Error()})
+		hc.SendLocalReply(http.StatusServiceUnavailable, bt)
+		return filter.Stop
+	}
+	endpoint.IncActiveRequests()
 	result := invoker.Invoke(invCtx, invoc)
+	endpoint.DecActiveRequests()
+	release()
 	result.SetAttachments(invoc.Attachments())
 
 	if result.Error() != nil {
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultServerError)
 		logger.Debugf("[dubbo-go-pixiu] invoke result error %v", result.Error())
 		bt, _ := json.Marshal(pixiuHttp.ErrResponse{Message: fmt.Sprintf("invoke result error %v", result.Error())})
 		// TODO statusCode I don't know what dubbo returns when it times out, first use the string to judge
@@ -233,6 +253,8 @@ func (f *Filter) Decode(hc *pixiuHttp.HttpContext) filter.FilterStatus {
 		return filter.Stop
 	}
 
+	clusterManager.ReportResult(clusterName, endpoint, cluster.ResultSuccess)
+
 	value := reflect.ValueOf(result.Result())
 	result.SetResult(value.Elem().Interface())
 	hc.SourceResp = resp
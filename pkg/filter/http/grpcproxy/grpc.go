@@ -37,12 +37,14 @@ import (
 	perrors "github.com/pkg/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
 	ct "github.com/apache/dubbo-go-pixiu/pkg/context"
@@ -183,12 +185,29 @@ func (f *Filter) Decode(c *http.HttpContext) filter.FilterStatus {
 	re := c.GetRouteEntry()
 	logger.Debugf("%s client choose endpoint from cluster :%v", loggerHeader, re.Cluster)
 
-	e := server.GetClusterManager().PickEndpoint(re.Cluster, c)
+	clusterManager := server.GetClusterManager()
+	e := clusterManager.PickEndpoint(re.Cluster, c)
 	if e == nil {
 		logger.Errorf("%s err {cluster not exists}", loggerHeader)
 		c.SendLocalReply(stdHttp.StatusServiceUnavailable, []byte("cluster not exists"))
 		return filter.Stop
 	}
+	tlsConfig, err := clusterManager.UpstreamTlsConfig(re.Cluster)
+	if err != nil {
+		logger.Errorf("%s err {%v}", loggerHeader, err)
+		c.SendLocalReply(stdHttp.StatusServiceUnavailable, []byte(err.Error()))
+		return filter.Stop
+	}
+	release, err := clusterManager.AcquireResource(c.Ctx, re.Cluster, cluster.ResourceRequest)
+	if err != nil {
+		logger.Errorf("%s err {%v}", loggerHeader, err)
+		c.SendLocalReply(stdHttp.StatusServiceUnavailable, []byte(err.Error()))
+		return filter.Stop
+	}
+	defer release()
+	e.IncActiveRequests()
+	defer e.DecActiveRequests()
+
 	// timeout for Dial and Invoke
 	ctx, cancel := context.WithTimeout(c.Ctx, c.Timeout)
 	defer cancel()
@@ -201,8 +220,11 @@ func (f *Filter) Decode(c *http.HttpContext) filter.FilterStatus {
 
 	clientConn, ok = p.Get().(*grpc.ClientConn)
 	if !ok || clientConn == nil {
-		// TODO(Kenway): Support Credential and TLS
-		clientConn, err = grpc.DialContext(ctx, ep, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		creds := insecure.NewCredentials()
+		if tlsConfig != nil {
+			creds = credentials.NewTLS(tlsConfig)
+		}
+		clientConn, err = grpc.DialContext(ctx, ep, grpc.WithTransportCredentials(creds))
 		if err != nil || clientConn == nil {
 			logger.Errorf("%s err {failed to connect to grpc service provider}", loggerHeader)
 			c.SendLocalReply(stdHttp.StatusServiceUnavailable, []byte((fmt.Sprintf("%s", err))))
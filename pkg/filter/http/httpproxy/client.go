@@ -0,0 +1,133 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"crypto/tls"
+	"net"
+	stdhttp "net/http"
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+type (
+	// upstreamClients the http clients to upstream with the same tls config
+	upstreamClients struct {
+		scheme string
+		client *stdhttp.Client
+		// upgradeClient is used for websocket, the connection will be long-lived so no timeout for whole request
+		upgradeClient *stdhttp.Client
+	}
+
+	// clusterClients the clients of a cluster and the tls config which they are created with
+	clusterClients struct {
+		tlsConfig *tls.Config
+		clients   *upstreamClients
+	}
+
+	// clientPool cache the tls clients for each cluster, so that connections can be reused. the clients are
+	// replaced when the tls config of cluster changes
+	clientPool struct {
+		cfg     *Config
+		plain   *upstreamClients
+		mu      sync.RWMutex
+		clients map[string]*clusterClients
+	}
+)
+
+func newClientPool(cfg *Config) *clientPool {
+	return &clientPool{
+		cfg:     cfg,
+		plain:   newUpstreamClients(cfg, nil),
+		clients: make(map[string]*clusterClients),
+	}
+}
+
+// get return the clients of cluster for the tls config, plaintext clients if tlsConfig is nil
+func (p *clientPool) get(clusterName string, tlsConfig *tls.Config) *upstreamClients {
+	p.mu.RLock()
+	c, ok := p.clients[clusterName]
+	p.mu.RUnlock()
+	if ok && c.tlsConfig == tlsConfig {
+		return c.clients
+	}
+	if !ok && tlsConfig == nil {
+		return p.plain
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c, ok = p.clients[clusterName]
+	if ok && c.tlsConfig == tlsConfig {
+		return c.clients
+	}
+	if ok {
+		delete(p.clients, clusterName)
+		c.clients.closeIdleConnections()
+	}
+	if tlsConfig == nil {
+		return p.plain
+	}
+	c = &clusterClients{tlsConfig: tlsConfig, clients: newUpstreamClients(p.cfg, tlsConfig)}
+	p.clients[clusterName] = c
+	return c.clients
+}
+
+func newUpstreamClients(cfg *Config, tlsConfig *tls.Config) *upstreamClients {
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+
+	dialTimeout := cfg.Timeout
+	if dialTimeout <= 0 {
+		dialTimeout = constant.DefaultReqTimeout
+	}
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	return &upstreamClients{
+		scheme: scheme,
+		// the timeout only covers waiting for the response header, a streamed body may last longer
+		client: &stdhttp.Client{
+			Transport: stdhttp.RoundTripper(&stdhttp.Transport{
+				MaxIdleConns:          cfg.MaxIdleConns,
+				MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+				MaxConnsPerHost:       cfg.MaxConnsPerHost,
+				ResponseHeaderTimeout: cfg.Timeout,
+				TLSClientConfig:       tlsConfig,
+				DialContext:           server.LimitConnections(dialer.DialContext),
+			}),
+		},
+		upgradeClient: &stdhttp.Client{
+			Transport: stdhttp.RoundTripper(&stdhttp.Transport{
+				DialContext:           server.LimitConnections(dialer.DialContext),
+				ResponseHeaderTimeout: dialTimeout,
+				TLSClientConfig:       tlsConfig,
+			}),
+		},
+	}
+}
+
+// closeIdleConnections close the idle connections of the replaced clients, the in-use ones are closed after use
+func (c *upstreamClients) closeIdleConnections() {
+	c.client.CloseIdleConnections()
+	c.upgradeClient.CloseIdleConnections()
+}
@@ -0,0 +1,46 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientPool(t *testing.T) {
+	pool := newClientPool(&Config{})
+	assert.Same(t, pool.plain, pool.get("a", nil))
+
+	cfg := &tls.Config{}
+	c := pool.get("a", cfg)
+	assert.Equal(t, "https", c.scheme)
+	assert.Same(t, c, pool.get("a", cfg))
+	assert.NotSame(t, c, pool.get("b", cfg))
+
+	// the clients are replaced when the tls config of cluster changes
+	c2 := pool.get("a", &tls.Config{})
+	assert.NotSame(t, c, c2)
+	assert.Len(t, pool.clients, 2)
+
+	assert.Same(t, pool.plain, pool.get("a", nil))
+	assert.Len(t, pool.clients, 1)
+}
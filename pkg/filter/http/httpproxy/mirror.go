@@ -0,0 +1,172 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	stdhttp "net/http"
+	"net/url"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+const (
+	DefaultMirrorHostSuffix     = "-shadow"
+	DefaultMaxMirrorConcurrency = 64
+)
+
+// mirrorSender send the mirrored requests in background, the requests are dropped when
+// the concurrency reaches the limit so that the primary requests are never blocked
+type mirrorSender struct {
+	pool    *clientPool
+	timeout time.Duration
+	sem     chan struct{}
+}
+
+func newMirrorSender(cfg *Config, pool *clientPool) *mirrorSender {
+	concurrency := cfg.MaxMirrorConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultMaxMirrorConcurrency
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = constant.DefaultReqTimeout
+	}
+	return &mirrorSender{
+		pool:    pool,
+		timeout: timeout,
+		sem:     make(chan struct{}, concurrency),
+	}
+}
+
+// sampled decide whether the request is mirrored by the percentage of policy
+func (m *mirrorSender) sampled(policy *model.MirrorPolicy) bool {
+	if policy == nil || policy.Cluster == "" {
+		return false
+	}
+	if policy.Percentage == nil || *policy.Percentage >= 100 {
+		return true
+	}
+	return rand.Float64()*100 < *policy.Percentage
+}
+
+// send mirror the request to the shadow cluster, the endpoint is picked before returning since
+// the http context is recycled once the primary request is done
+func (m *mirrorSender) send(hc *http.HttpContext, policy *model.MirrorPolicy, body []byte) {
+	clusterManager := server.GetClusterManager()
+	endpoint := clusterManager.PickEndpoint(policy.Cluster, hc)
+	if endpoint == nil {
+		logger.Debugf("[dubbo-go-pixiu] mirror cluster %s not found endpoint", policy.Cluster)
+		return
+	}
+	tlsConfig, err := clusterManager.UpstreamTlsConfig(policy.Cluster)
+	if err != nil {
+		logger.Debugf("[dubbo-go-pixiu] mirror %v", err)
+		return
+	}
+	clients := m.pool.get(policy.Cluster, tlsConfig)
+	req, err := newMirrorRequest(hc.Request, clients.scheme, endpoint, body, policy.HostSuffix)
+	if err != nil {
+		logger.Debugf("[dubbo-go-pixiu] new mirror request failed: %v", err)
+		return
+	}
+
+	select {
+	case m.sem <- struct{}{}:
+	default:
+		logger.Debugf("[dubbo-go-pixiu] mirror to cluster %s is dropped, too many mirrored requests", policy.Cluster)
+		return
+	}
+	go func() {
+		defer func() { <-m.sem }()
+		m.do(clients.client, req, policy.Cluster, endpoint)
+	}()
+}
+
+func (m *mirrorSender) do(client *stdhttp.Client, req *stdhttp.Request, clusterName string, endpoint *model.Endpoint) {
+	ctx, cancel := context.WithTimeout(server.WithCluster(context.Background(), clusterName), m.timeout)
+	defer cancel()
+	clusterManager := server.GetClusterManager()
+	release, err := clusterManager.AcquireResource(ctx, clusterName, cluster.ResourceRequest)
+	if err != nil {
+		logger.Debugf("[dubbo-go-pixiu] mirror %v", err)
+		return
+	}
+	defer release()
+
+	endpoint.IncActiveRequests()
+	defer endpoint.DecActiveRequests()
+	resp, err := client.Do(req.WithContext(ctx))
+	switch {
+	case err == nil:
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultOfHttpStatus(resp.StatusCode))
+		// the response of shadow is discarded
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	case isOverflow(err):
+	case isTimeout(err):
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultServerError)
+	default:
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultConnectFailure)
+	}
+	if err != nil {
+		logger.Debugf("[dubbo-go-pixiu] mirror to %s failed: %v", endpoint.Address.GetAddress(), err)
+	}
+}
+
+// newMirrorRequest copy the request with the host suffixed, e.g. foo.com:8080 -> foo.com-shadow:8080
+func newMirrorRequest(r *stdhttp.Request, scheme string, endpoint *model.Endpoint, body []byte, hostSuffix string) (*stdhttp.Request, error) {
+	parsedURL := url.URL{
+		Host:     endpoint.Address.GetAddress(),
+		Scheme:   scheme,
+		Path:     r.URL.Path,
+		RawQuery: r.URL.RawQuery,
+	}
+	var reqBody io.Reader
+	if len(body) > 0 {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := stdhttp.NewRequest(r.Method, parsedURL.String(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = r.Header.Clone()
+
+	if hostSuffix == "" {
+		hostSuffix = DefaultMirrorHostSuffix
+	}
+	host, port, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		req.Host = r.Host + hostSuffix
+	} else {
+		req.Host = net.JoinHostPort(host+hostSuffix, port)
+	}
+	return req, nil
+}
@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"io"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestMirrorSampled(t *testing.T) {
+	m := newMirrorSender(&Config{}, nil)
+	assert.Equal(t, DefaultMaxMirrorConcurrency, cap(m.sem))
+	assert.False(t, m.sampled(nil))
+	assert.False(t, m.sampled(&model.MirrorPolicy{}))
+	assert.True(t, m.sampled(&model.MirrorPolicy{Cluster: "shadow"}))
+
+	zero, ten := float64(0), float64(10)
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		assert.False(t, m.sampled(&model.MirrorPolicy{Cluster: "shadow", Percentage: &zero}))
+		if m.sampled(&model.MirrorPolicy{Cluster: "shadow", Percentage: &ten}) {
+			sampled++
+		}
+	}
+	assert.Greater(t, sampled, 0)
+	assert.Less(t, sampled, 200)
+}
+
+func TestNewMirrorRequest(t *testing.T) {
+	r := httptest.NewRequest(stdhttp.MethodPost, "http://foo.com:8080/api/v1?name=pixiu", strings.NewReader("hello"))
+	r.Header.Set("X-Request-Id", "1")
+	endpoint := &model.Endpoint{Address: model.SocketAddress{Address: "127.0.0.1", Port: 9090}}
+
+	req, err := newMirrorRequest(r, "http", endpoint, []byte("hello"), "")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://127.0.0.1:9090/api/v1?name=pixiu", req.URL.String())
+	assert.Equal(t, "foo.com-shadow:8080", req.Host)
+	assert.Equal(t, "1", req.Header.Get("X-Request-Id"))
+	bt, _ := io.ReadAll(req.Body)
+	assert.Equal(t, "hello", string(bt))
+
+	// the header of primary request is not affected
+	req.Header.Set("X-Request-Id", "2")
+	assert.Equal(t, "1", r.Header.Get("X-Request-Id"))
+
+	r.Host = "foo.com"
+	req, err = newMirrorRequest(r, "https", endpoint, nil, "-canary")
+	assert.Nil(t, err)
+	assert.Equal(t, "foo.com-canary", req.Host)
+	assert.Equal(t, "https", req.URL.Scheme)
+}
@@ -0,0 +1,173 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	stdhttp "net/http"
+	"net/url"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/util/stringutil"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const (
+	RetryOn5xx                  = "5xx"
+	RetryOnGatewayError         = "gateway-error"
+	RetryOnConnectFailure       = "connect-failure"
+	RetryOnReset                = "reset"
+	RetryOnRetriableStatusCodes = "retriable-status-codes"
+
+	DefaultRetryBaseInterval = 25 * time.Millisecond
+)
+
+// retryPolicy the parsed model.RetryPolicy of a route
+type retryPolicy struct {
+	maxAttempts    int
+	perTryTimeout  time.Duration
+	baseInterval   time.Duration
+	maxInterval    time.Duration
+	on5xx          bool
+	onGatewayError bool
+	onConnect      bool
+	onReset        bool
+	statusCodes    map[int]struct{}
+}
+
+// cancelBody cancel the per try context after the response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func newRetryPolicy(cfg *model.RetryPolicy) *retryPolicy {
+	p := &retryPolicy{maxAttempts: 1}
+	if cfg == nil {
+		return p
+	}
+	if cfg.MaxAttempts > 1 {
+		p.maxAttempts = cfg.MaxAttempts
+	}
+	p.perTryTimeout = stringutil.ResolveTimeStr2Time(cfg.PerTryTimeout, 0)
+	p.baseInterval = stringutil.ResolveTimeStr2Time(cfg.BaseInterval, DefaultRetryBaseInterval)
+	p.maxInterval = stringutil.ResolveTimeStr2Time(cfg.MaxInterval, 10*p.baseInterval)
+	for _, on := range cfg.RetryOn {
+		switch on {
+		case RetryOn5xx:
+			p.on5xx = true
+		case RetryOnGatewayError:
+			p.onGatewayError = true
+		case RetryOnConnectFailure:
+			p.onConnect = true
+		case RetryOnReset:
+			p.onReset = true
+		case RetryOnRetriableStatusCodes:
+			p.statusCodes = make(map[int]struct{}, len(cfg.RetriableStatusCodes))
+			for _, code := range cfg.RetriableStatusCodes {
+				p.statusCodes[code] = struct{}{}
+			}
+		}
+	}
+	return p
+}
+
+// enabled whether the request may be sent more than once
+func (p *retryPolicy) enabled() bool {
+	return p.maxAttempts > 1
+}
+
+// shouldRetry check the result of one attempt against the retry on conditions
+func (p *retryPolicy) shouldRetry(resp *stdhttp.Response, err error) bool {
+	if err != nil {
+		switch {
+		case isConnectFailure(err):
+			return p.onConnect || p.on5xx || p.onGatewayError
+		case isTimeout(err):
+			return p.on5xx || p.onGatewayError
+		default:
+			return p.onReset || p.on5xx
+		}
+	}
+
+	code := resp.StatusCode
+	if _, ok := p.statusCodes[code]; ok {
+		return true
+	}
+	if p.on5xx && code >= stdhttp.StatusInternalServerError {
+		return true
+	}
+	if p.onGatewayError {
+		return code == stdhttp.StatusBadGateway || code == stdhttp.StatusServiceUnavailable || code == stdhttp.StatusGatewayTimeout
+	}
+	return false
+}
+
+// backoff sleep before the next attempt, the interval grows exponentially with full jitter
+func (p *retryPolicy) backoff(ctx context.Context, attempt int) bool {
+	interval := p.baseInterval << uint(attempt-1)
+	if interval > p.maxInterval || interval <= 0 {
+		interval = p.maxInterval
+	}
+	if interval <= 0 {
+		return true
+	}
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(interval)) + 1))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// wait backoff before the next attempt and discard the failed response, the response is kept
+// if the backoff is aborted so that it can still be written to downstream
+func (p *retryPolicy) wait(ctx context.Context, resp *stdhttp.Response, attempt int) bool {
+	if !p.backoff(ctx, attempt) {
+		return false
+	}
+	if resp != nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+	return true
+}
+
+func isConnectFailure(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func isTimeout(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && urlErr.Timeout()
+}
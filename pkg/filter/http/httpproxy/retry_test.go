@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	stdhttp "net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+func TestRetryPolicy(t *testing.T) {
+	p := newRetryPolicy(nil)
+	assert.False(t, p.enabled())
+
+	p = newRetryPolicy(&model.RetryPolicy{
+		RetryOn:              []string{RetryOnConnectFailure, RetryOnRetriableStatusCodes},
+		MaxAttempts:          3,
+		PerTryTimeout:        "1s",
+		RetriableStatusCodes: []int{stdhttp.StatusTooManyRequests},
+	})
+	assert.True(t, p.enabled())
+	assert.Equal(t, time.Second, p.perTryTimeout)
+	assert.Equal(t, DefaultRetryBaseInterval, p.baseInterval)
+
+	connErr := &url.Error{Op: "Get", URL: "http://127.0.0.1", Err: &net.OpError{Op: "dial", Err: errors.New("refused")}}
+	resetErr := &url.Error{Op: "Get", URL: "http://127.0.0.1", Err: errors.New("connection reset by peer")}
+	timeout := &url.Error{Op: "Get", URL: "http://127.0.0.1", Err: timeoutErr{}}
+
+	assert.True(t, p.shouldRetry(nil, connErr))
+	assert.False(t, p.shouldRetry(nil, resetErr))
+	assert.False(t, p.shouldRetry(nil, timeout))
+	assert.True(t, p.shouldRetry(&stdhttp.Response{StatusCode: stdhttp.StatusTooManyRequests}, nil))
+	assert.False(t, p.shouldRetry(&stdhttp.Response{StatusCode: stdhttp.StatusInternalServerError}, nil))
+
+	p = newRetryPolicy(&model.RetryPolicy{RetryOn: []string{RetryOn5xx}, MaxAttempts: 2})
+	assert.True(t, p.shouldRetry(nil, resetErr))
+	assert.True(t, p.shouldRetry(nil, timeout))
+	assert.True(t, p.shouldRetry(&stdhttp.Response{StatusCode: stdhttp.StatusInternalServerError}, nil))
+	assert.False(t, p.shouldRetry(&stdhttp.Response{StatusCode: stdhttp.StatusOK}, nil))
+
+	p = newRetryPolicy(&model.RetryPolicy{RetryOn: []string{RetryOnGatewayError}, MaxAttempts: 2})
+	assert.True(t, p.shouldRetry(&stdhttp.Response{StatusCode: stdhttp.StatusBadGateway}, nil))
+	assert.False(t, p.shouldRetry(&stdhttp.Response{StatusCode: stdhttp.StatusInternalServerError}, nil))
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := newRetryPolicy(&model.RetryPolicy{MaxAttempts: 5, BaseInterval: "10ms", MaxInterval: "20ms"})
+	start := time.Now()
+	for i := 1; i < 5; i++ {
+		assert.True(t, p.backoff(context.Background(), i))
+	}
+	assert.True(t, time.Since(start) < 200*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p = newRetryPolicy(&model.RetryPolicy{MaxAttempts: 2, BaseInterval: "1s"})
+	assert.False(t, p.backoff(ctx, 1))
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestRetryWait(t *testing.T) {
+	p := newRetryPolicy(&model.RetryPolicy{MaxAttempts: 2, BaseInterval: "1ms"})
+	body := &closeRecorder{Reader: strings.NewReader("unavailable")}
+	assert.True(t, p.wait(context.Background(), &stdhttp.Response{Body: body}, 1))
+	assert.True(t, body.closed)
+
+	// the response of the last attempt is kept when the backoff is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p = newRetryPolicy(&model.RetryPolicy{MaxAttempts: 2, BaseInterval: "1s"})
+	body = &closeRecorder{Reader: strings.NewReader("unavailable")}
+	assert.False(t, p.wait(ctx, &stdhttp.Response{Body: body}, 1))
+	assert.False(t, body.closed)
+	bt, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.Equal(t, "unavailable", string(bt))
+
+	assert.True(t, newRetryPolicy(&model.RetryPolicy{MaxAttempts: 2}).wait(context.Background(), nil, 1))
+}
@@ -18,24 +18,37 @@
 package httpproxy
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	stdhttp "net/http"
 	"net/url"
+	"sync"
 	"time"
 )
 
 import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
 	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
 	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
 	"github.com/apache/dubbo-go-pixiu/pkg/server"
 )
 
 const (
 	// Kind is the kind of Fallback.
 	Kind = constant.HTTPProxyFilter
+
+	// maxRepickTimes the max times to pick an endpoint which is not tried when retry
+	maxRepickTimes = 3
+
+	// DefaultMaxBufferBytes the max size of request body buffered for retry and mirror
+	DefaultMaxBufferBytes = 1 << 20
 )
 
 func init() {
@@ -49,18 +62,28 @@ type (
 	// FilterFactory is http filter instance
 	FilterFactory struct {
 		cfg    *Config
-		client stdhttp.Client
+		pool   *clientPool
+		mirror *mirrorSender
 	}
 	//Filter
 	Filter struct {
-		client stdhttp.Client
+		cfg    *Config
+		pool   *clientPool
+		mirror *mirrorSender
 	}
 	// Config describe the config of FilterFactory
 	Config struct {
+		// Timeout the max time waiting for the response header of upstream
 		Timeout             time.Duration `yaml:"timeout" json:"timeout,omitempty"`
 		MaxIdleConns        int           `yaml:"maxIdleConns" json:"maxIdleConns,omitempty"`
 		MaxIdleConnsPerHost int           `yaml:"maxIdleConnsPerHost" json:"maxIdleConnsPerHost,omitempty"`
 		MaxConnsPerHost     int           `yaml:"maxConnsPerHost" json:"maxConnsPerHost,omitempty"`
+		// MirrorPolicy mirror the requests of the routes which have no mirror policy
+		MirrorPolicy *model.MirrorPolicy `yaml:"mirrorPolicy" json:"mirrorPolicy,omitempty"`
+		// MaxMirrorConcurrency the max in-flight mirrored requests, the others are dropped
+		MaxMirrorConcurrency int `yaml:"maxMirrorConcurrency" json:"maxMirrorConcurrency,omitempty"`
+		// MaxBufferBytes the request body larger than it is streamed to upstream without retry and mirror, 1MiB by default
+		MaxBufferBytes int64 `yaml:"maxBufferBytes" json:"maxBufferBytes,omitempty"`
 	}
 )
 
@@ -77,22 +100,17 @@ func (factory *FilterFactory) Config() interface{} {
 }
 
 func (factory *FilterFactory) Apply() error {
-	cfg := factory.cfg
-	client := stdhttp.Client{
-		Timeout: cfg.Timeout,
-		Transport: stdhttp.RoundTripper(&stdhttp.Transport{
-			MaxIdleConns:        cfg.MaxIdleConns,
-			MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
-			MaxConnsPerHost:     cfg.MaxConnsPerHost,
-		}),
-	}
-	factory.client = client
+	if factory.cfg.MaxBufferBytes <= 0 {
+		factory.cfg.MaxBufferBytes = DefaultMaxBufferBytes
+	}
+	factory.pool = newClientPool(factory.cfg)
+	factory.mirror = newMirrorSender(factory.cfg, factory.pool)
 	return nil
 }
 
 func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
 	//reuse http client
-	f := &Filter{factory.client}
+	f := &Filter{cfg: factory.cfg, pool: factory.pool, mirror: factory.mirror}
 	chain.AppendDecodeFilters(f)
 	return nil
 }
@@ -114,41 +132,271 @@ func (f *Filter) Decode(hc *http.HttpContext) filter.FilterStatus {
 		return filter.Stop
 	}
 
-	logger.Debugf("[dubbo-go-pixiu] client choose endpoint :%v", endpoint.Address.GetAddress())
+	tlsConfig, err := clusterManager.UpstreamTlsConfig(clusterName)
+	if err != nil {
+		logger.Errorf("[dubbo-go-pixiu] %v", err)
+		bt, _ := json.Marshal(http.ErrResponse{Message: err.Error()})
+		hc.SendLocalReply(stdhttp.StatusServiceUnavailable, bt)
+		return filter.Stop
+	}
+	clients := f.pool.get(clusterName, tlsConfig)
+
+	release, err := clusterManager.AcquireResource(hc.Request.Context(), clusterName, cluster.ResourceRequest)
+	if err != nil {
+		sendOverflow(hc, err)
+		return filter.Stop
+	}
+
+	if hc.IsWebSocketUpgrade() {
+		defer release()
+		return f.decodeUpgrade(hc, clients, clusterName, endpoint)
+	}
+
 	r := hc.Request
+	retry := newRetryPolicy(rEntry.RetryPolicy)
+	mirrorPolicy := rEntry.MirrorPolicy
+	if mirrorPolicy == nil {
+		mirrorPolicy = f.cfg.MirrorPolicy
+	}
+	mirrored := f.mirror.sampled(mirrorPolicy)
 
-	var (
-		req *stdhttp.Request
-		err error
-	)
+	// buffer the request body so that it can be replayed or mirrored
+	var body []byte
+	if (retry.enabled() || mirrored) && r.Body != nil {
+		body, err = bufferBody(r, f.cfg.MaxBufferBytes)
+		if err != nil {
+			release()
+			bt, _ := json.Marshal(http.ErrResponse{Message: fmt.Sprintf("read request body failed: %v", err)})
+			hc.SendLocalReply(stdhttp.StatusBadRequest, bt)
+			return filter.Stop
+		}
+		if body == nil {
+			logger.Debugf("[dubbo-go-pixiu] request body exceeds %d bytes, forward it without retry and mirror", f.cfg.MaxBufferBytes)
+			retry = newRetryPolicy(nil)
+			mirrored = false
+		}
+	}
+	if mirrored {
+		f.mirror.send(hc, mirrorPolicy, body)
+	}
 
-	parsedURL := url.URL{
-		Host:     endpoint.Address.GetAddress(),
-		Scheme:   "http",
-		Path:     r.URL.Path,
-		RawQuery: r.URL.RawQuery,
+	var resp *stdhttp.Response
+	tried := make(map[string]struct{}, retry.maxAttempts)
+	releaseRetry := func() {}
+	for attempt := 1; ; attempt++ {
+		logger.Debugf("[dubbo-go-pixiu] client choose endpoint :%v, attempt %d", endpoint.Address.GetAddress(), attempt)
+		tried[endpoint.ID] = struct{}{}
+
+		var req *stdhttp.Request
+		req, err = newUpstreamRequest(r, clients.scheme, endpoint, body)
+		if err != nil {
+			releaseRetry()
+			release()
+			bt, _ := json.Marshal(http.ErrResponse{Message: fmt.Sprintf("BUG: new request failed: %v", err)})
+			hc.SendLocalReply(stdhttp.StatusInternalServerError, bt)
+			return filter.Stop
+		}
+		req = req.WithContext(server.WithCluster(req.Context(), clusterName))
+
+		endpoint.IncActiveRequests()
+		resp, err = f.doOnce(clients.client, req, retry.perTryTimeout)
+		resp = trackActiveRequest(endpoint, resp)
+		releaseRetry()
+		f.reportResult(clusterName, endpoint, resp, err)
+		if attempt >= retry.maxAttempts || !retry.shouldRetry(resp, err) {
+			break
+		}
+
+		// choose a different endpoint for the next attempt
+		next := pickUntriedEndpoint(clusterName, hc, tried)
+		if next == nil {
+			break
+		}
+		var retryErr error
+		releaseRetry, retryErr = clusterManager.AcquireResource(r.Context(), clusterName, cluster.ResourceRetry)
+		if retryErr != nil {
+			logger.Debugf("[dubbo-go-pixiu] stop retrying: %v", retryErr)
+			break
+		}
+		if !retry.wait(r.Context(), resp, attempt) {
+			releaseRetry()
+			break
+		}
+		endpoint = next
+	}
+
+	if err != nil {
+		release()
+		if isOverflow(err) {
+			sendOverflow(hc, err)
+			return filter.Stop
+		}
+		if isTimeout(err) {
+			hc.SendLocalReply(stdhttp.StatusGatewayTimeout, []byte(err.Error()))
+			return filter.Stop
+		}
+		hc.SendLocalReply(stdhttp.StatusServiceUnavailable, []byte(err.Error()))
+		return filter.Stop
+	}
+	logger.Debugf("[dubbo-go-pixiu] client call resp:%v", resp)
+	resp.Body = &activeBody{ReadCloser: resp.Body, done: release}
+	hc.SourceResp = resp
+	// response write in hcm
+	return filter.Continue
+}
+
+// bufferBody read the whole request body if it is not larger than limit, otherwise nil is returned
+// and the body is left to be streamed, including the part already read
+func bufferBody(r *stdhttp.Request, limit int64) ([]byte, error) {
+	if r.ContentLength > limit {
+		return nil, nil
+	}
+	bt, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(bt)) > limit {
+		r.Body = &prefixedBody{Reader: io.MultiReader(bytes.NewReader(bt), r.Body), Closer: r.Body}
+		return nil, nil
 	}
+	_ = r.Body.Close()
+	return bt, nil
+}
+
+// prefixedBody the body whose beginning is read ahead
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
 
-	req, err = stdhttp.NewRequest(r.Method, parsedURL.String(), r.Body)
+// decodeUpgrade forward the websocket handshake to upstream, the upgraded connection is served by hcm
+func (f *Filter) decodeUpgrade(hc *http.HttpContext, clients *upstreamClients, clusterName string, endpoint *model.Endpoint) filter.FilterStatus {
+	logger.Debugf("[dubbo-go-pixiu] websocket upgrade to endpoint :%v", endpoint.Address.GetAddress())
+	req, err := newUpstreamRequest(hc.Request, clients.scheme, endpoint, nil)
 	if err != nil {
 		bt, _ := json.Marshal(http.ErrResponse{Message: fmt.Sprintf("BUG: new request failed: %v", err)})
 		hc.SendLocalReply(stdhttp.StatusInternalServerError, bt)
 		return filter.Stop
 	}
-	req.Header = r.Header
+	req = req.WithContext(server.WithCluster(req.Context(), clusterName))
 
-	resp, err := f.client.Do(req)
+	endpoint.IncActiveRequests()
+	resp, err := clients.upgradeClient.Do(req)
+	endpoint.DecActiveRequests()
+	f.reportResult(clusterName, endpoint, resp, err)
 	if err != nil {
-		urlErr, ok := err.(*url.Error)
-		if ok && urlErr.Timeout() {
+		if isOverflow(err) {
+			sendOverflow(hc, err)
+			return filter.Stop
+		}
+		if isTimeout(err) {
 			hc.SendLocalReply(stdhttp.StatusGatewayTimeout, []byte(err.Error()))
 			return filter.Stop
 		}
 		hc.SendLocalReply(stdhttp.StatusServiceUnavailable, []byte(err.Error()))
 		return filter.Stop
 	}
-	logger.Debugf("[dubbo-go-pixiu] client call resp:%v", resp)
 	hc.SourceResp = resp
-	// response write in hcm
 	return filter.Continue
 }
+
+// doOnce send the request to upstream, the per try context is canceled when the response body is closed
+func (f *Filter) doOnce(client *stdhttp.Client, req *stdhttp.Request, perTryTimeout time.Duration) (*stdhttp.Response, error) {
+	if perTryTimeout <= 0 {
+		return client.Do(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), perTryTimeout)
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// trackActiveRequest the request is in-flight until the response body is closed
+func trackActiveRequest(endpoint *model.Endpoint, resp *stdhttp.Response) *stdhttp.Response {
+	if resp == nil {
+		endpoint.DecActiveRequests()
+		return nil
+	}
+	resp.Body = &activeBody{ReadCloser: resp.Body, done: endpoint.DecActiveRequests}
+	return resp
+}
+
+// activeBody the request is done when the response body is closed, e.g. decrease the in-flight requests of endpoint
+type activeBody struct {
+	io.ReadCloser
+	done func()
+	once sync.Once
+}
+
+func (b *activeBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
+
+// isOverflow the request is rejected by the circuit breakers of cluster
+func isOverflow(err error) bool {
+	var overflow *cluster.OverflowError
+	return errors.As(err, &overflow)
+}
+
+// sendOverflow fail fast with 503 if the request is rejected by the circuit breakers of cluster
+func sendOverflow(hc *http.HttpContext, err error) {
+	logger.Debugf("[dubbo-go-pixiu] %v", err)
+	bt, _ := json.Marshal(http.ErrResponse{Message: err.Error()})
+	hc.SendLocalReply(stdhttp.StatusServiceUnavailable, bt)
+}
+
+func (f *Filter) reportResult(clusterName string, endpoint *model.Endpoint, resp *stdhttp.Response, err error) {
+	clusterManager := server.GetClusterManager()
+	switch {
+	case err == nil:
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultOfHttpStatus(resp.StatusCode))
+	case isOverflow(err):
+		// the endpoint is not to blame for the overflow of cluster
+	case isTimeout(err):
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultServerError)
+	default:
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultConnectFailure)
+	}
+}
+
+// pickUntriedEndpoint try to pick an endpoint which is not tried yet, fallback to any endpoint
+func pickUntriedEndpoint(clusterName string, hc *http.HttpContext, tried map[string]struct{}) *model.Endpoint {
+	clusterManager := server.GetClusterManager()
+	var endpoint *model.Endpoint
+	for i := 0; i < maxRepickTimes; i++ {
+		endpoint = clusterManager.PickEndpoint(clusterName, hc)
+		if endpoint == nil {
+			return nil
+		}
+		if _, ok := tried[endpoint.ID]; !ok {
+			return endpoint
+		}
+	}
+	return endpoint
+}
+
+func newUpstreamRequest(r *stdhttp.Request, scheme string, endpoint *model.Endpoint, body []byte) (*stdhttp.Request, error) {
+	parsedURL := url.URL{
+		Host:     endpoint.Address.GetAddress(),
+		Scheme:   scheme,
+		Path:     r.URL.Path,
+		RawQuery: r.URL.RawQuery,
+	}
+
+	var reqBody io.Reader = r.Body
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := stdhttp.NewRequest(r.Method, parsedURL.String(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = r.Header
+	return req, nil
+}
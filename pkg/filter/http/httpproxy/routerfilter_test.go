@@ -0,0 +1,53 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package httpproxy
+
+import (
+	"io"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufferBody(t *testing.T) {
+	r := httptest.NewRequest(stdhttp.MethodPost, "http://foo.com/api", strings.NewReader("hello"))
+	body, err := bufferBody(r, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(body))
+
+	// the body of unknown length exceeding the limit is streamed entirely
+	r = httptest.NewRequest(stdhttp.MethodPost, "http://foo.com/api", io.MultiReader(strings.NewReader("hello "), strings.NewReader("pixiu")))
+	r.ContentLength = -1
+	body, err = bufferBody(r, 5)
+	assert.Nil(t, err)
+	assert.Nil(t, body)
+	bt, _ := io.ReadAll(r.Body)
+	assert.Equal(t, "hello pixiu", string(bt))
+
+	// the body is not read at all if the content length exceeds the limit
+	r = httptest.NewRequest(stdhttp.MethodPost, "http://foo.com/api", strings.NewReader("hello pixiu"))
+	body, err = bufferBody(r, 5)
+	assert.Nil(t, err)
+	assert.Nil(t, body)
+	bt, _ = io.ReadAll(r.Body)
+	assert.Equal(t, "hello pixiu", string(bt))
+}
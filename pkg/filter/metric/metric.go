@@ -108,6 +108,7 @@ func (f *Filter) Encode(c *http.HttpContext) filter.FilterStatus {
 		attribute.String("method", c.Request.Method),
 		attribute.String("url", c.GetUrl()),
 		attribute.String("host", c.Request.Host),
+		attribute.String("consumer", c.GetConsumer()),
 	}
 
 	latency := time.Since(f.start)
@@ -126,11 +127,19 @@ func (f *Filter) Encode(c *http.HttpContext) filter.FilterStatus {
 		sizeRequest.Add(c.Ctx, int64(size), commonAttrs...)
 	}
 
-	size, err = computeApproximateResponseSize(c.TargetResp)
-	if err != nil {
-		logger.Warn("can not compute response size", err)
-	} else {
-		sizeResponse.Add(c.Ctx, int64(size), commonAttrs...)
+	if c.TargetResp != nil {
+		size, err = computeApproximateResponseSize(c.TargetResp)
+		if err != nil {
+			logger.Warn("can not compute response size", err)
+		} else {
+			sizeResponse.Add(c.Ctx, int64(size), commonAttrs...)
+		}
+	} else if !c.LocalReply() {
+		// the response body is streamed to client, count it once it is written
+		ctx := c.Ctx
+		c.OnResponseWritten(func(size int64) {
+			sizeResponse.Add(ctx, size, commonAttrs...)
+		})
 	}
 
 	logger.Debugf("[Metric] [UPSTREAM] receive request | %d | %s | %s | %s | ", c.GetStatusCode(), latency, c.GetMethod(), c.GetUrl())
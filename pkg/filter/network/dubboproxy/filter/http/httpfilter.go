@@ -18,12 +18,14 @@
 package http
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 )
 
 import (
@@ -34,6 +36,7 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
 	dubbo2 "github.com/apache/dubbo-go-pixiu/pkg/context/dubbo"
@@ -48,6 +51,14 @@ func init() {
 	filter.RegisterDubboFilterPlugin(&Plugin{})
 }
 
+var (
+	plainClient = &http.Client{}
+	// tlsClients cache the http client of each cluster, so that connections are reused. the client is replaced
+	// when the tls config of cluster changes
+	tlsClients   = make(map[string]*tlsClient)
+	tlsClientsMu sync.RWMutex
+)
+
 type (
 	// Plugin dubbo to http transform plugin
 	Plugin struct {
@@ -61,6 +72,12 @@ type (
 	Filter struct {
 		Config *Config
 	}
+
+	// tlsClient the client of a cluster and the tls config which it is created with
+	tlsClient struct {
+		tlsConfig *tls.Config
+		client    *http.Client
+	}
 )
 
 // Kind the filter kind
@@ -104,15 +121,24 @@ func (f Filter) Handle(ctx *dubbo2.RpcContext) filter.FilterStatus {
 	methodName := invoc.Arguments()[0].(string)
 	path := interfaceKey + "/" + methodName
 
+	tlsConfig, err := clusterManager.UpstreamTlsConfig(clusterName)
+	if err != nil {
+		ctx.SetError(err)
+		return filter.Stop
+	}
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
 	parsedURL := url.URL{
 		Host:   endpoint.Address.GetAddress(),
-		Scheme: "http",
+		Scheme: scheme,
 		Path:   path,
 	}
 
 	body := invoc.Arguments()[2]
 	b, _ := json.Marshal(body)
-	req, err = http.NewRequest(http.MethodPost, parsedURL.String(), strings.NewReader(string(b)))
+	req, err = http.NewRequestWithContext(ctx.Context(), http.MethodPost, parsedURL.String(), strings.NewReader(string(b)))
 	if err != nil {
 		err := errors.New(fmt.Sprintf("create new request failed: %v", err))
 		ctx.SetError(err)
@@ -127,7 +153,15 @@ func (f Filter) Handle(ctx *dubbo2.RpcContext) filter.FilterStatus {
 	req.Header.Set(constant.DubboServiceVersion, versionKey)
 	req.Header.Set(constant.DubboGroup, groupKey)
 
-	resp, err := (&http.Client{}).Do(req)
+	release, err := clusterManager.AcquireResource(ctx.Context(), clusterName, cluster.ResourceRequest)
+	if err != nil {
+		ctx.SetError(err)
+		return filter.Stop
+	}
+	endpoint.IncActiveRequests()
+	resp, err := httpClient(clusterName, tlsConfig).Do(req)
+	endpoint.DecActiveRequests()
+	release()
 	if err != nil {
 		ctx.SetError(err)
 		return filter.Stop
@@ -144,3 +178,35 @@ func (f Filter) Handle(ctx *dubbo2.RpcContext) filter.FilterStatus {
 	ctx.SetResult(result)
 	return filter.Continue
 }
+
+// httpClient return the cached client of cluster for the tls config, the plaintext one if tlsConfig is nil
+func httpClient(clusterName string, tlsConfig *tls.Config) *http.Client {
+	tlsClientsMu.RLock()
+	c, ok := tlsClients[clusterName]
+	tlsClientsMu.RUnlock()
+	if ok && c.tlsConfig == tlsConfig {
+		return c.client
+	}
+	if !ok && tlsConfig == nil {
+		return plainClient
+	}
+
+	tlsClientsMu.Lock()
+	defer tlsClientsMu.Unlock()
+	c, ok = tlsClients[clusterName]
+	if ok && c.tlsConfig == tlsConfig {
+		return c.client
+	}
+	if ok {
+		delete(tlsClients, clusterName)
+		c.client.CloseIdleConnections()
+	}
+	if tlsConfig == nil {
+		return plainClient
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	c = &tlsClient{tlsConfig: tlsConfig, client: &http.Client{Transport: transport}}
+	tlsClients[clusterName] = c
+	return c.client
+}
@@ -25,6 +25,7 @@ import (
 import (
 	"dubbo.apache.org/dubbo-go/v3/common"
 	dubboConstant "dubbo.apache.org/dubbo-go/v3/common/constant"
+	"dubbo.apache.org/dubbo-go/v3/protocol"
 	"dubbo.apache.org/dubbo-go/v3/protocol/dubbo"
 	"dubbo.apache.org/dubbo-go/v3/protocol/dubbo3"
 	tripleConstant "github.com/dubbogo/triple/pkg/common/constant"
@@ -32,9 +33,11 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
 	dubbo2 "github.com/apache/dubbo-go-pixiu/pkg/context/dubbo"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
 	"github.com/apache/dubbo-go-pixiu/pkg/server"
 )
 
@@ -101,6 +104,12 @@ func (f Filter) sendDubboRequest(ctx *dubbo2.RpcContext) filter.FilterStatus {
 		return filter.Stop
 	}
 
+	// the getty client of dubbo protocol only uses the global tls config of dubbo-go
+	if clusterManager.UpstreamTlsFiles(clusterName) != nil {
+		ctx.SetError(errors.Errorf("cluster %s tls is not supported by dubbo protocol, use the tls config of dubbo-go instead", clusterName))
+		return filter.Stop
+	}
+
 	invoc := ctx.RpcInvocation
 	interfaceKey, _ := invoc.GetAttachment(dubboConstant.InterfaceKey)
 	groupKey, _ := invoc.GetAttachment(dubboConstant.GroupKey)
@@ -126,6 +135,7 @@ func (f Filter) sendDubboRequest(ctx *dubbo2.RpcContext) filter.FilterStatus {
 	// TODO: will print many Error when failed to connect server
 	invoker := dubboProtocol.Refer(url)
 	if invoker == nil {
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultConnectFailure)
 		ctx.SetError(errors.Errorf("can't connect to upstream server %s with address %s", endpoint.Name, endpoint.Address.GetAddress()))
 		return filter.Stop
 	}
@@ -133,12 +143,22 @@ func (f Filter) sendDubboRequest(ctx *dubbo2.RpcContext) filter.FilterStatus {
 	invoc.SetReply(&resp)
 
 	invCtx := context.Background()
+	release, err := clusterManager.AcquireResource(ctx.Context(), clusterName, cluster.ResourceRequest)
+	if err != nil {
+		ctx.SetError(err)
+		return filter.Stop
+	}
+	endpoint.IncActiveRequests()
 	result := invoker.Invoke(invCtx, invoc)
+	endpoint.DecActiveRequests()
+	release()
 	result.SetAttachments(invoc.Attachments())
 	if result.Error() != nil {
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultServerError)
 		ctx.SetError(result.Error())
 		return filter.Stop
 	}
+	clusterManager.ReportResult(clusterName, endpoint, cluster.ResultSuccess)
 
 	value := reflect.ValueOf(result.Result())
 	result.SetResult(value.Elem().Interface())
@@ -172,8 +192,9 @@ func (f Filter) sendTripleRequest(ctx *dubbo2.RpcContext) filter.FilterStatus {
 		return filter.Stop
 	}
 
-	invoker, err := dubbo3.NewDubboInvoker(url)
+	invoker, err := newTripleInvoker(url, clusterManager.UpstreamTlsFiles(clusterName))
 	if err != nil {
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultConnectFailure)
 		ctx.SetError(err)
 		return filter.Stop
 	}
@@ -181,18 +202,31 @@ func (f Filter) sendTripleRequest(ctx *dubbo2.RpcContext) filter.FilterStatus {
 	var resp interface{}
 	invoc.SetReply(&resp)
 	invCtx := context.Background()
+	release, err := clusterManager.AcquireResource(ctx.Context(), clusterName, cluster.ResourceRequest)
+	if err != nil {
+		ctx.SetError(err)
+		return filter.Stop
+	}
+	endpoint.IncActiveRequests()
 	result := invoker.Invoke(invCtx, invoc)
+	endpoint.DecActiveRequests()
+	release()
+	// the invoker is created for each request, close its connection
+	invoker.Destroy()
 
 	if result.Error() != nil {
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultServerError)
 		ctx.SetError(result.Error())
 		return filter.Stop
 	}
 
 	// when upstream server down, the result and error in result are both nil
 	if result.Result() == nil {
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultConnectFailure)
 		ctx.SetError(errors.New("result from upstream server is nil"))
 		return filter.Stop
 	}
+	clusterManager.ReportResult(clusterName, endpoint, cluster.ResultSuccess)
 
 	result.SetAttachments(invoc.Attachments())
 	value := reflect.ValueOf(result.Result())
@@ -200,3 +234,11 @@ func (f Filter) sendTripleRequest(ctx *dubbo2.RpcContext) filter.FilterStatus {
 	ctx.SetResult(result)
 	return filter.Continue
 }
+
+// newTripleInvoker create the invoker of dubbo-go, or the one with the tls config of cluster if it is set
+func newTripleInvoker(url *common.URL, tlsConfig *model.UpstreamTlsConfig) (protocol.Invoker, error) {
+	if tlsConfig != nil {
+		return newTripleTlsInvoker(url, tlsConfig)
+	}
+	return dubbo3.NewDubboInvoker(url)
+}
@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+	"reflect"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common"
+	dubboConstant "dubbo.apache.org/dubbo-go/v3/common/constant"
+	"dubbo.apache.org/dubbo-go/v3/protocol"
+	"github.com/dubbogo/grpc-go/metadata"
+	tripleConstant "github.com/dubbogo/triple/pkg/common/constant"
+	triConfig "github.com/dubbogo/triple/pkg/config"
+	"github.com/dubbogo/triple/pkg/triple"
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+// defaultTripleTimeout the timeout of triple request if it is not set in url
+const defaultTripleTimeout = "3s"
+
+// tripleTlsInvoker invoke the upstream by triple client with the tls config of cluster,
+// the invoker of dubbo-go only uses the global tls config of dubbo-go
+type tripleTlsInvoker struct {
+	protocol.BaseInvoker
+	client *triple.TripleClient
+}
+
+func newTripleTlsInvoker(url *common.URL, cfg *model.UpstreamTlsConfig) (*tripleTlsInvoker, error) {
+	opt := triConfig.NewTripleOption(
+		triConfig.WithClientTimeout(url.GetParamDuration(dubboConstant.TimeoutKey, defaultTripleTimeout)),
+		triConfig.WithCodecType(tripleConstant.CodecType(url.GetParam(dubboConstant.SerializationKey, dubboConstant.Hessian2Serialization))),
+		triConfig.WithLocation(url.Location),
+		triConfig.WithHeaderAppVersion(url.GetParam(dubboConstant.AppVersionKey, "")),
+		triConfig.WithHeaderGroup(url.GetParam(dubboConstant.GroupKey, "")),
+		triConfig.WithLogger(logger.GetTripleLogger()),
+	)
+	if err := setTripleTlsOption(opt, cfg); err != nil {
+		return nil, err
+	}
+	client, err := triple.NewTripleClient(nil, opt)
+	if err != nil {
+		return nil, err
+	}
+	return &tripleTlsInvoker{BaseInvoker: *protocol.NewBaseInvoker(url), client: client}, nil
+}
+
+// setTripleTlsOption map the tls config to the certificate files accepted by triple client,
+// the options triple client can not honor are rejected rather than ignored silently
+func setTripleTlsOption(opt *triConfig.Option, cfg *model.UpstreamTlsConfig) error {
+	switch {
+	case cfg.InsecureSkipVerify:
+		return errors.New("tls insecure_skip_verify is not supported by triple upstream")
+	case cfg.MinVersion != "":
+		return errors.New("tls min_version is not supported by triple upstream")
+	case cfg.CACertFile == "":
+		return errors.New("tls ca_cert_file is required by triple upstream")
+	case (cfg.CertFile == "") != (cfg.KeyFile == ""):
+		return errors.New("tls cert_file and key_file must be set together")
+	}
+	opt.TLSServerName = cfg.ServerName
+	if cfg.CertFile == "" {
+		// triple client verifies the server with the certificate file as CA if no key is given
+		opt.TLSCertFile = cfg.CACertFile
+		return nil
+	}
+	opt.TLSCertFile = cfg.CertFile
+	opt.TLSKeyFile = cfg.KeyFile
+	opt.CACertFile = cfg.CACertFile
+	return nil
+}
+
+// Invoke send the invocation with its attachments as grpc metadata, as the invoker of dubbo-go does
+func (i *tripleTlsInvoker) Invoke(ctx context.Context, invocation protocol.Invocation) protocol.Result {
+	md := make(metadata.MD)
+	for k, v := range invocation.Attachments() {
+		switch val := v.(type) {
+		case string:
+			md.Set(k, val)
+		case []string:
+			md.Set(k, val...)
+		}
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+	ctx = context.WithValue(ctx, tripleConstant.InterfaceKey, i.GetURL().GetParam(dubboConstant.InterfaceKey, ""))
+	in := append([]reflect.Value{reflect.ValueOf(ctx)}, invocation.ParameterValues()...)
+
+	res := i.client.Invoke(invocation.MethodName(), in, invocation.Reply())
+	result := &protocol.RPCResult{
+		Err:   res.GetError(),
+		Attrs: make(map[string]interface{}),
+		Rest:  invocation.Reply(),
+	}
+	for k, v := range res.GetAttachments() {
+		result.Attrs[k] = v
+	}
+	return result
+}
+
+// Destroy close the connection to upstream
+func (i *tripleTlsInvoker) Destroy() {
+	i.BaseInvoker.Destroy()
+	i.client.Close()
+}
@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"testing"
+)
+
+import (
+	triConfig "github.com/dubbogo/triple/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestSetTripleTlsOption(t *testing.T) {
+	opt := triConfig.NewTripleOption()
+	err := setTripleTlsOption(opt, &model.UpstreamTlsConfig{CACertFile: "ca.pem", ServerName: "user"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ca.pem", opt.TLSCertFile)
+	assert.Equal(t, "", opt.TLSKeyFile)
+	assert.Equal(t, "user", opt.TLSServerName)
+
+	opt = triConfig.NewTripleOption()
+	err = setTripleTlsOption(opt, &model.UpstreamTlsConfig{CACertFile: "ca.pem", CertFile: "client.pem", KeyFile: "client.key"})
+	assert.NoError(t, err)
+	assert.Equal(t, "client.pem", opt.TLSCertFile)
+	assert.Equal(t, "client.key", opt.TLSKeyFile)
+	assert.Equal(t, "ca.pem", opt.CACertFile)
+
+	for _, cfg := range []*model.UpstreamTlsConfig{
+		{},
+		{CACertFile: "ca.pem", InsecureSkipVerify: true},
+		{CACertFile: "ca.pem", MinVersion: "TLSv1_2"},
+		{CACertFile: "ca.pem", CertFile: "client.pem"},
+	} {
+		assert.Error(t, setTripleTlsOption(triConfig.NewTripleOption(), cfg))
+	}
+}
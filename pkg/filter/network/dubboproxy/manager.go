@@ -128,7 +128,7 @@ func (dcm *DubboProxyConnectionManager) OnTripleData(ctx context.Context, method
 		invocation.WithParameterValues(inVArr),
 		invocation.WithAttachments(dubboAttachment))
 
-	rpcContext := &dubbo2.RpcContext{}
+	rpcContext := &dubbo2.RpcContext{Ctx: ctx}
 	rpcContext.SetInvocation(invoc)
 	rpcContext.SetRoute(ra)
 	dcm.handleRpcInvocation(rpcContext)
@@ -174,6 +174,16 @@ func (dcm *DubboProxyConnectionManager) OnData(data interface{}) (interface{}, e
 func (dcm *DubboProxyConnectionManager) handleRpcInvocation(c *dubbo2.RpcContext) {
 	filterChain := dcm.filterManager.filters
 
+	// bound the invocation, e.g. waiting for the pending requests of cluster, by the timeout of connection manager
+	if c.Ctx == nil {
+		c.Ctx = context.Background()
+	}
+	if dcm.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		c.Ctx, cancel = context.WithTimeout(c.Ctx, dcm.config.Timeout)
+		defer cancel()
+	}
+
 	// recover any err when filterChain run
 	defer func() {
 		if err := recover(); err != nil {
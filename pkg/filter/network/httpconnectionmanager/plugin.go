@@ -46,6 +46,10 @@ func (p *Plugin) Kind() string {
 func (p *Plugin) CreateFilter(config interface{}) (filter.NetworkFilter, error) {
 	hcmc := config.(*model.HttpConnectionManagerConfig)
 	hcmc.Timeout = stringutil.ResolveTimeStr2Time(hcmc.TimeoutStr, constant.DefaultReqTimeout)
+	if hcmc.WebSocket == nil {
+		hcmc.WebSocket = &model.WebSocketConfig{}
+	}
+	hcmc.WebSocket.IdleTimeout = stringutil.ResolveTimeStr2Time(hcmc.WebSocket.IdleTimeoutStr, constant.DefaultWebSocketIdleTimeout)
 	return http.CreateHttpConnectionManager(hcmc), nil
 }
 
@@ -0,0 +1,86 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcpproxy
+
+import (
+	"sync"
+)
+
+import (
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+)
+
+var (
+	registerOnce sync.Once
+	registerErr  error
+
+	connectionTotal   syncint64.Counter
+	connectionActive  syncint64.UpDownCounter
+	connectFailTotal  syncint64.Counter
+	receivedBytesSize syncint64.Counter
+	sentBytesSize     syncint64.Counter
+)
+
+func registerOtelMetric() error {
+	registerOnce.Do(func() {
+		registerErr = doRegisterOtelMetric()
+	})
+	return registerErr
+}
+
+func doRegisterOtelMetric() error {
+	meter := global.MeterProvider().Meter("pixiu")
+
+	var err error
+	connectionTotal, err = meter.SyncInt64().Counter("pixiu_tcp_connection_total", instrument.WithDescription("tcp proxy downstream connection total count in pixiu"))
+	if err != nil {
+		logger.Errorf("register pixiu_tcp_connection_total metric failed, err: %v", err)
+		return err
+	}
+
+	connectionActive, err = meter.SyncInt64().UpDownCounter("pixiu_tcp_connection_active", instrument.WithDescription("tcp proxy active connection count in pixiu"))
+	if err != nil {
+		logger.Errorf("register pixiu_tcp_connection_active metric failed, err: %v", err)
+		return err
+	}
+
+	connectFailTotal, err = meter.SyncInt64().Counter("pixiu_tcp_upstream_connect_fail_total", instrument.WithDescription("tcp proxy upstream connect failure count in pixiu"))
+	if err != nil {
+		logger.Errorf("register pixiu_tcp_upstream_connect_fail_total metric failed, err: %v", err)
+		return err
+	}
+
+	receivedBytesSize, err = meter.SyncInt64().Counter("pixiu_tcp_received_bytes", instrument.WithDescription("tcp proxy bytes received from downstream in pixiu"))
+	if err != nil {
+		logger.Errorf("register pixiu_tcp_received_bytes metric failed, err: %v", err)
+		return err
+	}
+
+	sentBytesSize, err = meter.SyncInt64().Counter("pixiu_tcp_sent_bytes", instrument.WithDescription("tcp proxy bytes sent to downstream in pixiu"))
+	if err != nil {
+		logger.Errorf("register pixiu_tcp_sent_bytes metric failed, err: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcpproxy
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/util/stringutil"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const (
+	Kind = constant.TCPProxyFilter
+)
+
+func init() {
+	filter.RegisterNetworkFilterPlugin(&Plugin{})
+}
+
+type (
+	// Plugin the tcp proxy networkfilter plugin
+	Plugin struct{}
+)
+
+// Kind kind
+func (p *Plugin) Kind() string {
+	return Kind
+}
+
+// CreateFilter create tcp proxy networkfilter
+func (p *Plugin) CreateFilter(config interface{}) (filter.NetworkFilter, error) {
+	tpc, ok := config.(*model.TcpProxyConfig)
+	if !ok {
+		panic("CreateFilter occur some exception for the type is not suitable one.")
+	}
+	tpc.ConnectTimeout = stringutil.ResolveTimeStr2Time(tpc.ConnectTimeoutStr, constant.DefaultTCPConnectTimeout)
+	tpc.IdleTimeout = stringutil.ResolveTimeStr2Time(tpc.IdleTimeoutStr, constant.DefaultTCPIdleTimeout)
+	if err := registerOtelMetric(); err != nil {
+		return nil, err
+	}
+	return CreateTcpProxy(tpc), nil
+}
+
+// Config return TcpProxyConfig
+func (p *Plugin) Config() interface{} {
+	return &model.TcpProxyConfig{}
+}
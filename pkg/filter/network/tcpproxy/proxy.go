@@ -0,0 +1,272 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcpproxy
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+const (
+	// copyBufferSize the buffer size of each direction
+	copyBufferSize = 32 * 1024
+)
+
+type (
+	// TcpProxy network filter which proxies the byte stream of tcp connection to upstream cluster
+	TcpProxy struct {
+		filter.EmptyNetworkFilter
+		config      *model.TcpProxyConfig
+		totalWeight int
+	}
+
+	// closeWriter the connection supports half close, like *net.TCPConn and *tls.Conn
+	closeWriter interface {
+		CloseWrite() error
+	}
+
+	// clientPolicy use the client ip as hash key, so that hash load balancers keep affinity
+	clientPolicy string
+
+	// connection a downstream connection and its upstream connection
+	connection struct {
+		downstream  net.Conn
+		upstream    net.Conn
+		idleTimeout time.Duration
+		lastActive  int64
+		closeOnce   sync.Once
+		done        chan struct{}
+	}
+)
+
+var _ filter.ConnectionNetworkFilter = (*TcpProxy)(nil)
+
+// GenerateHash generate hash key of the client
+func (p clientPolicy) GenerateHash() string {
+	return string(p)
+}
+
+// CreateTcpProxy create tcp proxy network filter
+func CreateTcpProxy(config *model.TcpProxyConfig) *TcpProxy {
+	totalWeight := 0
+	for _, wc := range config.WeightedClusters {
+		if wc.Weight > 0 {
+			totalWeight += wc.Weight
+		}
+	}
+	return &TcpProxy{
+		config:      config,
+		totalWeight: totalWeight,
+	}
+}
+
+// OnConnection pick an endpoint of the cluster and proxy the connection to it
+func (p *TcpProxy) OnConnection(conn net.Conn) {
+	ctx := context.Background()
+	clusterName := p.pickCluster()
+	attrs := []attribute.KeyValue{
+		attribute.String("stat_prefix", p.config.StatPrefix),
+		attribute.String("cluster", clusterName),
+	}
+	connectionTotal.Add(ctx, 1, attrs...)
+
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		host = conn.RemoteAddr().String()
+	}
+	clusterManager := server.GetClusterManager()
+	endpoint := clusterManager.PickEndpoint(clusterName, clientPolicy(host))
+	if endpoint == nil {
+		logger.Warnf("[dubbo-go-pixiu] tcp proxy cluster %s not found endpoint", clusterName)
+		_ = conn.Close()
+		return
+	}
+
+	tlsConfig, err := clusterManager.UpstreamTlsConfig(clusterName)
+	if err != nil {
+		logger.Errorf("[dubbo-go-pixiu] tcp proxy %v", err)
+		_ = conn.Close()
+		return
+	}
+	release, err := clusterManager.AcquireResource(ctx, clusterName, cluster.ResourceConnection)
+	if err != nil {
+		logger.Warnf("[dubbo-go-pixiu] tcp proxy %v", err)
+		_ = conn.Close()
+		return
+	}
+	defer release()
+	// the connection is counted as an in-flight request of endpoint until it is closed
+	endpoint.IncActiveRequests()
+	defer endpoint.DecActiveRequests()
+	upstream, err := dial(endpoint.Address.GetAddress(), p.config.ConnectTimeout, tlsConfig)
+	if err != nil {
+		logger.Warnf("[dubbo-go-pixiu] tcp proxy connect to %s failed: %v", endpoint.Address.GetAddress(), err)
+		connectFailTotal.Add(ctx, 1, attrs...)
+		clusterManager.ReportResult(clusterName, endpoint, cluster.ResultConnectFailure)
+		_ = conn.Close()
+		return
+	}
+	clusterManager.ReportResult(clusterName, endpoint, cluster.ResultSuccess)
+	logger.Debugf("[dubbo-go-pixiu] tcp proxy %s -> %s", conn.RemoteAddr(), endpoint.Address.GetAddress())
+	p.proxy(conn, upstream, attrs)
+}
+
+// pickCluster choose the cluster by weight if weighted clusters are configured
+func (p *TcpProxy) pickCluster() string {
+	if p.totalWeight <= 0 {
+		return p.config.Cluster
+	}
+	n := rand.Intn(p.totalWeight)
+	for _, wc := range p.config.WeightedClusters {
+		if wc.Weight <= 0 {
+			continue
+		}
+		if n < wc.Weight {
+			return wc.Name
+		}
+		n -= wc.Weight
+	}
+	return p.config.Cluster
+}
+
+// dial connect to upstream, the tls handshake is done within the connect timeout as well
+func dial(addr string, timeout time.Duration, tlsConfig *tls.Config) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	if tlsConfig == nil {
+		return dialer.Dial("tcp", addr)
+	}
+	return tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+}
+
+// proxy copy bytes in both directions until both sides closed or idle timeout
+func (p *TcpProxy) proxy(downstream, upstream net.Conn, attrs []attribute.KeyValue) {
+	ctx := context.Background()
+	connectionActive.Add(ctx, 1, attrs...)
+	defer connectionActive.Add(ctx, -1, attrs...)
+
+	c := &connection{
+		downstream:  downstream,
+		upstream:    upstream,
+		idleTimeout: p.config.IdleTimeout,
+		lastActive:  time.Now().UnixNano(),
+		done:        make(chan struct{}),
+	}
+	c.serve(func(received, sent int64) {
+		receivedBytesSize.Add(ctx, received, attrs...)
+		sentBytesSize.Add(ctx, sent, attrs...)
+	})
+}
+
+// serve copy in both directions, half close is passed to the other side
+func (c *connection) serve(report func(received, sent int64)) {
+	defer c.close()
+	if c.idleTimeout > 0 {
+		go c.watchIdle()
+	}
+
+	var (
+		wg             sync.WaitGroup
+		received, sent int64
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		received = c.copy(c.upstream, c.downstream)
+	}()
+	go func() {
+		defer wg.Done()
+		sent = c.copy(c.downstream, c.upstream)
+	}()
+	wg.Wait()
+	report(received, sent)
+}
+
+// copy copy from src to dst, return the bytes written
+func (c *connection) copy(dst, src net.Conn) int64 {
+	buf := make([]byte, copyBufferSize)
+	var written int64
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			atomic.StoreInt64(&c.lastActive, time.Now().UnixNano())
+			wn, werr := dst.Write(buf[:n])
+			written += int64(wn)
+			if werr != nil {
+				c.close()
+				return written
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				if cw, ok := dst.(closeWriter); ok {
+					_ = cw.CloseWrite()
+					return written
+				}
+			}
+			c.close()
+			return written
+		}
+	}
+}
+
+// watchIdle close the connection if no bytes in both directions within idle timeout
+func (c *connection) watchIdle() {
+	timer := time.NewTimer(c.idleTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-timer.C:
+			idle := time.Since(time.Unix(0, atomic.LoadInt64(&c.lastActive)))
+			if idle >= c.idleTimeout {
+				logger.Debugf("[dubbo-go-pixiu] tcp proxy connection %s idle timeout %s", c.downstream.RemoteAddr(), c.idleTimeout)
+				c.close()
+				return
+			}
+			timer.Reset(c.idleTimeout - idle)
+		}
+	}
+}
+
+func (c *connection) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		_ = c.downstream.Close()
+		_ = c.upstream.Close()
+	})
+}
@@ -0,0 +1,111 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcpproxy
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestPickCluster(t *testing.T) {
+	p := CreateTcpProxy(&model.TcpProxyConfig{Cluster: "default"})
+	assert.Equal(t, "default", p.pickCluster())
+
+	p = CreateTcpProxy(&model.TcpProxyConfig{
+		WeightedClusters: []*model.WeightedCluster{
+			{Name: "a", Weight: 3},
+			{Name: "b", Weight: 1},
+			{Name: "c", Weight: 0},
+		},
+	})
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		counts[p.pickCluster()]++
+	}
+	assert.Equal(t, 0, counts["c"])
+	assert.InDelta(t, 3000, counts["a"], 300)
+	assert.InDelta(t, 1000, counts["b"], 300)
+}
+
+func TestProxy(t *testing.T) {
+	assert.NoError(t, registerOtelMetric())
+
+	// upstream echo server
+	backend, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer backend.Close()
+	go func() {
+		for {
+			conn, err := backend.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	front, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer front.Close()
+
+	p := CreateTcpProxy(&model.TcpProxyConfig{
+		Cluster:        "test",
+		ConnectTimeout: time.Second,
+		IdleTimeout:    200 * time.Millisecond,
+	})
+	go func() {
+		conn, err := front.Accept()
+		if err != nil {
+			return
+		}
+		upstream, err := net.Dial("tcp", backend.Addr().String())
+		if err != nil {
+			_ = conn.Close()
+			return
+		}
+		p.proxy(conn, upstream, nil)
+	}()
+
+	client, err := net.Dial("tcp", front.Addr().String())
+	assert.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Write([]byte("ping"))
+	assert.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(client, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+
+	// connection is closed after idle timeout
+	_ = client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = client.Read(buf)
+	assert.Equal(t, io.EOF, err)
+}
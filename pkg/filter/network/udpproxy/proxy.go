@@ -0,0 +1,202 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udpproxy
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+const (
+	// maxDatagramSize the max size of a udp datagram
+	maxDatagramSize = 64 * 1024
+)
+
+var errTooManySessions = errors.New("too many udp sessions")
+
+type (
+	// UdpProxy network filter which forwards datagrams to upstream cluster
+	UdpProxy struct {
+		filter.EmptyNetworkFilter
+		config   *model.UdpProxyConfig
+		sessions map[string]*session
+		rwlock   sync.RWMutex
+	}
+
+	// session keep the upstream socket of one client, so that the datagrams of a client
+	// are always sent to the same endpoint and the replies can be routed back
+	session struct {
+		client     net.Addr
+		downstream net.PacketConn
+		upstream   *net.UDPConn
+		endpoint   *model.Endpoint
+		lastActive int64
+	}
+
+	// clientPolicy use the client address as hash key, so that hash load balancers keep affinity as well
+	clientPolicy string
+)
+
+// GenerateHash generate hash key of the client
+func (p clientPolicy) GenerateHash() string {
+	return string(p)
+}
+
+// CreateUdpProxy create udp proxy network filter
+func CreateUdpProxy(config *model.UdpProxyConfig) *UdpProxy {
+	return &UdpProxy{
+		config:   config,
+		sessions: make(map[string]*session),
+	}
+}
+
+// OnDatagram forward the datagram of client to the endpoint of its session
+func (p *UdpProxy) OnDatagram(conn net.PacketConn, addr net.Addr, data []byte) error {
+	s, err := p.getOrCreateSession(conn, addr)
+	if err != nil {
+		return err
+	}
+	s.active()
+	if _, err = s.upstream.Write(data); err != nil {
+		p.removeSession(s)
+		return perrors.Wrapf(err, "write datagram to %s", s.endpoint.Address.GetAddress())
+	}
+	return nil
+}
+
+func (p *UdpProxy) getOrCreateSession(conn net.PacketConn, addr net.Addr) (*session, error) {
+	key := addr.String()
+	p.rwlock.RLock()
+	s, ok := p.sessions[key]
+	p.rwlock.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	p.rwlock.Lock()
+	defer p.rwlock.Unlock()
+	if s, ok = p.sessions[key]; ok {
+		return s, nil
+	}
+	if p.config.MaxSessions > 0 && len(p.sessions) >= p.config.MaxSessions {
+		return nil, errTooManySessions
+	}
+
+	clusterManager := server.GetClusterManager()
+	endpoint := clusterManager.PickEndpoint(p.config.Cluster, clientPolicy(key))
+	if endpoint == nil {
+		return nil, perrors.Errorf("cluster %s not found endpoint", p.config.Cluster)
+	}
+	s, err := p.newSession(conn, addr, endpoint)
+	if err != nil {
+		clusterManager.ReportResult(p.config.Cluster, endpoint, cluster.ResultConnectFailure)
+		return nil, err
+	}
+	// the session is counted as an in-flight request of endpoint until it is removed
+	endpoint.IncActiveRequests()
+	p.sessions[key] = s
+	return s, nil
+}
+
+// newSession dial the endpoint and start to serve the replies of upstream
+func (p *UdpProxy) newSession(conn net.PacketConn, addr net.Addr, endpoint *model.Endpoint) (*session, error) {
+	raddr, err := net.ResolveUDPAddr("udp", endpoint.Address.GetAddress())
+	if err != nil {
+		return nil, perrors.Wrapf(err, "resolve endpoint %s", endpoint.Address.GetAddress())
+	}
+	upstream, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		return nil, perrors.Wrapf(err, "dial endpoint %s", endpoint.Address.GetAddress())
+	}
+
+	s := &session{
+		client:     addr,
+		downstream: conn,
+		upstream:   upstream,
+		endpoint:   endpoint,
+		lastActive: time.Now().UnixNano(),
+	}
+	logger.Debugf("[dubbo-go-pixiu] udp session %s created to endpoint %s", addr, endpoint.Address.GetAddress())
+	go p.serveUpstream(s)
+	return s, nil
+}
+
+// serveUpstream send the replies of upstream back to client until the session idle timeout
+func (p *UdpProxy) serveUpstream(s *session) {
+	defer p.removeSession(s)
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		_ = s.upstream.SetReadDeadline(time.Unix(0, atomic.LoadInt64(&s.lastActive)).Add(p.config.IdleTimeout))
+		n, err := s.upstream.Read(buf)
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				if s.idle() < p.config.IdleTimeout {
+					continue
+				}
+				logger.Debugf("[dubbo-go-pixiu] udp session %s idle timeout", s.client)
+				return
+			}
+			if !errors.Is(err, net.ErrClosed) {
+				// the endpoint refused the datagram, likely the port is not listening
+				server.GetClusterManager().ReportResult(p.config.Cluster, s.endpoint, cluster.ResultConnectFailure)
+				logger.Debugf("[dubbo-go-pixiu] udp session %s read upstream error: %v", s.client, err)
+			}
+			return
+		}
+		s.active()
+		if _, err = s.downstream.WriteTo(buf[:n], s.client); err != nil {
+			logger.Debugf("[dubbo-go-pixiu] udp session %s write downstream error: %v", s.client, err)
+		}
+	}
+}
+
+func (p *UdpProxy) removeSession(s *session) {
+	key := s.client.String()
+	p.rwlock.Lock()
+	if cur, ok := p.sessions[key]; ok && cur == s {
+		delete(p.sessions, key)
+		s.endpoint.DecActiveRequests()
+	}
+	p.rwlock.Unlock()
+	_ = s.upstream.Close()
+}
+
+func (s *session) active() {
+	atomic.StoreInt64(&s.lastActive, time.Now().UnixNano())
+}
+
+func (s *session) idle() time.Duration {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&s.lastActive)))
+}
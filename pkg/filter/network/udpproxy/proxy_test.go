@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udpproxy
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestUdpProxySession(t *testing.T) {
+	// upstream echo server
+	backend, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer backend.Close()
+	go func() {
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, addr, err := backend.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = backend.WriteTo(buf[:n], addr)
+		}
+	}()
+
+	// the listener socket of pixiu
+	front, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer front.Close()
+
+	client, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer client.Close()
+
+	proxy := CreateUdpProxy(&model.UdpProxyConfig{Cluster: "test", IdleTimeout: 200 * time.Millisecond})
+	backendAddr := backend.LocalAddr().(*net.UDPAddr)
+	endpoint := &model.Endpoint{
+		ID:      "backend",
+		Address: model.SocketAddress{Address: backendAddr.IP.String(), Port: backendAddr.Port},
+	}
+	s, err := proxy.newSession(front, client.LocalAddr(), endpoint)
+	assert.NoError(t, err)
+	proxy.sessions[client.LocalAddr().String()] = s
+
+	for _, msg := range []string{"ping", "pong"} {
+		assert.NoError(t, proxy.OnDatagram(front, client.LocalAddr(), []byte(msg)))
+		_ = client.SetReadDeadline(time.Now().Add(time.Second))
+		buf := make([]byte, 16)
+		n, addr, err := client.ReadFrom(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, string(buf[:n]))
+		assert.Equal(t, front.LocalAddr().String(), addr.String())
+	}
+
+	// session is removed after idle timeout
+	assert.Eventually(t, func() bool {
+		proxy.rwlock.RLock()
+		defer proxy.rwlock.RUnlock()
+		return len(proxy.sessions) == 0
+	}, 2*time.Second, 50*time.Millisecond)
+}
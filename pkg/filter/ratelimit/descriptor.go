@@ -0,0 +1,165 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package ratelimit contains the descriptors shared by the rate limit filters, a descriptor is
+// a list of key value entries generated from the request, the requests with the same descriptor
+// share the same quota.
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+)
+
+const (
+	// ActionPath the path of request
+	ActionPath = "path"
+	// ActionMethod the method of request
+	ActionMethod = "method"
+	// ActionHeader the value of header Name, like the api key
+	ActionHeader = "header"
+	// ActionRemoteAddress the address of the peer connection, which can not be forged by client
+	ActionRemoteAddress = "remote_address"
+	// ActionClientIP the client ip which honors X-Forwarded-For and X-Real-Ip, only use it behind a trusted proxy
+	ActionClientIP = "client_ip"
+	// ActionClaim the claim Name of the jwt verified by the jwt filter ahead, like sub, nested claim is separated by
+	// dot like user.id
+	ActionClaim = "claim"
+	// ActionConsumer the consumer authenticated by the api key filter ahead
+	ActionConsumer = "consumer"
+	// ActionConsumerTier the rate limit tier of the consumer authenticated by the api key filter ahead
+	ActionConsumerTier = "consumer_tier"
+	// ActionGeneric the constant Value, so that all the requests share one quota
+	ActionGeneric = "generic"
+)
+
+// the headers describe the quota of the request, see https://datatracker.ietf.org/doc/html/draft-polli-ratelimit-headers-03
+const (
+	HeaderLimit      = "X-RateLimit-Limit"
+	HeaderRemaining  = "X-RateLimit-Remaining"
+	HeaderReset      = "X-RateLimit-Reset"
+	HeaderRetryAfter = "Retry-After"
+)
+
+type (
+	// Action describe how to generate an entry of descriptor from request
+	Action struct {
+		Type  string `yaml:"type" json:"type" mapstructure:"type"`
+		Name  string `yaml:"name" json:"name,omitempty" mapstructure:"name"`    // header or claim name
+		Key   string `yaml:"key" json:"key,omitempty" mapstructure:"key"`       // descriptor key, default is the name or type
+		Value string `yaml:"value" json:"value,omitempty" mapstructure:"value"` // generic value
+	}
+
+	// Entry an entry of descriptor
+	Entry struct {
+		Key   string
+		Value string
+	}
+
+	// Descriptor the entries generated by actions in order
+	Descriptor []Entry
+)
+
+// Validate check the action is complete
+func (a *Action) Validate() error {
+	switch a.Type {
+	case ActionPath, ActionMethod, ActionRemoteAddress, ActionClientIP, ActionConsumer, ActionConsumerTier:
+		return nil
+	case ActionHeader, ActionClaim:
+		if a.Name == "" {
+			return fmt.Errorf("rate limit action %s requires name", a.Type)
+		}
+		return nil
+	case ActionGeneric:
+		if a.Value == "" {
+			return fmt.Errorf("rate limit action %s requires value", a.Type)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown rate limit action %s", a.Type)
+	}
+}
+
+func (a *Action) key() string {
+	if a.Key != "" {
+		return a.Key
+	}
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.Type
+}
+
+func (a *Action) value(ctx *http.HttpContext) string {
+	switch a.Type {
+	case ActionPath:
+		return ctx.GetUrl()
+	case ActionMethod:
+		return ctx.GetMethod()
+	case ActionHeader:
+		return ctx.GetHeader(a.Name)
+	case ActionRemoteAddress:
+		if host, _, err := net.SplitHostPort(ctx.Request.RemoteAddr); err == nil {
+			return host
+		}
+		return ctx.Request.RemoteAddr
+	case ActionClientIP:
+		return ctx.GetClientIP()
+	case ActionClaim:
+		v, _ := ctx.GetJwtClaim(a.Name)
+		return v
+	case ActionConsumer:
+		return ctx.GetConsumer()
+	case ActionConsumerTier:
+		return ctx.GetConsumerTier()
+	case ActionGeneric:
+		return a.Value
+	}
+	return ""
+}
+
+// Build generate the descriptor from request, return false if any action has no value,
+// e.g. the header is missing, then the limit does not apply to the request
+func Build(ctx *http.HttpContext, actions []Action) (Descriptor, bool) {
+	descriptor := make(Descriptor, 0, len(actions))
+	for i := range actions {
+		v := actions[i].value(ctx)
+		if v == "" {
+			return nil, false
+		}
+		descriptor = append(descriptor, Entry{Key: actions[i].key(), Value: v})
+	}
+	return descriptor, true
+}
+
+// String format the descriptor like k1=v1,k2=v2
+func (d Descriptor) String() string {
+	var sb strings.Builder
+	for i, e := range d {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(e.Key)
+		sb.WriteByte('=')
+		sb.WriteString(e.Value)
+	}
+	return sb.String()
+}
@@ -0,0 +1,51 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package global
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/filter/ratelimit"
+)
+
+type (
+	// Config describe the config of FilterFactory, the quota is shared by all the gateway replicas
+	// through the rate limit service speaking envoy.service.ratelimit.v3
+	Config struct {
+		// Domain the domain of rate limit service the descriptors belong to
+		Domain string `yaml:"domain" json:"domain" mapstructure:"domain"`
+		// Cluster the cluster of rate limit service, the endpoint is picked by its load balancer and connected with its tls config
+		Cluster string `yaml:"cluster" json:"cluster,omitempty" mapstructure:"cluster"`
+		// Address the address of rate limit service, used when cluster is empty
+		Address string `yaml:"address" json:"address,omitempty" mapstructure:"address"`
+		// Timeout of each call to the rate limit service, default 20ms
+		Timeout string `default:"20ms" yaml:"timeout" json:"timeout,omitempty" mapstructure:"timeout"`
+		// FailureModeDeny reject the request if the rate limit service fails, otherwise the request is allowed
+		FailureModeDeny bool         `yaml:"failure_mode_deny" json:"failure_mode_deny,omitempty" mapstructure:"failure_mode_deny"`
+		ErrMsg          string       `yaml:"err_msg" json:"err_msg,omitempty" mapstructure:"err_msg"`
+		Descriptors     []Descriptor `yaml:"descriptors" json:"descriptors" mapstructure:"descriptors"`
+	}
+
+	// Descriptor the descriptor generated by actions is sent to the rate limit service
+	Descriptor struct {
+		Match   Match              `yaml:"match" json:"match" mapstructure:"match"`
+		Actions []ratelimit.Action `yaml:"actions" json:"actions" mapstructure:"actions"`
+	}
+
+	// Match the descriptor applies to the requests whose path has the prefix, empty matches all
+	Match struct {
+		Prefix string `yaml:"prefix" json:"prefix" mapstructure:"prefix"`
+	}
+)
@@ -0,0 +1,271 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package global
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"math"
+	stdHttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	commonv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/util/stringutil"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/filter/ratelimit"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+const (
+	Kind = constant.HTTPGlobalRateLimitFilter
+)
+
+const (
+	defaultTimeout = 20 * time.Millisecond
+)
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance
+	FilterFactory struct {
+		cfg         *Config
+		timeout     time.Duration
+		errMsg      []byte
+		failMsg     []byte
+		connections *server.GrpcConnections
+	}
+
+	Filter struct {
+		cfg         *Config
+		timeout     time.Duration
+		errMsg      []byte
+		failMsg     []byte
+		connections *server.GrpcConnections
+	}
+)
+
+func (p Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}, connections: server.NewGrpcConnections()}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	if factory.cfg.Domain == "" {
+		return fmt.Errorf("rate limit domain is empty")
+	}
+	if factory.cfg.Cluster == "" && factory.cfg.Address == "" {
+		return fmt.Errorf("rate limit service requires cluster or address")
+	}
+	for i, d := range factory.cfg.Descriptors {
+		if len(d.Actions) == 0 {
+			return fmt.Errorf("descriptor %d has no actions", i)
+		}
+		for j := range d.Actions {
+			if err := d.Actions[j].Validate(); err != nil {
+				return fmt.Errorf("descriptor %d: %w", i, err)
+			}
+		}
+	}
+
+	factory.timeout = stringutil.ResolveTimeStr2Time(factory.cfg.Timeout, defaultTimeout)
+	if factory.cfg.ErrMsg == "" {
+		factory.cfg.ErrMsg = "too many requests"
+	}
+	factory.errMsg, _ = json.Marshal(http.ErrResponse{Message: factory.cfg.ErrMsg})
+	factory.failMsg, _ = json.Marshal(http.ErrResponse{Message: "rate limit service unavailable"})
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{
+		cfg:         factory.cfg,
+		timeout:     factory.timeout,
+		errMsg:      factory.errMsg,
+		failMsg:     factory.failMsg,
+		connections: factory.connections,
+	}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+// Decode ask the rate limit service whether the request is over limit, the request goes on
+// if no descriptor applies to it
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	descriptors := f.descriptors(ctx)
+	if len(descriptors) == 0 {
+		return filter.Continue
+	}
+
+	resp, err := f.shouldRateLimit(ctx, descriptors)
+	if err == nil && resp.GetOverallCode() == rlsv3.RateLimitResponse_UNKNOWN {
+		err = fmt.Errorf("unknown response code")
+	}
+	if err != nil {
+		logger.Warnf("[dubbo-go-pixiu] global rate limit domain %s error: %v", f.cfg.Domain, err)
+		if f.cfg.FailureModeDeny {
+			ctx.SendLocalReply(stdHttp.StatusInternalServerError, f.failMsg)
+			return filter.Stop
+		}
+		return filter.Continue
+	}
+
+	for _, h := range resp.GetResponseHeadersToAdd() {
+		ctx.AddHeader(h.GetKey(), h.GetValue())
+	}
+	addQuotaHeaders(ctx, resp)
+
+	if resp.GetOverallCode() == rlsv3.RateLimitResponse_OVER_LIMIT {
+		body := resp.GetRawBody()
+		if len(body) == 0 {
+			body = f.errMsg
+		}
+		ctx.SendLocalReply(stdHttp.StatusTooManyRequests, body)
+		return filter.Stop
+	}
+
+	for _, h := range resp.GetRequestHeadersToAdd() {
+		ctx.Request.Header.Set(h.GetKey(), h.GetValue())
+	}
+	return filter.Continue
+}
+
+func (f *Filter) descriptors(ctx *http.HttpContext) []*commonv3.RateLimitDescriptor {
+	path := ctx.GetUrl()
+	var descriptors []*commonv3.RateLimitDescriptor
+	for _, d := range f.cfg.Descriptors {
+		if !strings.HasPrefix(path, d.Match.Prefix) {
+			continue
+		}
+		descriptor, ok := ratelimit.Build(ctx, d.Actions)
+		if !ok {
+			continue
+		}
+		entries := make([]*commonv3.RateLimitDescriptor_Entry, 0, len(descriptor))
+		for _, e := range descriptor {
+			entries = append(entries, &commonv3.RateLimitDescriptor_Entry{Key: e.Key, Value: e.Value})
+		}
+		descriptors = append(descriptors, &commonv3.RateLimitDescriptor{Entries: entries})
+	}
+	return descriptors
+}
+
+func (f *Filter) shouldRateLimit(ctx *http.HttpContext, descriptors []*commonv3.RateLimitDescriptor) (*rlsv3.RateLimitResponse, error) {
+	address, tlsConfig, err := f.upstream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := f.connections.Get(address, tlsConfig, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	parent := ctx.Ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	callCtx, cancel := context.WithTimeout(parent, f.timeout)
+	defer cancel()
+	return rlsv3.NewRateLimitServiceClient(conn).ShouldRateLimit(callCtx, &rlsv3.RateLimitRequest{
+		Domain:      f.cfg.Domain,
+		Descriptors: descriptors,
+		HitsAddend:  1,
+	})
+}
+
+// upstream the address of rate limit service, and the tls config of its cluster which is nil for plaintext
+func (f *Filter) upstream(ctx *http.HttpContext) (string, *tls.Config, error) {
+	if f.cfg.Cluster == "" {
+		return f.cfg.Address, nil, nil
+	}
+	clusterManager := server.GetClusterManager()
+	endpoint := clusterManager.PickEndpoint(f.cfg.Cluster, ctx)
+	if endpoint == nil {
+		return "", nil, fmt.Errorf("no endpoint available in cluster %s", f.cfg.Cluster)
+	}
+	tlsConfig, err := clusterManager.UpstreamTlsConfig(f.cfg.Cluster)
+	if err != nil {
+		return "", nil, err
+	}
+	return endpoint.Address.GetAddress(), tlsConfig, nil
+}
+
+// addQuotaHeaders describe the descriptor closest to be exceeded by the rate limit headers
+func addQuotaHeaders(ctx *http.HttpContext, resp *rlsv3.RateLimitResponse) {
+	var reported *rlsv3.RateLimitResponse_DescriptorStatus
+	for _, s := range resp.GetStatuses() {
+		if s.GetCurrentLimit() == nil {
+			continue
+		}
+		if reported == nil || tighter(s, reported) {
+			reported = s
+		}
+	}
+	if reported == nil {
+		return
+	}
+
+	reset := seconds(reported.GetDurationUntilReset().AsDuration())
+	ctx.AddHeader(ratelimit.HeaderLimit, strconv.FormatUint(uint64(reported.GetCurrentLimit().GetRequestsPerUnit()), 10))
+	ctx.AddHeader(ratelimit.HeaderRemaining, strconv.FormatUint(uint64(reported.GetLimitRemaining()), 10))
+	ctx.AddHeader(ratelimit.HeaderReset, strconv.Itoa(reset))
+	if reported.GetCode() == rlsv3.RateLimitResponse_OVER_LIMIT {
+		ctx.AddHeader(ratelimit.HeaderRetryAfter, strconv.Itoa(int(math.Max(1, float64(reset)))))
+	}
+}
+
+// tighter whether a is closer to be exceeded than b, the over limit one with the longest wait comes first
+func tighter(a, b *rlsv3.RateLimitResponse_DescriptorStatus) bool {
+	aOver, bOver := a.GetCode() == rlsv3.RateLimitResponse_OVER_LIMIT, b.GetCode() == rlsv3.RateLimitResponse_OVER_LIMIT
+	if aOver != bOver {
+		return aOver
+	}
+	if aOver {
+		return a.GetDurationUntilReset().AsDuration() > b.GetDurationUntilReset().AsDuration()
+	}
+	return a.GetLimitRemaining() < b.GetLimitRemaining()
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,129 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package global
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/filter/ratelimit"
+	"github.com/apache/dubbo-go-pixiu/pkg/server"
+)
+
+func decode(f *Filter, path, key string) (*contexthttp.HttpContext, filter.FilterStatus) {
+	request, _ := http.NewRequest("GET", path, nil)
+	if key != "" {
+		request.Header.Set("X-Api-Key", key)
+	}
+	ctx := mock.GetMockHTTPContext(request)
+	return ctx, f.Decode(ctx)
+}
+
+func TestFilter(t *testing.T) {
+	svc := newMockService(2, time.Minute)
+	address, stop, err := svc.Start()
+	assert.NoError(t, err)
+	defer stop()
+
+	f := &Filter{
+		cfg: &Config{
+			Domain:  "pixiu",
+			Address: address,
+			Descriptors: []Descriptor{{
+				Match:   Match{Prefix: "/api"},
+				Actions: []ratelimit.Action{{Type: ratelimit.ActionGeneric, Key: "api", Value: "users"}, {Type: ratelimit.ActionHeader, Name: "X-Api-Key", Key: "key"}},
+			}},
+		},
+		timeout:     time.Second,
+		errMsg:      []byte("too many requests"),
+		connections: server.NewGrpcConnections(),
+	}
+
+	ctx, status := decode(f, "/api/users", "k1")
+	assert.Equal(t, filter.Continue, status)
+	assert.Equal(t, "2", ctx.Writer.Header().Get(ratelimit.HeaderLimit))
+	assert.Equal(t, "1", ctx.Writer.Header().Get(ratelimit.HeaderRemaining))
+	assert.Equal(t, "60", ctx.Writer.Header().Get(ratelimit.HeaderReset))
+
+	_, status = decode(f, "/api/users", "k1")
+	assert.Equal(t, filter.Continue, status)
+	ctx, status = decode(f, "/api/users", "k1")
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, http.StatusTooManyRequests, ctx.GetStatusCode())
+	assert.Equal(t, "too many requests", string(ctx.TargetResp.Data))
+	assert.Equal(t, "0", ctx.Writer.Header().Get(ratelimit.HeaderRemaining))
+	assert.Equal(t, "60", ctx.Writer.Header().Get(ratelimit.HeaderRetryAfter))
+
+	_, status = decode(f, "/api/users", "k2")
+	assert.Equal(t, filter.Continue, status)
+
+	// no descriptor applies, the service is not called
+	calls := svc.Calls()
+	_, status = decode(f, "/api/users", "")
+	assert.Equal(t, filter.Continue, status)
+	_, status = decode(f, "/health", "k1")
+	assert.Equal(t, filter.Continue, status)
+	assert.Equal(t, calls, svc.Calls())
+}
+
+func TestFailureMode(t *testing.T) {
+	svc := newMockService(1, time.Minute)
+	address, stop, err := svc.Start()
+	assert.NoError(t, err)
+	stop()
+
+	f := &Filter{
+		cfg: &Config{
+			Domain:      "pixiu",
+			Address:     address,
+			Descriptors: []Descriptor{{Actions: []ratelimit.Action{{Type: ratelimit.ActionHeader, Name: "X-Api-Key"}}}},
+		},
+		timeout:     200 * time.Millisecond,
+		failMsg:     []byte("rate limit service unavailable"),
+		connections: server.NewGrpcConnections(),
+	}
+	_, status := decode(f, "/api", "k1")
+	assert.Equal(t, filter.Continue, status)
+
+	f.cfg.FailureModeDeny = true
+	ctx, status := decode(f, "/api", "k1")
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, http.StatusInternalServerError, ctx.GetStatusCode())
+}
+
+func TestApply(t *testing.T) {
+	p := &Plugin{}
+	factory, _ := p.CreateFilterFactory()
+	*factory.Config().(*Config) = Config{Address: "127.0.0.1:8081"}
+	assert.Error(t, factory.Apply())
+
+	*factory.Config().(*Config) = Config{Domain: "pixiu"}
+	assert.Error(t, factory.Apply())
+
+	*factory.Config().(*Config) = Config{Domain: "pixiu", Address: "127.0.0.1:8081", Descriptors: []Descriptor{{}}}
+	assert.Error(t, factory.Apply())
+}
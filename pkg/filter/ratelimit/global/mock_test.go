@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package global
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+import (
+	commonv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+
+	"google.golang.org/grpc"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// mockService the in-process stand-in of rate limit service, each distinct domain and descriptor
+// is allowed Limit hits per Unit in a fixed window
+type mockService struct {
+	Limit uint32
+	Unit  time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*mockWindow
+	calls   int
+}
+
+type mockWindow struct {
+	start time.Time
+	hits  uint32
+}
+
+// newMockService create the stand-in allowing limit hits per unit, unit is time.Second or time.Minute
+func newMockService(limit uint32, unit time.Duration) *mockService {
+	return &mockService{Limit: limit, Unit: unit, windows: map[string]*mockWindow{}}
+}
+
+// Start serve the stand-in on a random local port until stop is called
+func (s *mockService) Start() (address string, stop func(), err error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+	srv := grpc.NewServer()
+	rlsv3.RegisterRateLimitServiceServer(srv, s)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	return lis.Addr().String(), srv.Stop, nil
+}
+
+// Calls the count of ShouldRateLimit received
+func (s *mockService) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// ShouldRateLimit the request is over limit if any descriptor is
+func (s *mockService) ShouldRateLimit(_ context.Context, req *rlsv3.RateLimitRequest) (*rlsv3.RateLimitResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+
+	now := time.Now()
+	hits := req.GetHitsAddend()
+	if hits == 0 {
+		hits = 1
+	}
+
+	resp := &rlsv3.RateLimitResponse{OverallCode: rlsv3.RateLimitResponse_OK}
+	for _, d := range req.GetDescriptors() {
+		key := req.GetDomain() + "|" + descriptorKey(d)
+		w, ok := s.windows[key]
+		if !ok || now.Sub(w.start) >= s.Unit {
+			w = &mockWindow{start: now}
+			s.windows[key] = w
+		}
+		w.hits += hits
+
+		status := &rlsv3.RateLimitResponse_DescriptorStatus{
+			Code:               rlsv3.RateLimitResponse_OK,
+			CurrentLimit:       &rlsv3.RateLimitResponse_RateLimit{RequestsPerUnit: s.Limit, Unit: s.unit()},
+			DurationUntilReset: durationpb.New(w.start.Add(s.Unit).Sub(now)),
+		}
+		if w.hits > s.Limit {
+			status.Code = rlsv3.RateLimitResponse_OVER_LIMIT
+			resp.OverallCode = rlsv3.RateLimitResponse_OVER_LIMIT
+		} else {
+			status.LimitRemaining = s.Limit - w.hits
+		}
+		resp.Statuses = append(resp.Statuses, status)
+	}
+	return resp, nil
+}
+
+func (s *mockService) unit() rlsv3.RateLimitResponse_RateLimit_Unit {
+	switch s.Unit {
+	case time.Second:
+		return rlsv3.RateLimitResponse_RateLimit_SECOND
+	case time.Minute:
+		return rlsv3.RateLimitResponse_RateLimit_MINUTE
+	case time.Hour:
+		return rlsv3.RateLimitResponse_RateLimit_HOUR
+	default:
+		return rlsv3.RateLimitResponse_RateLimit_UNKNOWN
+	}
+}
+
+func descriptorKey(d *commonv3.RateLimitDescriptor) string {
+	key := ""
+	for i, e := range d.GetEntries() {
+		if i > 0 {
+			key += ","
+		}
+		key += e.GetKey() + "=" + e.GetValue()
+	}
+	return key
+}
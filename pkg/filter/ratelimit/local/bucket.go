@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package local
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// bucket the token bucket refilled continuously at the rate of TokensPerFill per FillInterval,
+// the capacity is MaxTokens which allows the burst
+type bucket struct {
+	mu       sync.Mutex
+	capacity float64
+	rate     float64 // tokens per second
+	tokens   float64
+	last     time.Time
+}
+
+// quota the state of bucket after taking
+type quota struct {
+	allowed   bool
+	limit     int
+	remaining int
+	// reset the duration until the bucket is full
+	reset time.Duration
+	// retryAfter the duration until a token is available, zero if allowed
+	retryAfter time.Duration
+}
+
+func newBucket(capacity int, rate float64, now time.Time) *bucket {
+	return &bucket{capacity: float64(capacity), rate: rate, tokens: float64(capacity), last: now}
+}
+
+// take consume a token if available
+func (b *bucket) take(now time.Time) quota {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+
+	q := quota{limit: int(b.capacity)}
+	if b.tokens >= 1 {
+		b.tokens--
+		q.allowed = true
+	} else {
+		q.retryAfter = b.duration(1 - b.tokens)
+	}
+	q.remaining = int(b.tokens)
+	q.reset = b.duration(b.capacity - b.tokens)
+	return q
+}
+
+func (b *bucket) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / b.rate * float64(time.Second))
+}
@@ -0,0 +1,52 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package local
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/filter/ratelimit"
+)
+
+type (
+	// Config describe the config of FilterFactory, put the filter after the jwt filter if any limit uses the claim
+	Config struct {
+		ErrMsg string `yaml:"err_msg" json:"err_msg" mapstructure:"err_msg"`
+		// MaxKeys the max count of buckets kept, the least recently used bucket is evicted, default 10000
+		MaxKeys int     `yaml:"max_keys" json:"max_keys" mapstructure:"max_keys"`
+		Limits  []Limit `yaml:"limits" json:"limits" mapstructure:"limits"`
+	}
+
+	// Limit each distinct descriptor generated by the actions owns a token bucket
+	Limit struct {
+		Match       Match              `yaml:"match" json:"match" mapstructure:"match"`
+		Actions     []ratelimit.Action `yaml:"actions" json:"actions" mapstructure:"actions"`
+		TokenBucket TokenBucket        `yaml:"token_bucket" json:"token_bucket" mapstructure:"token_bucket"`
+	}
+
+	// Match the limit applies to the requests whose path has the prefix, and whose consumer is of the tier
+	// if Tier is not empty, so that each tier of consumers has its own limit
+	Match struct {
+		Prefix string `yaml:"prefix" json:"prefix" mapstructure:"prefix"`
+		Tier   string `yaml:"tier" json:"tier,omitempty" mapstructure:"tier"`
+	}
+
+	// TokenBucket MaxTokens is the burst, TokensPerFill tokens are added every FillInterval
+	TokenBucket struct {
+		MaxTokens     int    `yaml:"max_tokens" json:"max_tokens" mapstructure:"max_tokens"`
+		TokensPerFill int    `default:"1" yaml:"tokens_per_fill" json:"tokens_per_fill" mapstructure:"tokens_per_fill"`
+		FillInterval  string `default:"1s" yaml:"fill_interval" json:"fill_interval" mapstructure:"fill_interval"`
+	}
+)
@@ -0,0 +1,197 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	stdHttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	lru "github.com/hashicorp/golang-lru"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/constant"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/util/stringutil"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/filter/ratelimit"
+)
+
+const (
+	Kind = constant.HTTPLocalRateLimitFilter
+)
+
+const defaultMaxKeys = 10000
+
+func init() {
+	filter.RegisterHttpFilter(&Plugin{})
+}
+
+type (
+	// Plugin is http filter plugin.
+	Plugin struct {
+	}
+
+	// FilterFactory is http filter instance, the buckets are shared by all the requests
+	FilterFactory struct {
+		cfg     *Config
+		limits  []limit
+		buckets *lru.Cache
+		errMsg  []byte
+	}
+
+	Filter struct {
+		limits  []limit
+		buckets *lru.Cache
+		errMsg  []byte
+	}
+
+	limit struct {
+		Limit
+		// rate tokens per second
+		rate float64
+	}
+)
+
+func (p Plugin) Kind() string {
+	return Kind
+}
+
+func (p *Plugin) CreateFilterFactory() (filter.HttpFilterFactory, error) {
+	return &FilterFactory{cfg: &Config{}}, nil
+}
+
+func (factory *FilterFactory) Config() interface{} {
+	return factory.cfg
+}
+
+func (factory *FilterFactory) Apply() error {
+	limits := make([]limit, 0, len(factory.cfg.Limits))
+	for i, l := range factory.cfg.Limits {
+		if len(l.Actions) == 0 {
+			return fmt.Errorf("limit %d has no actions", i)
+		}
+		for j := range l.Actions {
+			if err := l.Actions[j].Validate(); err != nil {
+				return fmt.Errorf("limit %d: %w", i, err)
+			}
+		}
+		if l.TokenBucket.MaxTokens <= 0 {
+			return fmt.Errorf("limit %d max_tokens must be positive", i)
+		}
+		if l.TokenBucket.TokensPerFill <= 0 {
+			l.TokenBucket.TokensPerFill = 1
+		}
+		interval := stringutil.ResolveTimeStr2Time(l.TokenBucket.FillInterval, time.Second)
+		limits = append(limits, limit{Limit: l, rate: float64(l.TokenBucket.TokensPerFill) / interval.Seconds()})
+	}
+
+	maxKeys := factory.cfg.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = defaultMaxKeys
+	}
+	buckets, err := lru.New(maxKeys)
+	if err != nil {
+		return err
+	}
+
+	if factory.cfg.ErrMsg == "" {
+		factory.cfg.ErrMsg = "too many requests"
+	}
+	errMsg, _ := json.Marshal(http.ErrResponse{Message: factory.cfg.ErrMsg})
+
+	factory.limits, factory.buckets, factory.errMsg = limits, buckets, errMsg
+	return nil
+}
+
+func (factory *FilterFactory) PrepareFilterChain(ctx *http.HttpContext, chain filter.FilterChain) error {
+	f := &Filter{limits: factory.limits, buckets: factory.buckets, errMsg: factory.errMsg}
+	chain.AppendDecodeFilters(f)
+	return nil
+}
+
+// Decode take a token from the bucket of each matched limit, the request is rejected if any bucket is empty,
+// the rate limit headers describe the limit closest to be exceeded
+func (f *Filter) Decode(ctx *http.HttpContext) filter.FilterStatus {
+	now := time.Now()
+	path := ctx.GetUrl()
+
+	var reported *quota
+	for i := range f.limits {
+		l := &f.limits[i]
+		if !strings.HasPrefix(path, l.Match.Prefix) || (l.Match.Tier != "" && l.Match.Tier != ctx.GetConsumerTier()) {
+			continue
+		}
+		descriptor, ok := ratelimit.Build(ctx, l.Actions)
+		if !ok {
+			continue
+		}
+
+		q := f.bucket(i, l, descriptor, now).take(now)
+		if reported == nil || tighter(q, *reported) {
+			reported = &q
+		}
+	}
+
+	if reported == nil {
+		return filter.Continue
+	}
+
+	ctx.AddHeader(ratelimit.HeaderLimit, strconv.Itoa(reported.limit))
+	ctx.AddHeader(ratelimit.HeaderRemaining, strconv.Itoa(reported.remaining))
+	ctx.AddHeader(ratelimit.HeaderReset, strconv.Itoa(seconds(reported.reset)))
+	if !reported.allowed {
+		ctx.AddHeader(ratelimit.HeaderRetryAfter, strconv.Itoa(int(math.Max(1, float64(seconds(reported.retryAfter))))))
+		ctx.SendLocalReply(stdHttp.StatusTooManyRequests, f.errMsg)
+		return filter.Stop
+	}
+	return filter.Continue
+}
+
+func (f *Filter) bucket(index int, l *limit, descriptor ratelimit.Descriptor, now time.Time) *bucket {
+	key := strconv.Itoa(index) + "|" + descriptor.String()
+	if b, ok := f.buckets.Get(key); ok {
+		return b.(*bucket)
+	}
+	b := newBucket(l.TokenBucket.MaxTokens, l.rate, now)
+	if previous, ok, _ := f.buckets.PeekOrAdd(key, b); ok {
+		return previous.(*bucket)
+	}
+	return b
+}
+
+// tighter whether a is closer to be exceeded than b, the rejected one with the longest wait comes first
+func tighter(a, b quota) bool {
+	if a.allowed != b.allowed {
+		return !a.allowed
+	}
+	if !a.allowed {
+		return a.retryAfter > b.retryAfter
+	}
+	return a.remaining < b.remaining
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
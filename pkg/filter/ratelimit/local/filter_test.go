@@ -0,0 +1,224 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package local
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+import (
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/common/extension/filter"
+	contexthttp "github.com/apache/dubbo-go-pixiu/pkg/context/http"
+	"github.com/apache/dubbo-go-pixiu/pkg/context/mock"
+	"github.com/apache/dubbo-go-pixiu/pkg/filter/ratelimit"
+)
+
+func newBuckets(t *testing.T, size int) *lru.Cache {
+	buckets, err := lru.New(size)
+	assert.NoError(t, err)
+	return buckets
+}
+
+func TestBucket(t *testing.T) {
+	now := time.Now()
+	// burst 2, one token every 500ms
+	b := newBucket(2, 2, now)
+
+	q := b.take(now)
+	assert.True(t, q.allowed)
+	assert.Equal(t, 1, q.remaining)
+	assert.Equal(t, 500*time.Millisecond, q.reset)
+
+	assert.True(t, b.take(now).allowed)
+	q = b.take(now)
+	assert.False(t, q.allowed)
+	assert.Equal(t, 0, q.remaining)
+	assert.Equal(t, 500*time.Millisecond, q.retryAfter)
+	assert.Equal(t, time.Second, q.reset)
+
+	assert.False(t, b.take(now.Add(400*time.Millisecond)).allowed)
+	assert.True(t, b.take(now.Add(500*time.Millisecond)).allowed)
+
+	// refill never exceeds the burst
+	q = b.take(now.Add(time.Hour))
+	assert.True(t, q.allowed)
+	assert.Equal(t, 1, q.remaining)
+}
+
+func TestFilter(t *testing.T) {
+	f := &Filter{
+		limits: []limit{
+			{
+				Limit: Limit{
+					Match:       Match{Prefix: "/api"},
+					Actions:     []ratelimit.Action{{Type: ratelimit.ActionHeader, Name: "X-Api-Key"}},
+					TokenBucket: TokenBucket{MaxTokens: 2},
+				},
+				rate: 1.0 / 60,
+			},
+			{
+				Limit: Limit{
+					Actions:     []ratelimit.Action{{Type: ratelimit.ActionClaim, Name: "user.sub"}, {Type: ratelimit.ActionMethod}},
+					TokenBucket: TokenBucket{MaxTokens: 1},
+				},
+				rate: 1.0 / 3600,
+			},
+		},
+		buckets: newBuckets(t, 10),
+	}
+
+	decode := func(path, key string, claims map[string]interface{}) (*contexthttp.HttpContext, filter.FilterStatus) {
+		request, _ := http.NewRequest("GET", path, nil)
+		if key != "" {
+			request.Header.Set("X-Api-Key", key)
+		}
+		ctx := mock.GetMockHTTPContext(request)
+		ctx.SetJwtClaims(claims)
+		return ctx, f.Decode(ctx)
+	}
+
+	ctx, status := decode("/api/users", "k1", nil)
+	assert.Equal(t, filter.Continue, status)
+	assert.Equal(t, "2", ctx.Writer.Header().Get(ratelimit.HeaderLimit))
+	assert.Equal(t, "1", ctx.Writer.Header().Get(ratelimit.HeaderRemaining))
+	assert.Equal(t, "60", ctx.Writer.Header().Get(ratelimit.HeaderReset))
+
+	_, status = decode("/api/users", "k1", nil)
+	assert.Equal(t, filter.Continue, status)
+	ctx, status = decode("/api/users", "k1", nil)
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, http.StatusTooManyRequests, ctx.GetStatusCode())
+	assert.Equal(t, "0", ctx.Writer.Header().Get(ratelimit.HeaderRemaining))
+	assert.Equal(t, "60", ctx.Writer.Header().Get(ratelimit.HeaderRetryAfter))
+
+	// another key owns another bucket
+	_, status = decode("/api/users", "k2", nil)
+	assert.Equal(t, filter.Continue, status)
+
+	// no limit applies without the header or out of the prefix
+	ctx, status = decode("/api/users", "", nil)
+	assert.Equal(t, filter.Continue, status)
+	assert.Empty(t, ctx.Writer.Header().Get(ratelimit.HeaderLimit))
+	_, status = decode("/health", "k1", nil)
+	assert.Equal(t, filter.Continue, status)
+
+	// the tightest limit is reported
+	ctx, status = decode("/api/users", "k3", map[string]interface{}{"user": map[string]interface{}{"sub": "alice"}})
+	assert.Equal(t, filter.Continue, status)
+	assert.Equal(t, "1", ctx.Writer.Header().Get(ratelimit.HeaderLimit))
+	assert.Equal(t, "0", ctx.Writer.Header().Get(ratelimit.HeaderRemaining))
+	ctx, status = decode("/other", "", map[string]interface{}{"user": map[string]interface{}{"sub": "alice"}})
+	assert.Equal(t, filter.Stop, status)
+	assert.Equal(t, "3600", ctx.Writer.Header().Get(ratelimit.HeaderRetryAfter))
+	_, status = decode("/other", "", map[string]interface{}{"user": map[string]interface{}{"sub": "bob"}})
+	assert.Equal(t, filter.Continue, status)
+}
+
+func TestMaxKeys(t *testing.T) {
+	f := &Filter{
+		limits: []limit{{
+			Limit: Limit{
+				Actions:     []ratelimit.Action{{Type: ratelimit.ActionRemoteAddress}},
+				TokenBucket: TokenBucket{MaxTokens: 1},
+			},
+			rate: 1.0 / 3600,
+		}},
+		buckets: newBuckets(t, 2),
+	}
+
+	for _, addr := range []string{"10.0.0.1:1234", "10.0.0.2:1234", "10.0.0.3:1234"} {
+		request, _ := http.NewRequest("GET", "/api", nil)
+		request.RemoteAddr = addr
+		assert.Equal(t, filter.Continue, f.Decode(mock.GetMockHTTPContext(request)))
+	}
+	assert.Equal(t, 2, f.buckets.Len())
+	assert.False(t, f.buckets.Contains("0|remote_address=10.0.0.1"))
+}
+
+func TestApply(t *testing.T) {
+	p := &Plugin{}
+	factory, _ := p.CreateFilterFactory()
+	*factory.Config().(*Config) = Config{Limits: []Limit{{
+		Actions:     []ratelimit.Action{{Type: ratelimit.ActionMethod}},
+		TokenBucket: TokenBucket{MaxTokens: 1, FillInterval: "1m"},
+	}}}
+	assert.NoError(t, factory.Apply())
+	f := factory.(*FilterFactory)
+	// one token per fill by default
+	assert.Equal(t, 1.0/60, f.limits[0].rate)
+	assert.Equal(t, `{"message":"too many requests"}`, string(f.errMsg))
+	assert.NotNil(t, f.buckets)
+
+	*factory.Config().(*Config) = Config{Limits: []Limit{{
+		Actions:     []ratelimit.Action{{Type: ratelimit.ActionHeader}},
+		TokenBucket: TokenBucket{MaxTokens: 1},
+	}}}
+	assert.Error(t, factory.Apply())
+
+	*factory.Config().(*Config) = Config{Limits: []Limit{{
+		Actions: []ratelimit.Action{{Type: ratelimit.ActionGeneric, Value: "all"}},
+	}}}
+	assert.Error(t, factory.Apply())
+}
+
+func TestConsumerTier(t *testing.T) {
+	f := &Filter{
+		limits: []limit{
+			{
+				Limit: Limit{
+					Match:       Match{Tier: "gold"},
+					Actions:     []ratelimit.Action{{Type: ratelimit.ActionConsumer}},
+					TokenBucket: TokenBucket{MaxTokens: 2},
+				},
+				rate: 1.0 / 3600,
+			},
+			{
+				Limit: Limit{
+					Match:       Match{Tier: "free"},
+					Actions:     []ratelimit.Action{{Type: ratelimit.ActionConsumer}},
+					TokenBucket: TokenBucket{MaxTokens: 1},
+				},
+				rate: 1.0 / 3600,
+			},
+		},
+		buckets: newBuckets(t, 10),
+	}
+
+	decode := func(consumer, tier string) filter.FilterStatus {
+		request, _ := http.NewRequest("GET", "/api", nil)
+		ctx := mock.GetMockHTTPContext(request)
+		ctx.SetConsumer(consumer, tier)
+		return f.Decode(ctx)
+	}
+
+	assert.Equal(t, filter.Continue, decode("partner-a", "gold"))
+	assert.Equal(t, filter.Continue, decode("partner-a", "gold"))
+	assert.Equal(t, filter.Stop, decode("partner-a", "gold"))
+
+	assert.Equal(t, filter.Continue, decode("partner-b", "free"))
+	assert.Equal(t, filter.Stop, decode("partner-b", "free"))
+
+	// anonymous requests are not limited by the tiers
+	assert.Equal(t, filter.Continue, decode("", ""))
+}
@@ -19,6 +19,7 @@ package filterchain
 
 import (
 	"context"
+	"net"
 	"net/http"
 )
 
@@ -82,6 +83,39 @@ func (fc *NetworkFilterChain) OnTripleData(ctx context.Context, methodName strin
 	return nil, errors.Errorf("filterChain don't have network filter")
 }
 
+// OnDatagram handle datagram received from udp listener
+func (fc *NetworkFilterChain) OnDatagram(conn net.PacketConn, addr net.Addr, data []byte) error {
+	// todo: only one filter will exist for now, needs change when more than one
+	for _, filter := range fc.filtersArray {
+		return filter.OnDatagram(conn, addr, data)
+	}
+	return errors.Errorf("filterChain don't have network filter")
+}
+
+// SupportConnection check whether the filter chain handles raw tcp connection
+func (fc *NetworkFilterChain) SupportConnection() bool {
+	// todo: only one filter will exist for now, needs change when more than one
+	for _, f := range fc.filtersArray {
+		_, ok := f.(filter.ConnectionNetworkFilter)
+		return ok
+	}
+	return false
+}
+
+// OnConnection handle raw tcp connection accepted by tcp listener
+func (fc *NetworkFilterChain) OnConnection(conn net.Conn) error {
+	// todo: only one filter will exist for now, needs change when more than one
+	for _, f := range fc.filtersArray {
+		cf, ok := f.(filter.ConnectionNetworkFilter)
+		if !ok {
+			return errors.Errorf("network filter %T can't handle raw connection", f)
+		}
+		cf.OnConnection(conn)
+		return nil
+	}
+	return errors.Errorf("filterChain don't have network filter")
+}
+
 // CreateNetworkFilterChain create network filter chain
 func CreateNetworkFilterChain(config model.FilterChain) *NetworkFilterChain {
 	var filters []filter.NetworkFilter
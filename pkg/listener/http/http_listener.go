@@ -19,6 +19,7 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net/http"
@@ -33,6 +34,7 @@ import (
 )
 
 import (
+	pixiutls "github.com/apache/dubbo-go-pixiu/pkg/common/tls"
 	"github.com/apache/dubbo-go-pixiu/pkg/config"
 	"github.com/apache/dubbo-go-pixiu/pkg/filterchain"
 	"github.com/apache/dubbo-go-pixiu/pkg/listener"
@@ -49,7 +51,8 @@ type (
 	// ListenerService the facade of a listener
 	HttpListenerService struct {
 		listener.BaseListenerService
-		srv *http.Server
+		srv         *http.Server
+		certManager *pixiutls.CertificateManager
 	}
 
 	// DefaultHttpListener
@@ -75,6 +78,9 @@ func (ls *HttpListenerService) Start() error {
 	case model.ProtocolTypeHTTP:
 		ls.httpListener()
 	case model.ProtocolTypeHTTPS:
+		if ls.Config.Tls != nil {
+			return ls.staticTlsListener()
+		}
 		ls.httpsListener()
 	default:
 		return errors.New(fmt.Sprintf("unsupported protocol start: %d", ls.Config.Protocol))
@@ -83,6 +89,9 @@ func (ls *HttpListenerService) Start() error {
 }
 
 func (ls *HttpListenerService) Close() error {
+	if ls.certManager != nil {
+		ls.certManager.Close()
+	}
 	return ls.srv.Close()
 }
 
@@ -94,6 +103,9 @@ func (ls *HttpListenerService) ShutDown(wg interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer func() {
 		cancel()
+		if ls.certManager != nil {
+			ls.certManager.Close()
+		}
 		wg.(*sync.WaitGroup).Done()
 	}()
 	return ls.srv.Shutdown(ctx)
@@ -107,35 +119,53 @@ func (ls *HttpListenerService) Refresh(c model.Listener) error {
 }
 
 func (ls *HttpListenerService) httpsListener() {
-	hl := createDefaultHttpWorker(ls)
-
-	// user customize http config
-	var hc *model.HttpConfig
-	hc = model.MapInStruct(ls.Config)
-
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", hl.ServeHTTP)
 	m := &autocert.Manager{
 		Cache:      autocert.DirCache(ls.Config.Address.SocketAddress.CertsDir),
 		Prompt:     autocert.AcceptTOS,
 		HostPolicy: autocert.HostWhitelist(ls.Config.Address.SocketAddress.Domains...),
 	}
-	ls.srv = &http.Server{
-		Addr:           ":https",
-		Handler:        mux,
-		ReadTimeout:    resolveStr2Time(hc.ReadTimeoutStr, 20*time.Second),
-		WriteTimeout:   resolveStr2Time(hc.WriteTimeoutStr, 20*time.Second),
-		IdleTimeout:    resolveStr2Time(hc.IdleTimeoutStr, 20*time.Second),
-		MaxHeaderBytes: resolveInt2IntProp(hc.MaxHeaderBytes, 1<<20),
-		TLSConfig:      m.TLSConfig(),
-	}
+	ls.srv = ls.newServer(":https", m.TLSConfig())
 	autoLs := autocert.NewListener(ls.Config.Address.SocketAddress.Domains...)
 	logger.Infof("[dubbo-go-server] httpsListener start at : %s", ls.srv.Addr)
 	err := ls.srv.Serve(autoLs)
 	logger.Info("[dubbo-go-server] httpsListener result:", err)
 }
 
+// staticTlsListener serve https with the certificates configured in listener, the certificate is chosen by SNI
+func (ls *HttpListenerService) staticTlsListener() error {
+	certManager, err := pixiutls.NewCertificateManager(ls.Config.Tls)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := certManager.TLSConfig()
+	if err != nil {
+		certManager.Close()
+		return err
+	}
+	ls.certManager = certManager
+
+	sa := ls.Config.Address.SocketAddress
+	ls.srv = ls.newServer(resolveAddress(sa.Address+":"+strconv.Itoa(sa.Port)), tlsConfig)
+
+	logger.Infof("[dubbo-go-server] httpsListener start at : %s", ls.srv.Addr)
+	// certificates are provided by TLSConfig.GetCertificate
+	err = ls.srv.ListenAndServeTLS("", "")
+	logger.Info("[dubbo-go-server] httpsListener result:", err)
+	return nil
+}
+
 func (ls *HttpListenerService) httpListener() {
+	sa := ls.Config.Address.SocketAddress
+	ls.srv = ls.newServer(resolveAddress(sa.Address+":"+strconv.Itoa(sa.Port)), nil)
+
+	logger.Infof("[dubbo-go-server] httpListener start at : %s", ls.srv.Addr)
+
+	log.Println(ls.srv.ListenAndServe())
+}
+
+// newServer create the http server serving the filter chain of listener with the user customized http config,
+// tlsConfig is nil for plain http
+func (ls *HttpListenerService) newServer(addr string, tlsConfig *tls.Config) *http.Server {
 	hl := createDefaultHttpWorker(ls)
 
 	// user customize http config
@@ -145,19 +175,15 @@ func (ls *HttpListenerService) httpListener() {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", hl.ServeHTTP)
 
-	sa := ls.Config.Address.SocketAddress
-	ls.srv = &http.Server{
-		Addr:           resolveAddress(sa.Address + ":" + strconv.Itoa(sa.Port)),
+	return &http.Server{
+		Addr:           addr,
 		Handler:        mux,
 		ReadTimeout:    resolveStr2Time(hc.ReadTimeoutStr, 20*time.Second),
 		WriteTimeout:   resolveStr2Time(hc.WriteTimeoutStr, 20*time.Second),
 		IdleTimeout:    resolveStr2Time(hc.IdleTimeoutStr, 20*time.Second),
 		MaxHeaderBytes: resolveInt2IntProp(hc.MaxHeaderBytes, 1<<20),
+		TLSConfig:      tlsConfig,
 	}
-
-	logger.Infof("[dubbo-go-server] httpListener start at : %s", ls.srv.Addr)
-
-	log.Println(ls.srv.ListenAndServe())
 }
 
 // createDefaultHttpWorker create http listener
@@ -18,6 +18,7 @@
 package http2
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
 	"strconv"
@@ -31,6 +32,7 @@ import (
 )
 
 import (
+	pixiutls "github.com/apache/dubbo-go-pixiu/pkg/common/tls"
 	"github.com/apache/dubbo-go-pixiu/pkg/config"
 	"github.com/apache/dubbo-go-pixiu/pkg/filterchain"
 	"github.com/apache/dubbo-go-pixiu/pkg/listener"
@@ -49,6 +51,7 @@ type (
 		listener        net.Listener
 		server          *http.Server
 		gShutdownConfig *listener.ListenerGracefulShutdownConfig
+		certManager     *pixiutls.CertificateManager
 	}
 )
 
@@ -103,6 +106,14 @@ func (ls *Http2ListenerService) Start() error {
 	}
 	ls.listener = l
 
+	var tlsConfig *tls.Config
+	if ls.Config.Tls != nil {
+		if tlsConfig, err = ls.createTlsConfig(); err != nil {
+			_ = l.Close()
+			return err
+		}
+	}
+
 	handlerWrapper := &handleWrapper{
 		fc:              ls.FilterChain,
 		gShutdownConfig: ls.gShutdownConfig,
@@ -114,12 +125,22 @@ func (ls *Http2ListenerService) Start() error {
 	}
 
 	ls.server = &http.Server{
-		Addr:    addr,
-		Handler: h,
+		Addr:      addr,
+		Handler:   h,
+		TLSConfig: tlsConfig,
 	}
 
 	go func() {
-		if err := ls.server.Serve(ls.listener); err != nil {
+		var err error
+		if tlsConfig != nil {
+			// negotiate h2 by ALPN, certificates are provided by TLSConfig.GetCertificate
+			if err = http2.ConfigureServer(ls.server, h2s); err == nil {
+				err = ls.server.ServeTLS(ls.listener, "", "")
+			}
+		} else {
+			err = ls.server.Serve(ls.listener)
+		}
+		if err != nil {
 			if err == http.ErrServerClosed {
 				logger.Infof("Listener %s closed", ls.Config.Name)
 				return
@@ -131,6 +152,9 @@ func (ls *Http2ListenerService) Start() error {
 }
 
 func (ls *Http2ListenerService) Close() error {
+	if ls.certManager != nil {
+		ls.certManager.Close()
+	}
 	return ls.server.Close()
 }
 
@@ -148,8 +172,7 @@ func (ls *Http2ListenerService) ShutDown(wg interface{}) error {
 		logger.Infof("waiting for active invocation count = %d", ls.gShutdownConfig.ActiveCount)
 	}
 	wg.(*sync.WaitGroup).Done()
-	ls.server.Close()
-	return nil
+	return ls.Close()
 }
 
 func (ls *Http2ListenerService) Refresh(c model.Listener) error {
@@ -159,6 +182,20 @@ func (ls *Http2ListenerService) Refresh(c model.Listener) error {
 	return nil
 }
 
+func (ls *Http2ListenerService) createTlsConfig() (*tls.Config, error) {
+	certManager, err := pixiutls.NewCertificateManager(ls.Config.Tls)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := certManager.TLSConfig()
+	if err != nil {
+		certManager.Close()
+		return nil, err
+	}
+	ls.certManager = certManager
+	return tlsConfig, nil
+}
+
 func resolveAddress(addr string) string {
 	if addr == "" {
 		logger.Debug("Addr is undefined. Using port :8080 by default")
@@ -19,6 +19,7 @@
 package tcp
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"sync"
@@ -47,32 +48,52 @@ type (
 		listener.BaseListenerService
 		server          getty.Server
 		gShutdownConfig *listener.ListenerGracefulShutdownConfig
+		// rawListener is used instead of getty server when the network filter handles raw connection
+		rawListener net.Listener
 	}
 )
 
 func newTcpListenerService(lc *model.Listener, bs *model.Bootstrap) (listener.ListenerService, error) {
-	serverOpts := []getty.ServerOption{getty.WithLocalAddress(lc.Address.SocketAddress.GetAddress())}
-	// todo taskPoolMode
-	server := getty.NewTCPServer(serverOpts...)
-
 	fc := filterchain.CreateNetworkFilterChain(lc.FilterChain)
-	return &TcpListenerService{
+	ls := &TcpListenerService{
 		BaseListenerService: listener.BaseListenerService{
 			Config:      lc,
 			FilterChain: fc,
 		},
-		server:          server,
 		gShutdownConfig: &listener.ListenerGracefulShutdownConfig{},
-	}, nil
+	}
+	if fc.SupportConnection() {
+		return ls, nil
+	}
+
+	serverOpts := []getty.ServerOption{getty.WithLocalAddress(lc.Address.SocketAddress.GetAddress())}
+	// todo taskPoolMode
+	ls.server = getty.NewTCPServer(serverOpts...)
+	return ls, nil
 }
 
 // Start start tcp server
 func (ls *TcpListenerService) Start() error {
+	if ls.server == nil {
+		l, err := net.Listen("tcp", ls.Config.Address.SocketAddress.GetAddress())
+		if err != nil {
+			return err
+		}
+		ls.rawListener = l
+		go ls.serveRaw()
+		return nil
+	}
 	go ls.server.RunEventLoop(ls.newSession)
 	return nil
 }
 
 func (ls *TcpListenerService) Close() error {
+	if ls.server == nil {
+		if ls.rawListener == nil {
+			return nil
+		}
+		return ls.rawListener.Close()
+	}
 	ls.server.Close()
 	return nil
 }
@@ -91,8 +112,43 @@ func (ls *TcpListenerService) ShutDown(wg interface{}) error {
 		logger.Infof("waiting for active invocation count = %d", ls.gShutdownConfig.ActiveCount)
 	}
 	wg.(*sync.WaitGroup).Done()
-	ls.server.Close()
-	return nil
+	return ls.Close()
+}
+
+// serveRaw accept connections and pass them to network filter directly
+func (ls *TcpListenerService) serveRaw() {
+	for {
+		conn, err := ls.rawListener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				logger.Infof("[dubbo-go-pixiu] tcp listener %s closed", ls.Config.Name)
+				return
+			}
+			logger.Warnf("[dubbo-go-pixiu] tcp listener %s accept error: %v", ls.Config.Name, err)
+			continue
+		}
+		if ls.gShutdownConfig.RejectRequest {
+			_ = conn.Close()
+			continue
+		}
+		go ls.handleConnection(conn)
+	}
+}
+
+func (ls *TcpListenerService) handleConnection(conn net.Conn) {
+	ls.gShutdownConfig.AddActiveCount(1)
+	defer ls.gShutdownConfig.AddActiveCount(-1)
+	defer func() {
+		if e := recover(); e != nil {
+			logger.Errorf("[dubbo-go-pixiu] tcp listener handle connection from %s panic: %v", conn.RemoteAddr(), e)
+			_ = conn.Close()
+		}
+	}()
+
+	if err := ls.FilterChain.OnConnection(conn); err != nil {
+		logger.Warnf("[dubbo-go-pixiu] tcp listener handle connection from %s error: %v", conn.RemoteAddr(), err)
+		_ = conn.Close()
+	}
 }
 
 func (ls *TcpListenerService) Refresh(c model.Listener) error {
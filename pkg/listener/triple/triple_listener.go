@@ -19,6 +19,7 @@ package triple
 
 import (
 	"context"
+	"crypto/tls"
 	"reflect"
 	"sync"
 	"time"
@@ -32,6 +33,8 @@ import (
 )
 
 import (
+	pixiutls "github.com/apache/dubbo-go-pixiu/pkg/common/tls"
+	"github.com/apache/dubbo-go-pixiu/pkg/common/util/stringutil"
 	"github.com/apache/dubbo-go-pixiu/pkg/config"
 	"github.com/apache/dubbo-go-pixiu/pkg/filterchain"
 	"github.com/apache/dubbo-go-pixiu/pkg/listener"
@@ -77,6 +80,11 @@ func newTripleListenerService(lc *model.Listener, bs *model.Bootstrap) (listener
 	}
 
 	triOption := triConfig.NewTripleOption(opts...)
+	if lc.Tls != nil {
+		if err := setTlsOption(triOption, lc.Tls); err != nil {
+			return nil, errors.Wrapf(err, "triple listener %s", lc.Name)
+		}
+	}
 
 	tripleService := &ProxyService{ls: ls}
 	serviceMap := &sync.Map{}
@@ -87,6 +95,46 @@ func newTripleListenerService(lc *model.Listener, bs *model.Bootstrap) (listener
 	return ls, nil
 }
 
+// setTlsOption apply the tls config to triple server, which only accepts one certificate file and an optional
+// client CA. The options triple server can not honor are rejected rather than ignored silently.
+func setTlsOption(opt *triConfig.Option, cfg *model.TlsConfig) error {
+	if len(cfg.Certificates) != 1 {
+		return errors.New("exactly one tls certificate is required")
+	}
+	cert := cfg.Certificates[0]
+	switch {
+	case len(cert.ServerNames) > 0:
+		return errors.New("tls server_names is not supported")
+	case cfg.MinVersion != "" || cfg.MaxVersion != "":
+		return errors.New("tls min_version and max_version are not supported")
+	case len(cfg.CipherSuites) > 0:
+		return errors.New("tls cipher_suites is not supported")
+	case stringutil.ResolveTimeStr2Time(cfg.ReloadIntervalStr, 0) > 0:
+		return errors.New("tls reload_interval is not supported, the certificate is loaded once")
+	}
+	// triple server falls back to plaintext if the certificate can not be loaded, so check it first
+	if _, err := tls.LoadX509KeyPair(cert.CertFile, cert.KeyFile); err != nil {
+		return errors.Wrapf(err, "load tls certificate %s", cert.CertFile)
+	}
+
+	switch cfg.ClientAuth {
+	case "", "none":
+	case "require_and_verify":
+		if cfg.ClientCACertFile == "" {
+			return errors.New("client_ca_cert_file is required by client_auth require_and_verify")
+		}
+		if _, err := pixiutls.LoadCertPool(cfg.ClientCACertFile); err != nil {
+			return err
+		}
+		opt.CACertFile = cfg.ClientCACertFile
+	default:
+		return errors.Errorf("tls client_auth %s is not supported", cfg.ClientAuth)
+	}
+	opt.TLSCertFile = cert.CertFile
+	opt.TLSKeyFile = cert.KeyFile
+	return nil
+}
+
 // Start start triple server
 func (ls *TripleListenerService) Start() error {
 	ls.server.Start()
@@ -0,0 +1,140 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udp
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/config"
+	"github.com/apache/dubbo-go-pixiu/pkg/filterchain"
+	"github.com/apache/dubbo-go-pixiu/pkg/listener"
+	"github.com/apache/dubbo-go-pixiu/pkg/logger"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+const (
+	// maxDatagramSize the max size of a udp datagram
+	maxDatagramSize = 64 * 1024
+)
+
+func init() {
+	listener.SetListenerServiceFactory(model.ProtocolTypeUDP, newUdpListenerService)
+}
+
+type (
+	// UdpListenerService the facade of a udp listener
+	UdpListenerService struct {
+		listener.BaseListenerService
+		conn            net.PacketConn
+		gShutdownConfig *listener.ListenerGracefulShutdownConfig
+	}
+)
+
+func newUdpListenerService(lc *model.Listener, bs *model.Bootstrap) (listener.ListenerService, error) {
+	fc := filterchain.CreateNetworkFilterChain(lc.FilterChain)
+	return &UdpListenerService{
+		BaseListenerService: listener.BaseListenerService{
+			Config:      lc,
+			FilterChain: fc,
+		},
+		gShutdownConfig: &listener.ListenerGracefulShutdownConfig{},
+	}, nil
+}
+
+// Start start udp server
+func (ls *UdpListenerService) Start() error {
+	conn, err := net.ListenPacket("udp", ls.Config.Address.SocketAddress.GetAddress())
+	if err != nil {
+		return err
+	}
+	ls.conn = conn
+	go ls.serve()
+	return nil
+}
+
+func (ls *UdpListenerService) Close() error {
+	if ls.conn == nil {
+		return nil
+	}
+	return ls.conn.Close()
+}
+
+func (ls *UdpListenerService) ShutDown(wg interface{}) error {
+	timeout := config.GetBootstrap().GetShutdownConfig().GetTimeout()
+	if timeout <= 0 {
+		return nil
+	}
+	// stop accept datagram
+	ls.gShutdownConfig.RejectRequest = true
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) && ls.gShutdownConfig.ActiveCount > 0 {
+		// sleep 100 ms and check it again
+		time.Sleep(100 * time.Millisecond)
+		logger.Infof("waiting for active datagram count = %d", ls.gShutdownConfig.ActiveCount)
+	}
+	wg.(*sync.WaitGroup).Done()
+	return ls.Close()
+}
+
+func (ls *UdpListenerService) Refresh(c model.Listener) error {
+	// There is no need to lock here for now, as there is at most one NetworkFilter
+	fc := filterchain.CreateNetworkFilterChain(c.FilterChain)
+	ls.FilterChain = fc
+	return nil
+}
+
+// serve read datagrams until the conn is closed
+func (ls *UdpListenerService) serve() {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := ls.conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				logger.Infof("[dubbo-go-pixiu] udp listener %s closed", ls.Config.Name)
+				return
+			}
+			logger.Warnf("[dubbo-go-pixiu] udp listener %s read error: %v", ls.Config.Name, err)
+			continue
+		}
+		if ls.gShutdownConfig.RejectRequest {
+			continue
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		ls.handle(addr, data)
+	}
+}
+
+func (ls *UdpListenerService) handle(addr net.Addr, data []byte) {
+	ls.gShutdownConfig.AddActiveCount(1)
+	defer ls.gShutdownConfig.AddActiveCount(-1)
+	defer func() {
+		if e := recover(); e != nil {
+			logger.Errorf("[dubbo-go-pixiu] udp listener handle datagram from %s panic: %v", addr, e)
+		}
+	}()
+
+	if err := ls.FilterChain.OnDatagram(ls.conn, addr, data); err != nil {
+		logger.Debugf("[dubbo-go-pixiu] udp listener handle datagram from %s error: %v", addr, err)
+	}
+}
@@ -43,6 +43,14 @@ type Bootstrap struct {
 type Node struct {
 	Cluster string `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
 	Id      string `yaml:"id" json:"id" mapstructure:"id"`
+	// Locality where pixiu is deployed, the locality aware load balancing prefers the endpoints nearby
+	Locality *Locality `yaml:"locality" json:"locality,omitempty" mapstructure:"locality"`
+}
+
+// Locality the region and zone of pixiu or endpoint
+type Locality struct {
+	Region string `yaml:"region" json:"region" mapstructure:"region"`
+	Zone   string `yaml:"zone" json:"zone" mapstructure:"zone"`
 }
 
 // GetListeners
@@ -19,6 +19,38 @@ package model
 
 import (
 	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// SubsetFallbackNone no endpoint is selected if no subset matches
+	SubsetFallbackNone = "NO_FALLBACK"
+	// SubsetFallbackAnyEndpoint all endpoints of the cluster are used if no subset matches
+	SubsetFallbackAnyEndpoint = "ANY_ENDPOINT"
+	// SubsetFallbackDefaultSubset the endpoints matching the default subset are used if no subset matches
+	SubsetFallbackDefaultSubset = "DEFAULT_SUBSET"
+)
+
+const (
+	// DefaultEndpointWeight the weight of endpoint if it is not set
+	DefaultEndpointWeight uint32 = 1
+	// EndpointWeightKey the metadata key of endpoint weight used by service discovery which has no weight field
+	EndpointWeightKey = "weight"
+	// EndpointRegionKey the metadata key of the region where endpoint is deployed
+	EndpointRegionKey = "region"
+	// EndpointZoneKey the metadata key of the zone where endpoint is deployed
+	EndpointZoneKey = "zone"
+	// EndpointPriorityKey the metadata key of endpoint priority, 0 is the highest and the higher levels are for failover
+	EndpointPriorityKey = "priority"
+)
+
+const (
+	// DefaultLocalHealthyPercent spill over to other zones when the available endpoints of local zone are below it
+	DefaultLocalHealthyPercent = 70
+	// DefaultOverprovisioningFactor a priority level is healthy if available percentage * factor / 100 >= 100
+	DefaultOverprovisioningFactor = 140
 )
 
 const (
@@ -52,14 +84,32 @@ var (
 type (
 	// ClusterConfig a single upstream cluster
 	ClusterConfig struct {
-		Name                 string              `yaml:"name" json:"name"` // Name the cluster unique name
-		TypeStr              string              `yaml:"type" json:"type"` // Type the cluster discovery type string value
-		Type                 DiscoveryType       `yaml:"-" json:"-"`       // Type the cluster discovery type
-		EdsClusterConfig     EdsClusterConfig    `yaml:"eds_cluster_config" json:"eds_cluster_config" mapstructure:"eds_cluster_config"`
-		LbStr                LbPolicyType        `yaml:"lb_policy" json:"lb_policy"`   // Lb the cluster select node used loadBalance policy
-		ConsistentHash       ConsistentHash      `yaml:"consistent" json:"consistent"` // Consistent hash config info
-		HealthChecks         []HealthCheckConfig `yaml:"health_checks" json:"health_checks"`
-		Endpoints            []*Endpoint         `yaml:"endpoints" json:"endpoints"`
+		Name             string           `yaml:"name" json:"name"` // Name the cluster unique name
+		TypeStr          string           `yaml:"type" json:"type"` // Type the cluster discovery type string value
+		Type             DiscoveryType    `yaml:"-" json:"-"`       // Type the cluster discovery type
+		EdsClusterConfig EdsClusterConfig `yaml:"eds_cluster_config" json:"eds_cluster_config" mapstructure:"eds_cluster_config"`
+		LbStr            LbPolicyType     `yaml:"lb_policy" json:"lb_policy"`   // Lb the cluster select node used loadBalance policy
+		ConsistentHash   ConsistentHash   `yaml:"consistent" json:"consistent"` // Consistent hash config info
+		// HashPolicy the hash policies for consistent hash load balancers, the request line is hashed if no key is found
+		HashPolicy []HashPolicy `yaml:"hash_policy" json:"hash_policy,omitempty"`
+		// LbSubsetConfig divide the endpoints into subsets by metadata, the route selects a subset by metadata_match
+		LbSubsetConfig *LbSubsetConfig `yaml:"lb_subset_config" json:"lb_subset_config,omitempty"`
+		// LocalityLbConfig prefer the endpoints of higher priority and in the same zone as the node of pixiu
+		LocalityLbConfig *LocalityLbConfig   `yaml:"locality_lb_config" json:"locality_lb_config,omitempty"`
+		HealthChecks     []HealthCheckConfig `yaml:"health_checks" json:"health_checks"`
+		OutlierDetection *OutlierDetection   `yaml:"outlier_detection" json:"outlier_detection"`
+		// CircuitBreakers the requests overflowing the limits of cluster fail fast with 503
+		CircuitBreakers *CircuitBreakers `yaml:"circuit_breakers" json:"circuit_breakers,omitempty"`
+		// SlowStartConfig ramp up the traffic of the endpoints which are newly added or return from unhealthy
+		SlowStartConfig *SlowStartConfig `yaml:"slow_start_config" json:"slow_start_config,omitempty"`
+		// DnsRefreshRate the interval to re-resolve the hostnames of StrictDNS and LogicalDns cluster, default 5s
+		DnsRefreshRate string `yaml:"dns_refresh_rate" json:"dns_refresh_rate,omitempty"`
+		// RespectDnsTTL re-resolve the hostnames when the dns records expire if the resolver knows the ttl
+		RespectDnsTTL bool `yaml:"respect_dns_ttl" json:"respect_dns_ttl,omitempty"`
+		// Tls the upstream connections use tls if set. Triple upstreams require ca_cert_file and support neither
+		// insecure_skip_verify nor min_version, dubbo upstreams reject it and use the tls config of dubbo-go instead
+		Tls                  *UpstreamTlsConfig `yaml:"tls" json:"tls"`
+		Endpoints            []*Endpoint        `yaml:"endpoints" json:"endpoints"`
 		PrePickEndpointIndex int
 	}
 
@@ -89,11 +139,77 @@ type (
 
 	// Endpoint
 	Endpoint struct {
-		ID        string            `yaml:"ID" json:"ID"`                                                       // ID indicate one endpoint
-		Name      string            `yaml:"name" json:"name"`                                                   // Name the cluster unique name
-		Address   SocketAddress     `yaml:"socket_address" json:"socket_address" mapstructure:"socket_address"` // Address socket address
-		Metadata  map[string]string `yaml:"meta" json:"meta"`                                                   // Metadata extra info such as label or other meta data
+		// activeRequests the in-flight requests counted by proxy filters, keep it first for 64-bit atomic alignment
+		activeRequests int64
+		// warmupSince the unix nano when the endpoint starts to warm up, 0 if it never does
+		warmupSince int64
+
+		ID       string            `yaml:"ID" json:"ID"`                                                       // ID indicate one endpoint
+		Name     string            `yaml:"name" json:"name"`                                                   // Name the cluster unique name
+		Address  SocketAddress     `yaml:"socket_address" json:"socket_address" mapstructure:"socket_address"` // Address socket address
+		Metadata map[string]string `yaml:"meta" json:"meta"`                                                   // Metadata extra info such as label or other meta data
+		// Weight the relative weight used by weighted load balancers, 0 means the default weight 1
+		Weight    uint32 `yaml:"weight" json:"weight,omitempty" mapstructure:"weight"`
 		UnHealthy bool
+		// Ejected the endpoint is ejected by outlier detection for a while
+		Ejected bool `yaml:"-" json:"-"`
+	}
+
+	// LbSubsetConfig the subsets of endpoints are precomputed by the selectors when endpoints change
+	LbSubsetConfig struct {
+		// FallbackPolicy NO_FALLBACK, ANY_ENDPOINT or DEFAULT_SUBSET, applied when the route has no metadata_match
+		// or the selected subset has no available endpoint. the default is ANY_ENDPOINT
+		FallbackPolicy string `yaml:"fallback_policy" json:"fallback_policy" mapstructure:"fallback_policy"`
+		// DefaultSubset the metadata of endpoints used by DEFAULT_SUBSET fallback policy
+		DefaultSubset map[string]string `yaml:"default_subset" json:"default_subset" mapstructure:"default_subset"`
+		// SubsetSelectors the metadata keys to divide endpoints, the keys of metadata_match must equal to one of them
+		SubsetSelectors []SubsetSelector `yaml:"subset_selectors" json:"subset_selectors" mapstructure:"subset_selectors"`
+	}
+
+	// LocalityLbConfig the locality and priority of endpoint are read from metadata region, zone and priority
+	LocalityLbConfig struct {
+		// LocalHealthyPercent the local zone, then the local region is used only if its available percentage reaches it
+		LocalHealthyPercent int `yaml:"local_healthy_percent" json:"local_healthy_percent" mapstructure:"local_healthy_percent"`
+		// OverprovisioningFactor the traffic fails over to the next priority if the available percentage * factor / 100 < 100
+		OverprovisioningFactor int `yaml:"overprovisioning_factor" json:"overprovisioning_factor" mapstructure:"overprovisioning_factor"`
+	}
+
+	// SubsetSelector the metadata keys of a kind of subsets, e.g. [version] or [version, region]
+	SubsetSelector struct {
+		Keys []string `yaml:"keys" json:"keys" mapstructure:"keys"`
+	}
+
+	// OutlierDetection passive health check, eject the endpoint which fails continuously with real traffic
+	OutlierDetection struct {
+		Consecutive5xx            uint32 `yaml:"consecutive_5xx" json:"consecutive_5xx"`
+		ConsecutiveConnectFailure uint32 `yaml:"consecutive_connect_failure" json:"consecutive_connect_failure"`
+		BaseEjectionTime          string `yaml:"base_ejection_time" json:"base_ejection_time"`
+		MaxEjectionTime           string `yaml:"max_ejection_time" json:"max_ejection_time"`
+		MaxEjectionPercent        int    `yaml:"max_ejection_percent" json:"max_ejection_percent"`
+	}
+
+	// CircuitBreakers the resource limits of an upstream cluster, 0 means unlimited
+	CircuitBreakers struct {
+		// MaxConnections the max connections to the cluster, only applied to the connections managed by pixiu, i.e. http,
+		// grpc and tcp. the connections of dubbo are managed by dubbo-go, so they are not covered
+		MaxConnections uint32 `yaml:"max_connections" json:"max_connections" mapstructure:"max_connections"`
+		// MaxPendingRequests the max requests waiting for MaxRequests, no request waits if it is 0
+		MaxPendingRequests uint32 `yaml:"max_pending_requests" json:"max_pending_requests" mapstructure:"max_pending_requests"`
+		// MaxRequests the max concurrent requests to the cluster
+		MaxRequests uint32 `yaml:"max_requests" json:"max_requests" mapstructure:"max_requests"`
+		// MaxRetries the max concurrent retries to the cluster
+		MaxRetries uint32 `yaml:"max_retries" json:"max_retries" mapstructure:"max_retries"`
+	}
+
+	// SlowStartConfig the weight of warming endpoint is scaled by max(elapsed / window, min_weight_percent) ^ (1 / aggression),
+	// it applies to RoundRobin, Rand and WeightedRoundRobin
+	SlowStartConfig struct {
+		// SlowStartWindow the duration of warming up, e.g. 60s
+		SlowStartWindow string `yaml:"slow_start_window" json:"slow_start_window" mapstructure:"slow_start_window"`
+		// Aggression the larger the faster the traffic ramps up at the beginning, default 1.0 ramps up linearly
+		Aggression float64 `yaml:"aggression" json:"aggression" mapstructure:"aggression"`
+		// MinWeightPercent the min percentage of the weight during warming up, default 10
+		MinWeightPercent int `yaml:"min_weight_percent" json:"min_weight_percent" mapstructure:"min_weight_percent"`
 	}
 
 	// ConsistentHash methods include: RingHash, MaglevHash
@@ -101,7 +217,10 @@ type (
 		ReplicaNum      int   `yaml:"replica_num" json:"replica_num"`
 		MaxVnodeNum     int32 `yaml:"max_vnode_num" json:"max_vnode_num"`
 		MaglevTableSize int   `yaml:"maglev_table_size" json:"maglev_table_size"`
-		Hash            LbConsistentHash
+		// MaxRingSize the max number of virtual nodes in the ring hash, the weights of endpoints are scaled down to fit.
+		// default 8M as envoy's maximum_ring_size
+		MaxRingSize int `yaml:"max_ring_size" json:"max_ring_size,omitempty"`
+		Hash        LbConsistentHash
 	}
 )
 
@@ -109,7 +228,7 @@ func (c *ClusterConfig) GetEndpoint(mustHealth bool) []*Endpoint {
 	var endpoints = make([]*Endpoint, 0, len(c.Endpoints))
 	for _, e := range c.Endpoints {
 		// select all endpoint or endpoint is health
-		if !mustHealth || !e.UnHealthy {
+		if !mustHealth || e.IsAvailable() {
 			endpoints = append(endpoints, e)
 		}
 	}
@@ -123,6 +242,74 @@ func (c *ClusterConfig) CreateConsistentHash() {
 	}
 }
 
+// GetDiscoveryType the discovery type of the cluster, TypeStr takes precedence since Type is only resolved on loading config
+func (c *ClusterConfig) GetDiscoveryType() DiscoveryType {
+	if t, ok := DiscoveryTypeValue[c.TypeStr]; ok {
+		return t
+	}
+	return c.Type
+}
+
+// IsAvailable the endpoint is neither unhealthy nor ejected
+func (e *Endpoint) IsAvailable() bool {
+	return !e.UnHealthy && !e.Ejected
+}
+
+// GetLocality the locality of the endpoint read from metadata
+func (e *Endpoint) GetLocality() Locality {
+	return Locality{Region: e.Metadata[EndpointRegionKey], Zone: e.Metadata[EndpointZoneKey]}
+}
+
+// GetPriority the priority of the endpoint read from metadata, 0 if it is not set or invalid
+func (e *Endpoint) GetPriority() uint32 {
+	p, ok := e.Metadata[EndpointPriorityKey]
+	if !ok {
+		return 0
+	}
+	priority, err := strconv.ParseUint(p, 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(priority)
+}
+
+// GetWeight the weight of the endpoint, at least 1
+func (e *Endpoint) GetWeight() uint32 {
+	if e.Weight == 0 {
+		return DefaultEndpointWeight
+	}
+	return e.Weight
+}
+
+// IncActiveRequests increase the in-flight requests of the endpoint, DecActiveRequests must be called when the request is done
+func (e *Endpoint) IncActiveRequests() {
+	atomic.AddInt64(&e.activeRequests, 1)
+}
+
+// DecActiveRequests decrease the in-flight requests of the endpoint
+func (e *Endpoint) DecActiveRequests() {
+	atomic.AddInt64(&e.activeRequests, -1)
+}
+
+// ActiveRequests the in-flight requests of the endpoint
+func (e *Endpoint) ActiveRequests() int64 {
+	return atomic.LoadInt64(&e.activeRequests)
+}
+
+// StartWarmup the endpoint is newly added or returns from unhealthy, slow start ramps up its traffic
+func (e *Endpoint) StartWarmup() {
+	atomic.StoreInt64(&e.warmupSince, time.Now().UnixNano())
+}
+
+// WarmupSince the time when the endpoint starts to warm up, zero time if it never does
+func (e *Endpoint) WarmupSince() time.Time {
+	since := atomic.LoadInt64(&e.warmupSince)
+	if since == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, since)
+}
+
 func (e Endpoint) GetHost() string {
 	return fmt.Sprintf("%s:%d", e.Address.Address, e.Address.Port)
 }
@@ -19,31 +19,42 @@ package model
 
 // HealthCheck
 type HealthCheckConfig struct {
-	Protocol            string                 `json:"protocol,omitempty"`
-	TimeoutConfig       string                 `json:"timeout,omitempty"`
-	IntervalConfig      string                 `json:"interval,omitempty"`
-	InitialDelaySeconds string                 `json:"initial_delay_seconds,omitempty"`
-	HealthyThreshold    uint32                 `json:"healthy_threshold,omitempty"`
-	UnhealthyThreshold  uint32                 `json:"unhealthy_threshold,omitempty"`
-	ServiceName         string                 `json:"service_name,omitempty"`
-	SessionConfig       map[string]interface{} `json:"check_config,omitempty"`
-	CommonCallbacks     []string               `json:"common_callbacks,omitempty"`
+	Protocol            string                 `yaml:"protocol" json:"protocol,omitempty"`
+	TimeoutConfig       string                 `yaml:"timeout" json:"timeout,omitempty"`
+	IntervalConfig      string                 `yaml:"interval" json:"interval,omitempty"`
+	InitialDelaySeconds string                 `yaml:"initial_delay_seconds" json:"initial_delay_seconds,omitempty"`
+	HealthyThreshold    uint32                 `yaml:"healthy_threshold" json:"healthy_threshold,omitempty"`
+	UnhealthyThreshold  uint32                 `yaml:"unhealthy_threshold" json:"unhealthy_threshold,omitempty"`
+	ServiceName         string                 `yaml:"service_name" json:"service_name,omitempty"`
+	SessionConfig       map[string]interface{} `yaml:"check_config" json:"check_config,omitempty"`
+	CommonCallbacks     []string               `yaml:"common_callbacks" json:"common_callbacks,omitempty"`
+	HttpHealthCheck     *HttpHealthCheck       `yaml:"http_health_check" json:"http_health_check,omitempty"`
+	GrpcHealthCheck     *GrpcHealthCheck       `yaml:"grpc_health_check" json:"grpc_health_check,omitempty"`
 }
 
-// HttpHealthCheck
+// HttpHealthCheck config for http and https health check
 type HttpHealthCheck struct {
-	HealthCheckConfig
-	Host             string
-	Path             string
-	UseHttp2         bool
-	ExpectedStatuses int64
+	Host     string            `yaml:"host" json:"host,omitempty"`
+	Path     string            `yaml:"path" json:"path,omitempty"`
+	Method   string            `yaml:"method" json:"method,omitempty"`
+	Headers  map[string]string `yaml:"headers" json:"headers,omitempty"`
+	UseHttp2 bool              `yaml:"use_http2" json:"use_http2,omitempty"`
+	// ExpectedStatuses the status ranges treated as healthy, default [200, 400)
+	ExpectedStatuses []StatusRange `yaml:"expected_statuses" json:"expected_statuses,omitempty"`
+	// ExpectedBody the substring which must be contained in response body, skip if empty
+	ExpectedBody string `yaml:"expected_body" json:"expected_body,omitempty"`
+}
+
+// StatusRange http status range, Start is inclusive and End is exclusive
+type StatusRange struct {
+	Start int `yaml:"start" json:"start"`
+	End   int `yaml:"end" json:"end"`
 }
 
-// GrpcHealthCheck
+// GrpcHealthCheck config for grpc.health.v1 health check
 type GrpcHealthCheck struct {
-	HealthCheckConfig
-	ServiceName string
-	Authority   string
+	ServiceName string `yaml:"service_name" json:"service_name,omitempty"`
+	Authority   string `yaml:"authority" json:"authority,omitempty"`
 }
 
 // CustomHealthCheck
@@ -52,3 +63,8 @@ type CustomHealthCheck struct {
 	Name   string
 	Config interface{}
 }
+
+// Contains check whether the status is in the range
+func (r StatusRange) Contains(status int) bool {
+	return status >= r.Start && status < r.End
+}
@@ -38,6 +38,18 @@ type HttpConnectionManagerConfig struct {
 	GenerateRequestID bool               `yaml:"generate_request_id" json:"generate_request_id" mapstructure:"generate_request_id"`
 	TimeoutStr        string             `yaml:"timeout" json:"timeout" mapstructure:"timeout"`
 	Timeout           time.Duration      `yaml:"-" json:"-" mapstructure:"-"`
+	// StreamBody copy the upstream response body to client with flushing instead of buffering it in memory,
+	// it is suitable for SSE, chunked download and large file transfer. The write_timeout of the listener, 20s by
+	// default, bounds the whole response, set it to 0s or long enough for long-lived streams
+	StreamBody bool `yaml:"stream_body" json:"stream_body,omitempty" mapstructure:"stream_body"`
+	// WebSocket config of the websocket connection which is upgraded from http
+	WebSocket *WebSocketConfig `yaml:"websocket" json:"websocket,omitempty" mapstructure:"websocket"`
+}
+
+// WebSocketConfig websocket proxy config
+type WebSocketConfig struct {
+	IdleTimeoutStr string        `yaml:"idle_timeout" json:"idle_timeout" mapstructure:"idle_timeout"`
+	IdleTimeout    time.Duration `yaml:"-" json:"-" mapstructure:"-"`
 }
 
 // GRPCConnectionManagerConfig
@@ -21,23 +21,68 @@ package model
 type LbPolicyType string
 
 const (
-	LoadBalancerRand          LbPolicyType = "Rand"
-	LoadBalancerRoundRobin    LbPolicyType = "RoundRobin"
-	LoadBalancerRingHashing   LbPolicyType = "RingHashing"
-	LoadBalancerMaglevHashing LbPolicyType = "MaglevHashing"
+	LoadBalancerRand               LbPolicyType = "Rand"
+	LoadBalancerRoundRobin         LbPolicyType = "RoundRobin"
+	LoadBalancerRingHashing        LbPolicyType = "RingHashing"
+	LoadBalancerMaglevHashing      LbPolicyType = "MaglevHashing"
+	LoadBalancerLeastRequest       LbPolicyType = "LeastRequest"
+	LoadBalancerP2C                LbPolicyType = "P2C"
+	LoadBalancerWeightedRoundRobin LbPolicyType = "WeightedRoundRobin"
 )
 
 var LbPolicyTypeValue = map[string]LbPolicyType{
-	"Rand":          LoadBalancerRand,
-	"RoundRobin":    LoadBalancerRoundRobin,
-	"RingHashing":   LoadBalancerRingHashing,
-	"MaglevHashing": LoadBalancerMaglevHashing,
+	"Rand":               LoadBalancerRand,
+	"RoundRobin":         LoadBalancerRoundRobin,
+	"RingHashing":        LoadBalancerRingHashing,
+	"MaglevHashing":      LoadBalancerMaglevHashing,
+	"LeastRequest":       LoadBalancerLeastRequest,
+	"P2C":                LoadBalancerP2C,
+	"WeightedRoundRobin": LoadBalancerWeightedRoundRobin,
 }
 
 type LbPolicy interface {
 	GenerateHash() string
 }
 
+// HashPolicyLbPolicy the LbPolicy which can generate the hash key by the hash policies of cluster
+type HashPolicyLbPolicy interface {
+	LbPolicy
+	// GenerateHashWithPolicy generate the hash key by the first policy which finds a key,
+	// the policies of route take precedence over the given ones, GenerateHash is the fallback
+	GenerateHashWithPolicy(policies []HashPolicy) string
+}
+
+// MetadataMatchLbPolicy the LbPolicy which selects an endpoint subset by metadata
+type MetadataMatchLbPolicy interface {
+	LbPolicy
+	// MetadataMatch the labels the endpoint metadata must contain, nil means no subset is selected
+	MetadataMatch() map[string]string
+}
+
+type (
+	// HashPolicy the source of the hash key for consistent hash load balancers, set only one source in a policy
+	HashPolicy struct {
+		// Header hash on the request header, or the attachment of dubbo invocation
+		Header string      `yaml:"header" json:"header,omitempty" mapstructure:"header"`
+		Cookie *HashCookie `yaml:"cookie" json:"cookie,omitempty" mapstructure:"cookie"`
+		// QueryParameter hash on the query parameter of request url
+		QueryParameter string `yaml:"query_parameter" json:"query_parameter,omitempty" mapstructure:"query_parameter"`
+		// SourceIP hash on the client ip
+		SourceIP bool `yaml:"source_ip" json:"source_ip,omitempty" mapstructure:"source_ip"`
+		// JwtClaim hash on the claim of the bearer token, nested claim is separated by dot like user.id.
+		// the token is not verified here, use the jwt filter to verify it
+		JwtClaim string `yaml:"jwt_claim" json:"jwt_claim,omitempty" mapstructure:"jwt_claim"`
+	}
+
+	// HashCookie hash on the cookie, the cookie is generated if it is missing and the ttl is set
+	HashCookie struct {
+		Name string `yaml:"name" json:"name" mapstructure:"name"`
+		Path string `yaml:"path" json:"path,omitempty" mapstructure:"path"`
+		// TTL the max age of the generated cookie, like 1h
+		TTL string `yaml:"ttl" json:"ttl,omitempty" mapstructure:"ttl"`
+	}
+)
+
 // LbConsistentHash supports consistent hash load balancing
 type LbConsistentHash interface {
 	Hash(key string) uint32
@@ -70,5 +70,7 @@ type (
 		Protocol    ProtocolType `default:"http" yaml:"omitempty" json:"omitempty"`
 		FilterChain FilterChain  `yaml:"filter_chains" json:"filter_chains" mapstructure:"filter_chains"`
 		Config      interface{}  `yaml:"config" json:"config" mapstructure:"config"`
+		// Tls the listener serves tls if set, triple listener only supports one certificate without reloading
+		Tls *TlsConfig `yaml:"tls" json:"tls" mapstructure:"tls"`
 	}
 )
@@ -19,6 +19,7 @@ package model
 
 import (
 	stdHttp "net/http"
+	"net/url"
 	"regexp"
 	"strings"
 )
@@ -40,20 +41,58 @@ type (
 		Route RouteAction `yaml:"route" json:"route" mapstructure:"route"`
 	}
 
-	// RouterMatch
+	// RouterMatch one of prefix, path and regex matches the path, they can be combined with methods, headers and query parameters.
+	// the routes are matched in the priority:
+	// 1. the routes with headers or query parameters, the first matched one in configuration order wins
+	// 2. the routes with regex or ignore case, the first matched one in configuration order wins
+	// 3. the routes with only prefix or path, the exact path takes precedence over the longest prefix
 	RouterMatch struct {
 		Prefix string `yaml:"prefix" json:"prefix" mapstructure:"prefix"`
 		Path   string `yaml:"path" json:"path" mapstructure:"path"`
-		// Regex   string          `yaml:"regex" json:"regex" mapstructure:"regex"` TODO: next version
+		// Regex the RE2 regex which must match the whole path
+		Regex   string          `yaml:"regex,omitempty" json:"regex,omitempty" mapstructure:"regex"`
 		Methods []string        `yaml:"methods" json:"methods" mapstructure:"methods"`
 		Headers []HeaderMatcher `yaml:"headers,omitempty" json:"headers,omitempty" mapstructure:"headers"`
-		// pathRE  *regexp.Regexp
+		// QueryParameters all of them must match
+		QueryParameters []QueryParameterMatcher `yaml:"query_parameters,omitempty" json:"query_parameters,omitempty" mapstructure:"query_parameters"`
+		// IgnoreCase match the prefix, path and regex case-insensitively
+		IgnoreCase bool `yaml:"ignore_case,omitempty" json:"ignore_case,omitempty" mapstructure:"ignore_case"`
+		pathRE     *regexp.Regexp
 	}
 
 	// RouteAction match route should do
 	RouteAction struct {
-		Cluster                     string `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
-		ClusterNotFoundResponseCode int    `yaml:"cluster_not_found_response_code" json:"cluster_not_found_response_code" mapstructure:"cluster_not_found_response_code"`
+		Cluster                     string       `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
+		ClusterNotFoundResponseCode int          `yaml:"cluster_not_found_response_code" json:"cluster_not_found_response_code" mapstructure:"cluster_not_found_response_code"`
+		RetryPolicy                 *RetryPolicy `yaml:"retry_policy,omitempty" json:"retry_policy,omitempty" mapstructure:"retry_policy"`
+		// HashPolicy the hash policies for consistent hash load balancers, override the ones of cluster
+		HashPolicy []HashPolicy `yaml:"hash_policy,omitempty" json:"hash_policy,omitempty" mapstructure:"hash_policy"`
+		// MetadataMatch select the endpoint subset whose metadata contains all the labels, like version: v2
+		MetadataMatch map[string]string `yaml:"metadata_match,omitempty" json:"metadata_match,omitempty" mapstructure:"metadata_match"`
+		// MirrorPolicy send a copy of the request to the shadow cluster, override the one of http proxy filter
+		MirrorPolicy *MirrorPolicy `yaml:"mirror_policy,omitempty" json:"mirror_policy,omitempty" mapstructure:"mirror_policy"`
+	}
+
+	// MirrorPolicy the request is mirrored asynchronously and the response of shadow cluster is discarded
+	MirrorPolicy struct {
+		Cluster string `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
+		// Percentage the percentage of requests to be mirrored, 100 if it is not set, 0 disables mirroring
+		Percentage *float64 `yaml:"percentage" json:"percentage,omitempty" mapstructure:"percentage"`
+		// HostSuffix appended to the host of the mirrored request, default -shadow
+		HostSuffix string `yaml:"host_suffix" json:"host_suffix" mapstructure:"host_suffix"`
+	}
+
+	// RetryPolicy retry the upstream request on the given conditions
+	RetryPolicy struct {
+		// RetryOn conditions: 5xx, gateway-error, connect-failure, reset, retriable-status-codes
+		RetryOn []string `yaml:"retry_on" json:"retry_on" mapstructure:"retry_on"`
+		// MaxAttempts the max attempts count including the first request
+		MaxAttempts          int    `yaml:"max_attempts" json:"max_attempts" mapstructure:"max_attempts"`
+		PerTryTimeout        string `yaml:"per_try_timeout" json:"per_try_timeout" mapstructure:"per_try_timeout"`
+		RetriableStatusCodes []int  `yaml:"retriable_status_codes" json:"retriable_status_codes" mapstructure:"retriable_status_codes"`
+		// BaseInterval and MaxInterval of the exponential backoff between attempts, jitter is applied
+		BaseInterval string `yaml:"base_interval" json:"base_interval" mapstructure:"base_interval"`
+		MaxInterval  string `yaml:"max_interval" json:"max_interval" mapstructure:"max_interval"`
 	}
 
 	// RouteConfiguration
@@ -70,6 +109,15 @@ type (
 		Regex   bool     `yaml:"regex" json:"regex" mapstructure:"regex"`
 		valueRE *regexp.Regexp
 	}
+
+	// QueryParameterMatcher match the query parameter by Values or the regex of first value, it matches if the
+	// parameter is present when Values is empty
+	QueryParameterMatcher struct {
+		Name    string   `yaml:"name" json:"name" mapstructure:"name"`
+		Values  []string `yaml:"values" json:"values" mapstructure:"values"`
+		Regex   bool     `yaml:"regex" json:"regex" mapstructure:"regex"`
+		valueRE *regexp.Regexp
+	}
 )
 
 func NewRouterMatchPrefix(name string) RouterMatch {
@@ -118,6 +166,146 @@ HEADER:
 	return false
 }
 
+// IsConditional the route has header or query parameter matchers
+func (rm *RouterMatch) IsConditional() bool {
+	return len(rm.Headers) > 0 || len(rm.QueryParameters) > 0
+}
+
+// IsPattern the route path can't be matched by the route trie
+func (rm *RouterMatch) IsPattern() bool {
+	return rm.Regex != "" || rm.IgnoreCase
+}
+
+// Compile compile the regex of path, headers and query parameters
+func (rm *RouterMatch) Compile() error {
+	if rm.Regex != "" {
+		expr := "^(?:" + rm.Regex + ")$"
+		if rm.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		r, err := regexp.Compile(expr)
+		if err != nil {
+			return errors.Wrapf(err, "invalid path regex %s", rm.Regex)
+		}
+		rm.pathRE = r
+	}
+	for i := range rm.Headers {
+		if rm.Headers[i].Regex && len(rm.Headers[i].Values) > 0 {
+			// regexp always use first value of header
+			if err := rm.Headers[i].SetValueRegex(rm.Headers[i].Values[0]); err != nil {
+				return errors.Wrapf(err, "invalid regexp in headers[%d]", i)
+			}
+		}
+	}
+	for i := range rm.QueryParameters {
+		if rm.QueryParameters[i].Regex && len(rm.QueryParameters[i].Values) > 0 {
+			if err := rm.QueryParameters[i].SetValueRegex(rm.QueryParameters[i].Values[0]); err != nil {
+				return errors.Wrapf(err, "invalid regexp in query_parameters[%d]", i)
+			}
+		}
+	}
+	return nil
+}
+
+// Match match all the conditions of the route. the headers only route is matched if any of headers matches for
+// compatibility, otherwise all of the headers must match
+func (rm *RouterMatch) Match(req *stdHttp.Request) bool {
+	if !rm.MatchMethod(req.Method) || !rm.MatchPath(req.URL.Path) {
+		return false
+	}
+	if len(rm.Headers) > 0 {
+		if rm.Prefix == "" && rm.Path == "" && rm.Regex == "" && len(rm.QueryParameters) == 0 {
+			return rm.MatchHeader(req)
+		}
+		for _, header := range rm.Headers {
+			if val := req.Header.Get(header.Name); len(val) == 0 || !header.MatchValues(val) {
+				return false
+			}
+		}
+	}
+	if len(rm.QueryParameters) > 0 {
+		query := req.URL.Query()
+		for _, param := range rm.QueryParameters {
+			if !param.Match(query) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// MatchMethod any method matches if no methods configured
+func (rm *RouterMatch) MatchMethod(method string) bool {
+	if len(rm.Methods) == 0 {
+		return true
+	}
+	for _, m := range rm.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPath match the path by regex, path or prefix, any path matches if none of them configured
+func (rm *RouterMatch) MatchPath(path string) bool {
+	switch {
+	case rm.Regex != "":
+		return rm.pathRE != nil && rm.pathRE.MatchString(path)
+	case rm.Path != "":
+		if rm.IgnoreCase {
+			return strings.EqualFold(rm.Path, path)
+		}
+		return rm.Path == path
+	case rm.Prefix != "":
+		if len(path) < len(rm.Prefix) {
+			return false
+		}
+		if rm.IgnoreCase {
+			return strings.EqualFold(rm.Prefix, path[:len(rm.Prefix)])
+		}
+		return strings.HasPrefix(path, rm.Prefix)
+	default:
+		return true
+	}
+}
+
+// Match match the values of the query parameter, including regex type
+func (qm *QueryParameterMatcher) Match(query url.Values) bool {
+	values, ok := query[qm.Name]
+	if !ok {
+		return false
+	}
+	if len(qm.Values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if qm.Regex && qm.valueRE != nil {
+			if qm.valueRE.MatchString(v) {
+				return true
+			}
+			continue
+		}
+		for _, src := range qm.Values {
+			if src == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetValueRegex compile the regex, disable regex if it failed
+func (qm *QueryParameterMatcher) SetValueRegex(regex string) error {
+	r, err := regexp.Compile(regex)
+	if err == nil {
+		qm.valueRE = r
+		return nil
+	}
+	qm.Regex = false
+	return err
+}
+
 // MatchValues match values in header, including regex type
 func (hm *HeaderMatcher) MatchValues(dst string) bool {
 	if hm.Regex && hm.valueRE != nil {
@@ -146,7 +334,9 @@ func (hm *HeaderMatcher) SetValueRegex(regex string) error {
 func (r *Router) String() string {
 	var builder strings.Builder
 	builder.WriteString("[" + strings.Join(r.Match.Methods, ",") + "] ")
-	if r.Match.Prefix != "" {
+	if r.Match.Regex != "" {
+		builder.WriteString("regex " + r.Match.Regex)
+	} else if r.Match.Prefix != "" {
 		builder.WriteString("prefix " + r.Match.Prefix)
 	} else {
 		builder.WriteString("path " + r.Match.Path)
@@ -0,0 +1,44 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"time"
+)
+
+type (
+	// TcpProxyConfig the config of tcp proxy network filter
+	TcpProxyConfig struct {
+		// StatPrefix the prefix attached to the metrics of the proxy
+		StatPrefix string `yaml:"stat_prefix" json:"stat_prefix" mapstructure:"stat_prefix"`
+		// Cluster the upstream cluster, ignored when WeightedClusters is set
+		Cluster           string             `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
+		WeightedClusters  []*WeightedCluster `yaml:"weighted_clusters" json:"weighted_clusters" mapstructure:"weighted_clusters"`
+		ConnectTimeoutStr string             `yaml:"connect_timeout" json:"connect_timeout" mapstructure:"connect_timeout"`
+		ConnectTimeout    time.Duration      `yaml:"-" json:"-" mapstructure:"-"`
+		// IdleTimeoutStr the connection is closed when no bytes in both directions within the timeout
+		IdleTimeoutStr string        `yaml:"idle_timeout" json:"idle_timeout" mapstructure:"idle_timeout"`
+		IdleTimeout    time.Duration `yaml:"-" json:"-" mapstructure:"-"`
+	}
+
+	// WeightedCluster a cluster with the weight of traffic it receives
+	WeightedCluster struct {
+		Name   string `yaml:"name" json:"name" mapstructure:"name"`
+		Weight int    `yaml:"weight" json:"weight" mapstructure:"weight"`
+	}
+)
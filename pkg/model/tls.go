@@ -0,0 +1,66 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"time"
+)
+
+type (
+	// TlsConfig the tls config of downstream listener. The triple listener accepts exactly one certificate without
+	// server_names and client_auth none or require_and_verify only, it rejects min_version, max_version,
+	// cipher_suites and reload_interval
+	TlsConfig struct {
+		// Certificates the first one is used when no certificate matches the SNI
+		Certificates []*TlsCertificate `yaml:"certificates" json:"certificates" mapstructure:"certificates"`
+		// MinVersion TLSv1_0, TLSv1_1, TLSv1_2 or TLSv1_3
+		MinVersion   string   `yaml:"min_version" json:"min_version" mapstructure:"min_version"`
+		MaxVersion   string   `yaml:"max_version" json:"max_version" mapstructure:"max_version"`
+		CipherSuites []string `yaml:"cipher_suites" json:"cipher_suites" mapstructure:"cipher_suites"`
+		// ReloadIntervalStr the interval to check whether the certificate files are changed
+		ReloadIntervalStr string        `yaml:"reload_interval" json:"reload_interval" mapstructure:"reload_interval"`
+		ReloadInterval    time.Duration `yaml:"-" json:"-" mapstructure:"-"`
+		// ClientAuth none, request, require, verify_if_given or require_and_verify, the default is none.
+		// use request with the mtls filter to validate client certificates per route
+		ClientAuth string `yaml:"client_auth" json:"client_auth" mapstructure:"client_auth"`
+		// ClientCACertFile the CA bundle to verify client certificates, required by verify_if_given and require_and_verify
+		ClientCACertFile string `yaml:"client_ca_cert_file" json:"client_ca_cert_file" mapstructure:"client_ca_cert_file"`
+	}
+
+	// TlsCertificate a certificate and key pair
+	TlsCertificate struct {
+		CertFile string `yaml:"cert_file" json:"cert_file" mapstructure:"cert_file"`
+		KeyFile  string `yaml:"key_file" json:"key_file" mapstructure:"key_file"`
+		// ServerNames the SNI served by the certificate, wildcard like *.example.com is supported.
+		// the names in certificate are used if it is empty
+		ServerNames []string `yaml:"server_names" json:"server_names" mapstructure:"server_names"`
+	}
+
+	// UpstreamTlsConfig the tls config used to connect to the endpoints of cluster
+	UpstreamTlsConfig struct {
+		// CACertFile the CA bundle to verify the upstream certificate, system roots are used if it is empty
+		CACertFile string `yaml:"ca_cert_file" json:"ca_cert_file" mapstructure:"ca_cert_file"`
+		// CertFile and KeyFile the client certificate for mutual tls
+		CertFile string `yaml:"cert_file" json:"cert_file" mapstructure:"cert_file"`
+		KeyFile  string `yaml:"key_file" json:"key_file" mapstructure:"key_file"`
+		// ServerName override the SNI and the name to verify, the host of endpoint is used by default
+		ServerName         string `yaml:"server_name" json:"server_name" mapstructure:"server_name"`
+		InsecureSkipVerify bool   `yaml:"insecure_skip_verify" json:"insecure_skip_verify" mapstructure:"insecure_skip_verify"`
+		MinVersion         string `yaml:"min_version" json:"min_version" mapstructure:"min_version"`
+	}
+)
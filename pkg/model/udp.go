@@ -0,0 +1,33 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"time"
+)
+
+// UdpProxyConfig the config of udp proxy network filter
+type UdpProxyConfig struct {
+	// Cluster the upstream cluster which datagrams are forwarded to
+	Cluster string `yaml:"cluster" json:"cluster" mapstructure:"cluster"`
+	// IdleTimeoutStr a session is removed when no datagram in both directions within the timeout
+	IdleTimeoutStr string        `yaml:"idle_timeout" json:"idle_timeout" mapstructure:"idle_timeout"`
+	IdleTimeout    time.Duration `yaml:"-" json:"-" mapstructure:"-"`
+	// MaxSessions the max client sessions, 0 means no limit
+	MaxSessions int `yaml:"max_sessions" json:"max_sessions" mapstructure:"max_sessions"`
+}
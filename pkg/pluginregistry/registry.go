@@ -20,12 +20,18 @@ package pluginregistry
 import (
 	_ "github.com/apache/dubbo-go-pixiu/pkg/adapter/dubboregistry"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/adapter/springcloud"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/leastrequest"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/maglev"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/p2c"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/rand"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/ringhash"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/roundrobin"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/weightedroundrobin"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/accesslog"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/auth/apikey"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/auth/extauthz"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/auth/jwt"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/auth/mtls"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/authority"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/cors"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/csrf"
@@ -45,11 +51,16 @@ import (
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/network/dubboproxy/filter/proxy"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/network/grpcconnectionmanager"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/network/httpconnectionmanager"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/network/tcpproxy"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/network/udpproxy"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/prometheus"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/ratelimit/global"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/ratelimit/local"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/tracing"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/filter/traffic"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/listener/http"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/listener/http2"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/listener/tcp"
 	_ "github.com/apache/dubbo-go-pixiu/pkg/listener/triple"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/listener/udp"
 )
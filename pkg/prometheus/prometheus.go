@@ -350,6 +350,11 @@ func (p *Prometheus) HandlerFunc() ContextHandlerFunc {
 		resSz, err2 := computeApproximateResponseSize(c.TargetResp)
 		if err2 == nil {
 			p.resSz.WithLabelValues(statusStr, method, url).Observe(float64(resSz))
+		} else if !c.LocalReply() {
+			// the response is not built yet or its body is streamed, observe it once it is written
+			c.OnResponseWritten(func(size int64) {
+				p.resSz.WithLabelValues(statusStr, method, url).Observe(float64(size))
+			})
 		}
 		p.Ppg.mutex.Lock()
 		p.Ppg.counter = p.Ppg.counter + 1
@@ -26,6 +26,7 @@ import (
 
 import (
 	"github.com/nacos-group/nacos-sdk-go/clients"
+	"github.com/nacos-group/nacos-sdk-go/clients/config_client"
 	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
 	"github.com/nacos-group/nacos-sdk-go/common/constant"
 	model2 "github.com/nacos-group/nacos-sdk-go/model"
@@ -59,18 +60,9 @@ func (client *NacosClient) Unsubscribe(param *vo.SubscribeParam) error {
 
 func NewNacosClient(config *model.RemoteConfig) (*NacosClient, error) {
 	configMap := make(map[string]interface{}, 2)
-	addresses := strings.Split(config.Address, ",")
-	serverConfigs := make([]constant.ServerConfig, 0, len(addresses))
-	for _, addr := range addresses {
-		ip, portStr, err := net.SplitHostPort(addr)
-		if err != nil {
-			return nil, perrors.WithMessagef(err, "split [%s] ", addr)
-		}
-		port, _ := strconv.Atoi(portStr)
-		serverConfigs = append(serverConfigs, constant.ServerConfig{
-			IpAddr: ip,
-			Port:   uint64(port),
-		})
+	serverConfigs, err := newServerConfigs(config.Address)
+	if err != nil {
+		return nil, err
 	}
 	configMap["serverConfigs"] = serverConfigs
 
@@ -91,3 +83,46 @@ func NewNacosClient(config *model.RemoteConfig) (*NacosClient, error) {
 	}
 	return &NacosClient{client}, nil
 }
+
+// NewNacosConfigClient create the client of nacos config center in the namespace
+func NewNacosConfigClient(config *model.RemoteConfig, namespace string) (config_client.IConfigClient, error) {
+	serverConfigs, err := newServerConfigs(config.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	duration, _ := time.ParseDuration(config.Timeout)
+	client, err := clients.NewConfigClient(
+		vo.NacosClientParam{
+			ClientConfig: constant.NewClientConfig(
+				constant.WithTimeoutMs(uint64(duration.Milliseconds())),
+				constant.WithNamespaceId(namespace),
+				constant.WithUsername(config.Username),
+				constant.WithPassword(config.Password),
+				constant.WithNotLoadCacheAtStart(true),
+			),
+			ServerConfigs: serverConfigs,
+		},
+	)
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "nacos config client create error")
+	}
+	return client, nil
+}
+
+func newServerConfigs(address string) ([]constant.ServerConfig, error) {
+	addresses := strings.Split(address, ",")
+	serverConfigs := make([]constant.ServerConfig, 0, len(addresses))
+	for _, addr := range addresses {
+		ip, portStr, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "split [%s] ", addr)
+		}
+		port, _ := strconv.Atoi(portStr)
+		serverConfigs = append(serverConfigs, constant.ServerConfig{
+			IpAddr: ip,
+			Port:   uint64(port),
+		})
+	}
+	return serverConfigs, nil
+}
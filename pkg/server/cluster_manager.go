@@ -18,13 +18,21 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
 )
 
 import (
 	"github.com/apache/dubbo-go-pixiu/pkg/cluster"
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/dns"
 	"github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer"
 	"github.com/apache/dubbo-go-pixiu/pkg/common/yaml"
 	"github.com/apache/dubbo-go-pixiu/pkg/logger"
@@ -40,6 +48,10 @@ type (
 		rw sync.RWMutex
 
 		store *ClusterStore
+		// locality where pixiu is deployed, used by locality aware load balancing
+		locality *model.Locality
+		// resolver resolve the hostnames of dns clusters
+		resolver dns.Resolver
 		//cConfig []*model.ClusterConfig
 	}
 
@@ -50,6 +62,14 @@ type (
 		clustersMap map[string]*cluster.Cluster
 	}
 
+	// dnsUpdater apply the endpoints resolved by dns discovery to the cluster store,
+	// the changes are dropped once the cluster is removed or the discovery is replaced
+	dnsUpdater struct {
+		cm        *ClusterManager
+		cluster   *cluster.Cluster
+		discovery *dns.Discovery
+	}
+
 	// xdsControlStore help convert ClusterStore to controls.ClusterStore interface
 	xdsControlStore struct {
 		*ClusterStore
@@ -67,7 +87,14 @@ func (cm *ClusterManager) CloneXdsControlStore() (controls.ClusterStore, error)
 }
 
 func CreateDefaultClusterManager(bs *model.Bootstrap) *ClusterManager {
-	return &ClusterManager{store: newClusterStore(bs)}
+	cm := &ClusterManager{store: newClusterStore(bs), resolver: dns.NewResolver()}
+	if bs.Node != nil {
+		cm.locality = bs.Node.Locality
+	}
+	for _, c := range cm.store.Config {
+		cm.startDiscovery(c)
+	}
+	return cm
 }
 
 func newClusterStore(bs *model.Bootstrap) *ClusterStore {
@@ -86,6 +113,7 @@ func (cm *ClusterManager) AddCluster(c *model.ClusterConfig) {
 
 	cm.store.IncreaseVersion()
 	cm.store.AddCluster(c)
+	cm.startDiscovery(c)
 }
 
 func (cm *ClusterManager) UpdateCluster(new *model.ClusterConfig) {
@@ -94,6 +122,54 @@ func (cm *ClusterManager) UpdateCluster(new *model.ClusterConfig) {
 
 	cm.store.IncreaseVersion()
 	cm.store.UpdateCluster(new)
+	cm.startDiscovery(new)
+}
+
+// startDiscovery start to resolve the hostnames if it is a dns cluster, the previous discovery is stopped.
+// the lock must be held by caller
+func (cm *ClusterManager) startDiscovery(c *model.ClusterConfig) {
+	cl := cm.store.clustersMap[c.Name]
+	if cl == nil {
+		return
+	}
+	if cl.Dns != nil {
+		cl.Dns.Stop()
+		cl.Dns = nil
+	}
+	updater := &dnsUpdater{cm: cm, cluster: cl}
+	d := dns.NewDiscovery(c, cm.resolver, updater)
+	if d == nil {
+		return
+	}
+	updater.discovery = d
+	cl.Dns = d
+	d.Start()
+}
+
+func (u *dnsUpdater) SetEndpoint(clusterName string, endpoint *model.Endpoint) {
+	u.cm.rw.Lock()
+	defer u.cm.rw.Unlock()
+
+	if !u.active(clusterName) {
+		return
+	}
+	u.cm.store.IncreaseVersion()
+	u.cm.store.SetEndpoint(clusterName, endpoint)
+}
+
+func (u *dnsUpdater) DeleteEndpoint(clusterName string, endpointID string) {
+	u.cm.rw.Lock()
+	defer u.cm.rw.Unlock()
+
+	if !u.active(clusterName) {
+		return
+	}
+	u.cm.store.IncreaseVersion()
+	u.cm.store.DeleteEndpoint(clusterName, endpointID)
+}
+
+func (u *dnsUpdater) active(clusterName string) bool {
+	return u.cm.store.clustersMap[clusterName] == u.cluster && u.cluster.Dns == u.discovery
 }
 
 func (cm *ClusterManager) SetEndpoint(clusterName string, endpoint *model.Endpoint) {
@@ -155,6 +231,12 @@ func (cm *ClusterManager) PickEndpoint(clusterName string, policy model.LbPolicy
 
 	for _, c := range cm.store.Config {
 		if c.Name == clusterName {
+			if c.LbSubsetConfig != nil || c.LocalityLbConfig != nil {
+				c = cm.store.selectEndpoints(c, policy, cm.locality)
+				if c == nil {
+					return nil
+				}
+			}
 			return cm.pickOneEndpoint(c, policy)
 		}
 	}
@@ -167,7 +249,7 @@ func (cm *ClusterManager) pickOneEndpoint(c *model.ClusterConfig, policy model.L
 	}
 
 	if len(c.Endpoints) == 1 {
-		if !c.Endpoints[0].UnHealthy {
+		if c.Endpoints[0].IsAvailable() {
 			return c.Endpoints[0]
 		}
 		return nil
@@ -180,6 +262,113 @@ func (cm *ClusterManager) pickOneEndpoint(c *model.ClusterConfig, policy model.L
 	return loadbalancer.LoadBalancerStrategy[model.LoadBalancerRand].Handler(c, policy)
 }
 
+// ReportResult report the result of an upstream call for outlier detection
+func (cm *ClusterManager) ReportResult(clusterName string, endpoint *model.Endpoint, result cluster.Result) {
+	if endpoint == nil {
+		return
+	}
+	cm.rw.RLock()
+	c := cm.store.clustersMap[clusterName]
+	var detector *cluster.OutlierDetector
+	if c != nil {
+		detector = c.Outlier
+	}
+	cm.rw.RUnlock()
+	if detector == nil {
+		return
+	}
+
+	duration, eject := detector.Report(endpoint, result)
+	if !eject {
+		return
+	}
+
+	cm.rw.Lock()
+	defer cm.rw.Unlock()
+	// the cluster may be updated or removed while reporting
+	if endpoint.Ejected || cm.store.clustersMap[clusterName] != c || c.Outlier != detector {
+		return
+	}
+	ejected := 0
+	for _, e := range c.Config.Endpoints {
+		if e.Ejected {
+			ejected++
+		}
+	}
+	if !detector.CanEject(ejected, len(c.Config.Endpoints)) {
+		logger.Debugf("[dubbo-go-pixiu] outlier detection reach max ejection percent, skip ejecting %s in cluster %s", endpoint.GetHost(), clusterName)
+		return
+	}
+	endpoint.Ejected = true
+	detector.OnEject(endpoint)
+	logger.Infof("[dubbo-go-pixiu] outlier detection eject %s in cluster %s for %s", endpoint.GetHost(), clusterName, duration)
+
+	time.AfterFunc(duration, func() {
+		cm.rw.Lock()
+		defer cm.rw.Unlock()
+		// the ejection is cleared when the detector is rebuilt or the cluster is removed
+		if cm.store.clustersMap[clusterName] != c || c.Outlier != detector {
+			return
+		}
+		endpoint.Ejected = false
+		detector.OnUneject(endpoint)
+		logger.Infof("[dubbo-go-pixiu] outlier detection uneject %s in cluster %s", endpoint.GetHost(), clusterName)
+	})
+}
+
+// AcquireResource take a resource of the cluster limited by circuit breakers, release must be called when it is
+// no longer used. *cluster.OverflowError is returned if the limit is exceeded
+func (cm *ClusterManager) AcquireResource(ctx context.Context, clusterName string, resource cluster.Resource) (release func(), err error) {
+	cm.rw.RLock()
+	c := cm.store.clustersMap[clusterName]
+	var cfg *model.CircuitBreakers
+	for _, cc := range cm.store.Config {
+		if cc.Name == clusterName {
+			cfg = cc.CircuitBreakers
+			break
+		}
+	}
+	cm.rw.RUnlock()
+	if c == nil || cfg == nil {
+		return func() {}, nil
+	}
+	return c.Breaker.Acquire(ctx, clusterName, cfg, resource)
+}
+
+// UpstreamTlsConfig return the client tls config of the cluster, nil if the cluster doesn't use tls
+func (cm *ClusterManager) UpstreamTlsConfig(clusterName string) (*tls.Config, error) {
+	cm.rw.RLock()
+	c := cm.store.clustersMap[clusterName]
+	var cfg *model.UpstreamTlsConfig
+	for _, cc := range cm.store.Config {
+		if cc.Name == clusterName {
+			cfg = cc.Tls
+			break
+		}
+	}
+	cm.rw.RUnlock()
+	if c == nil || cfg == nil {
+		return nil, nil
+	}
+	tlsConfig, err := c.TlsConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create tls config of cluster %s", clusterName)
+	}
+	return tlsConfig, nil
+}
+
+// UpstreamTlsFiles return the tls config of cluster as it is configured, for the clients which only accept certificate files
+func (cm *ClusterManager) UpstreamTlsFiles(clusterName string) *model.UpstreamTlsConfig {
+	cm.rw.RLock()
+	defer cm.rw.RUnlock()
+	for _, cc := range cm.store.Config {
+		if cc.Name == clusterName {
+			return cc.Tls
+		}
+	}
+	return nil
+}
+
 func (cm *ClusterManager) RemoveCluster(namesToDel []string) {
 	cm.rw.Lock()
 	defer cm.rw.Unlock()
@@ -192,6 +381,7 @@ func (cm *ClusterManager) RemoveCluster(namesToDel []string) {
 			if name == c.Name {
 				removed := cm.store.Config[i]
 				cm.store.clustersMap[removed.Name].Stop()
+				loadbalancer.RemoveCluster(removed.Name)
 				cm.store.Config[i] = nil
 				delete(cm.store.clustersMap, removed.Name)
 			}
@@ -229,6 +419,9 @@ func (s *ClusterStore) UpdateCluster(new *model.ClusterConfig) {
 	for i, c := range s.Config {
 		if c.Name == new.Name {
 			s.Config[i] = new
+			if cluster := s.clustersMap[new.Name]; cluster != nil {
+				cluster.Update(new)
+			}
 			return
 		}
 	}
@@ -252,16 +445,21 @@ func (s *ClusterStore) SetEndpoint(clusterName string, endpoint *model.Endpoint)
 					e.Name = endpoint.Name
 					e.Metadata = endpoint.Metadata
 					e.Address = endpoint.Address
+					e.Weight = endpoint.Weight
 					cluster.AddEndpoint(e)
+					// the address or weight may change, re-place the endpoints in consistent hash
+					c.CreateConsistentHash()
+					cluster.UpdateEndpoints(c)
 					return
 				}
 			}
 			// endpoint create
+			endpoint.StartWarmup()
 			c.Endpoints = append(c.Endpoints, endpoint)
 			cluster.AddEndpoint(endpoint)
-			if c.ConsistentHash.Hash != nil {
-				c.ConsistentHash.Hash.Add(endpoint.GetHost())
-			}
+			// rebuild rather than add the host, since the normalized weights of all endpoints may change
+			c.CreateConsistentHash()
+			cluster.UpdateEndpoints(c)
 			return
 		}
 	}
@@ -278,9 +476,9 @@ func (s *ClusterStore) DeleteEndpoint(clusterName string, endpointID string) {
 				if e.ID == endpointID {
 					cluster.RemoveEndpoint(e)
 					c.Endpoints = append(c.Endpoints[:i], c.Endpoints[i+1:]...)
-					if c.ConsistentHash.Hash != nil {
-						c.ConsistentHash.Hash.Remove(e.GetHost())
-					}
+					// rebuild to drop the weighted copies of the host as well
+					c.CreateConsistentHash()
+					cluster.UpdateEndpoints(c)
 					return
 				}
 			}
@@ -291,6 +489,29 @@ func (s *ClusterStore) DeleteEndpoint(clusterName string, endpointID string) {
 	logger.Warnf("not found cluster %s", clusterName)
 }
 
+// selectEndpoints narrow the endpoints of cluster down to the subset matched by the metadata of policy,
+// then to the healthy priority level and locality
+func (s *ClusterStore) selectEndpoints(c *model.ClusterConfig, policy model.LbPolicy, locality *model.Locality) *model.ClusterConfig {
+	cluster := s.clustersMap[c.Name]
+	if cluster == nil {
+		return c
+	}
+	selected := c
+	if c.LbSubsetConfig != nil {
+		var match map[string]string
+		if p, ok := policy.(model.MetadataMatchLbPolicy); ok {
+			match = p.MetadataMatch()
+		}
+		if selected = cluster.Subset(c, match); selected == nil {
+			return nil
+		}
+	}
+	if c.LocalityLbConfig != nil {
+		selected = cluster.Locality(selected, c.LocalityLbConfig, locality)
+	}
+	return selected
+}
+
 func (s *ClusterStore) HasCluster(clusterName string) bool {
 	for _, c := range s.Config {
 		if c.Name == clusterName {
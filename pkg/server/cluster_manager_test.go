@@ -18,7 +18,12 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
 	"testing"
+	"time"
 )
 
 import (
@@ -26,6 +31,12 @@ import (
 )
 
 import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster"
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster/dns"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/maglev"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/ringhash"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/roundrobin"
+	_ "github.com/apache/dubbo-go-pixiu/pkg/cluster/loadbalancer/weightedroundrobin"
 	"github.com/apache/dubbo-go-pixiu/pkg/model"
 )
 
@@ -68,3 +79,529 @@ func TestClusterManager(t *testing.T) {
 	assert.Equal(t, cm.PickEndpoint("test", nil).ID, "1")
 	cm.DeleteEndpoint("test2", "1")
 }
+
+func TestOutlierDetection(t *testing.T) {
+	bs := &model.Bootstrap{
+		StaticResources: model.StaticResources{
+			Clusters: []*model.ClusterConfig{
+				{
+					Name:  "test",
+					LbStr: model.LoadBalancerRoundRobin,
+					OutlierDetection: &model.OutlierDetection{
+						Consecutive5xx:     2,
+						BaseEjectionTime:   "100ms",
+						MaxEjectionPercent: 50,
+					},
+					Endpoints: []*model.Endpoint{
+						{ID: "1", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8081}},
+						{ID: "2", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8082}},
+					},
+				},
+			},
+		},
+	}
+	cm := CreateDefaultClusterManager(bs)
+	e1 := cm.store.Config[0].Endpoints[0]
+	e2 := cm.store.Config[0].Endpoints[1]
+
+	cm.ReportResult("test", e1, cluster.ResultServerError)
+	cm.ReportResult("test", e1, cluster.ResultSuccess)
+	cm.ReportResult("test", e1, cluster.ResultServerError)
+	assert.False(t, e1.Ejected)
+
+	cm.ReportResult("test", e1, cluster.ResultConnectFailure)
+	assert.True(t, e1.Ejected)
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, "2", cm.PickEndpoint("test", nil).ID)
+	}
+
+	// max ejection percent guard, at least half of endpoints stay
+	cm.ReportResult("test", e2, cluster.ResultServerError)
+	cm.ReportResult("test", e2, cluster.ResultServerError)
+	assert.False(t, e2.Ejected)
+
+	time.Sleep(300 * time.Millisecond)
+	cm.rw.RLock()
+	assert.False(t, e1.Ejected)
+	cm.rw.RUnlock()
+}
+
+func TestOutlierDetectionDefaultPercent(t *testing.T) {
+	bs := &model.Bootstrap{
+		StaticResources: model.StaticResources{
+			Clusters: []*model.ClusterConfig{
+				{
+					Name:             "test",
+					LbStr:            model.LoadBalancerRoundRobin,
+					OutlierDetection: &model.OutlierDetection{Consecutive5xx: 1},
+					Endpoints: []*model.Endpoint{
+						{ID: "1", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8081}},
+						{ID: "2", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8082}},
+						{ID: "3", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8083}},
+					},
+				},
+			},
+		},
+	}
+	cm := CreateDefaultClusterManager(bs)
+	e1 := cm.store.Config[0].Endpoints[0]
+	e2 := cm.store.Config[0].Endpoints[1]
+
+	// the default 10% of 3 endpoints rounds down to 0, one endpoint can be ejected anyway
+	cm.ReportResult("test", e1, cluster.ResultServerError)
+	assert.True(t, e1.Ejected)
+
+	cm.ReportResult("test", e2, cluster.ResultServerError)
+	assert.False(t, e2.Ejected)
+}
+
+func TestOutlierDetectionClusterUpdate(t *testing.T) {
+	bs := &model.Bootstrap{
+		StaticResources: model.StaticResources{
+			Clusters: []*model.ClusterConfig{
+				{
+					Name:             "test",
+					OutlierDetection: &model.OutlierDetection{Consecutive5xx: 1, BaseEjectionTime: "100ms"},
+					Endpoints: []*model.Endpoint{
+						{ID: "1", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8081}},
+						{ID: "2", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8082}},
+					},
+				},
+			},
+		},
+	}
+	cm := CreateDefaultClusterManager(bs)
+	old := cm.store.Config[0].Endpoints[0]
+	cm.ReportResult("test", old, cluster.ResultServerError)
+	assert.True(t, old.Ejected)
+
+	endpoints := []*model.Endpoint{
+		{ID: "1", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8081}},
+		{ID: "2", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8082}},
+		{ID: "3", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8083}},
+		{ID: "4", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8084}},
+	}
+	cm.UpdateCluster(&model.ClusterConfig{
+		Name:             "test",
+		OutlierDetection: &model.OutlierDetection{Consecutive5xx: 2, BaseEjectionTime: "1h", MaxEjectionPercent: 50},
+		Endpoints:        endpoints,
+	})
+
+	// the new threshold is used
+	cm.ReportResult("test", endpoints[0], cluster.ResultServerError)
+	assert.False(t, endpoints[0].Ejected)
+	cm.ReportResult("test", endpoints[0], cluster.ResultServerError)
+	assert.True(t, endpoints[0].Ejected)
+
+	// the percent guard counts the endpoints of the new config, 2 of 4 can be ejected
+	cm.ReportResult("test", endpoints[1], cluster.ResultServerError)
+	cm.ReportResult("test", endpoints[1], cluster.ResultServerError)
+	assert.True(t, endpoints[1].Ejected)
+
+	// the un-eject timer of the replaced detector is ignored
+	time.Sleep(300 * time.Millisecond)
+	cm.rw.RLock()
+	assert.True(t, old.Ejected)
+	assert.True(t, endpoints[0].Ejected)
+	cm.rw.RUnlock()
+}
+
+type hashPolicy string
+
+func (p hashPolicy) GenerateHash() string {
+	return string(p)
+}
+
+func TestConsistentHashEndpointChange(t *testing.T) {
+	for _, lb := range []model.LbPolicyType{model.LoadBalancerRingHashing, model.LoadBalancerMaglevHashing} {
+		bs := &model.Bootstrap{
+			StaticResources: model.StaticResources{
+				Clusters: []*model.ClusterConfig{
+					{
+						Name:  "test",
+						LbStr: lb,
+						Endpoints: []*model.Endpoint{
+							{ID: "1", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8081}},
+						},
+					},
+				},
+			},
+		}
+		cm := CreateDefaultClusterManager(bs)
+		distribution := func() map[string]int {
+			dist := make(map[string]int)
+			for i := 0; i < 2000; i++ {
+				e := cm.PickEndpoint("test", hashPolicy("key"+strconv.Itoa(i)))
+				assert.NotNil(t, e)
+				dist[e.ID]++
+			}
+			return dist
+		}
+
+		// the weight of the endpoint added at runtime is respected
+		cm.SetEndpoint("test", &model.Endpoint{ID: "2", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8082}, Weight: 3})
+		dist := distribution()
+		assert.InDelta(t, 1500, dist["2"], 200, "%s %v", lb, dist)
+
+		// re-placed after the weight is updated
+		cm.SetEndpoint("test", &model.Endpoint{ID: "2", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8082}, Weight: 1})
+		dist = distribution()
+		assert.InDelta(t, 1000, dist["2"], 200, "%s %v", lb, dist)
+
+		// no weighted copy of the deleted endpoint is left
+		cm.SetEndpoint("test", &model.Endpoint{ID: "2", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8082}, Weight: 3})
+		cm.SetEndpoint("test", &model.Endpoint{ID: "3", Address: model.SocketAddress{Address: "127.0.0.1", Port: 8083}})
+		cm.DeleteEndpoint("test", "2")
+		dist = distribution()
+		assert.Equal(t, 0, dist["2"], "%s %v", lb, dist)
+		assert.InDelta(t, 1000, dist["3"], 200, "%s %v", lb, dist)
+	}
+}
+
+type metadataPolicy map[string]string
+
+func (p metadataPolicy) GenerateHash() string {
+	return ""
+}
+
+func (p metadataPolicy) MetadataMatch() map[string]string {
+	return p
+}
+
+func TestSubsetLoadBalance(t *testing.T) {
+	newBootstrap := func(fallback string) *model.Bootstrap {
+		return &model.Bootstrap{
+			StaticResources: model.StaticResources{
+				Clusters: []*model.ClusterConfig{
+					{
+						Name:  "test",
+						LbStr: model.LoadBalancerRoundRobin,
+						LbSubsetConfig: &model.LbSubsetConfig{
+							FallbackPolicy:  fallback,
+							DefaultSubset:   map[string]string{"version": "v1"},
+							SubsetSelectors: []model.SubsetSelector{{Keys: []string{"version"}}, {Keys: []string{"version", "region"}}},
+						},
+						Endpoints: []*model.Endpoint{
+							{ID: "1", Metadata: map[string]string{"version": "v1", "region": "hz"}},
+							{ID: "2", Metadata: map[string]string{"version": "v2", "region": "hz"}},
+							{ID: "3", Metadata: map[string]string{"version": "v2", "region": "bj"}},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	cm := CreateDefaultClusterManager(newBootstrap(model.SubsetFallbackNone))
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, "1", cm.PickEndpoint("test", metadataPolicy{"version": "v1"}).ID)
+		assert.Equal(t, "3", cm.PickEndpoint("test", metadataPolicy{"version": "v2", "region": "bj"}).ID)
+		id := cm.PickEndpoint("test", metadataPolicy{"version": "v2"}).ID
+		assert.True(t, id == "2" || id == "3")
+	}
+	assert.Nil(t, cm.PickEndpoint("test", metadataPolicy{"version": "v3"}))
+	assert.Nil(t, cm.PickEndpoint("test", nil))
+
+	// the subsets follow the changes of endpoints
+	cm.SetEndpoint("test", &model.Endpoint{ID: "4", Metadata: map[string]string{"version": "v3"}})
+	assert.Equal(t, "4", cm.PickEndpoint("test", metadataPolicy{"version": "v3"}).ID)
+	cm.DeleteEndpoint("test", "1")
+	assert.Nil(t, cm.PickEndpoint("test", metadataPolicy{"version": "v1"}))
+
+	cm = CreateDefaultClusterManager(newBootstrap(model.SubsetFallbackDefaultSubset))
+	assert.Equal(t, "1", cm.PickEndpoint("test", metadataPolicy{"version": "v3"}).ID)
+	assert.Equal(t, "1", cm.PickEndpoint("test", nil).ID)
+
+	cm = CreateDefaultClusterManager(newBootstrap(""))
+	ids := make(map[string]bool)
+	for i := 0; i < 6; i++ {
+		ids[cm.PickEndpoint("test", metadataPolicy{"version": "v3"}).ID] = true
+	}
+	assert.Equal(t, 3, len(ids))
+
+	// unavailable subset falls back as well
+	cm.store.Config[0].Endpoints[0].Ejected = true
+	assert.NotEqual(t, "1", cm.PickEndpoint("test", metadataPolicy{"version": "v1"}).ID)
+}
+
+func TestLocalityLoadBalance(t *testing.T) {
+	meta := func(region, zone, priority string) map[string]string {
+		return map[string]string{model.EndpointRegionKey: region, model.EndpointZoneKey: zone, model.EndpointPriorityKey: priority}
+	}
+	bs := &model.Bootstrap{
+		Node: &model.Node{Locality: &model.Locality{Region: "r1", Zone: "z1"}},
+		StaticResources: model.StaticResources{
+			Clusters: []*model.ClusterConfig{
+				{
+					Name:             "test",
+					LbStr:            model.LoadBalancerRoundRobin,
+					LocalityLbConfig: &model.LocalityLbConfig{},
+					Endpoints: []*model.Endpoint{
+						{ID: "1", Metadata: meta("r1", "z1", "0")},
+						{ID: "2", Metadata: meta("r1", "z1", "0")},
+						{ID: "3", Metadata: meta("r1", "z2", "0")},
+						{ID: "4", Metadata: meta("r2", "z3", "0")},
+						{ID: "5", Metadata: meta("r2", "z3", "1")},
+					},
+				},
+			},
+		},
+	}
+	cm := CreateDefaultClusterManager(bs)
+	endpoints := cm.store.Config[0].Endpoints
+	picked := func() map[string]bool {
+		ids := make(map[string]bool)
+		for i := 0; i < 8; i++ {
+			ids[cm.PickEndpoint("test", nil).ID] = true
+		}
+		return ids
+	}
+
+	// local zone is preferred
+	assert.Equal(t, map[string]bool{"1": true, "2": true}, picked())
+
+	// local zone and region are below the healthy percent, spill over to the whole priority level
+	endpoints[0].Ejected = true
+	assert.Equal(t, map[string]bool{"2": true, "3": true, "4": true}, picked())
+
+	// priority 0 is degraded, fail over to priority 1
+	endpoints[1].Ejected = true
+	assert.Equal(t, map[string]bool{"5": true}, picked())
+
+	// all priorities are degraded, the first one still having available endpoints is used
+	endpoints[4].UnHealthy = true
+	assert.Equal(t, map[string]bool{"3": true, "4": true}, picked())
+
+	endpoints[2].UnHealthy = true
+	endpoints[3].UnHealthy = true
+	assert.Nil(t, cm.PickEndpoint("test", nil))
+
+	// the new endpoint of local zone takes effect
+	cm.SetEndpoint("test", &model.Endpoint{ID: "6", Metadata: meta("r1", "z1", "0")})
+	assert.Equal(t, map[string]bool{"6": true}, picked())
+}
+
+func TestSubsetSlowStart(t *testing.T) {
+	meta := map[string]string{"version": "v1", model.EndpointRegionKey: "r1", model.EndpointZoneKey: "z1"}
+	tests := []struct {
+		name   string
+		config *model.ClusterConfig
+	}{
+		{
+			name:   "subset",
+			config: &model.ClusterConfig{LbSubsetConfig: &model.LbSubsetConfig{SubsetSelectors: []model.SubsetSelector{{Keys: []string{"version"}}}}},
+		},
+		{
+			name:   "locality",
+			config: &model.ClusterConfig{LocalityLbConfig: &model.LocalityLbConfig{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.config
+			c.Name = "test"
+			c.LbStr = model.LoadBalancerWeightedRoundRobin
+			c.SlowStartConfig = &model.SlowStartConfig{SlowStartWindow: "1h"}
+			c.Endpoints = []*model.Endpoint{{ID: "1", Metadata: meta}}
+			bs := &model.Bootstrap{
+				Node:            &model.Node{Locality: &model.Locality{Region: "r1", Zone: "z1"}},
+				StaticResources: model.StaticResources{Clusters: []*model.ClusterConfig{c}},
+			}
+			cm := CreateDefaultClusterManager(bs)
+			// the new endpoint warms up in the subset or locality as well
+			cm.SetEndpoint("test", &model.Endpoint{ID: "2", Metadata: meta})
+
+			counts := make(map[string]int)
+			for i := 0; i < 110; i++ {
+				counts[cm.PickEndpoint("test", metadataPolicy{"version": "v1"}).ID]++
+			}
+			assert.Equal(t, 100, counts["1"])
+			assert.Equal(t, 10, counts["2"])
+		})
+	}
+}
+
+func TestCircuitBreakers(t *testing.T) {
+	bs := &model.Bootstrap{
+		StaticResources: model.StaticResources{
+			Clusters: []*model.ClusterConfig{
+				{
+					Name: "test",
+					CircuitBreakers: &model.CircuitBreakers{
+						MaxConnections:     1,
+						MaxPendingRequests: 1,
+						MaxRequests:        1,
+					},
+					Endpoints: []*model.Endpoint{{ID: "1"}},
+				},
+				{
+					Name:      "unlimited",
+					Endpoints: []*model.Endpoint{{ID: "1"}},
+				},
+			},
+		},
+	}
+	cm := CreateDefaultClusterManager(bs)
+	ctx := context.Background()
+	breaker := cm.store.clustersMap["test"].Breaker
+
+	release, err := cm.AcquireResource(ctx, "test", cluster.ResourceConnection)
+	assert.Nil(t, err)
+	_, err = cm.AcquireResource(ctx, "test", cluster.ResourceConnection)
+	var overflow *cluster.OverflowError
+	assert.True(t, errors.As(err, &overflow))
+	assert.Equal(t, cluster.ResourceConnection, overflow.Resource)
+	release()
+	// release is idempotent
+	release()
+	assert.Equal(t, uint32(0), breaker.Count(cluster.ResourceConnection))
+	release, err = cm.AcquireResource(ctx, "test", cluster.ResourceConnection)
+	assert.Nil(t, err)
+	release()
+
+	// max retries is unlimited
+	for i := 0; i < 3; i++ {
+		_, err = cm.AcquireResource(ctx, "test", cluster.ResourceRetry)
+		assert.Nil(t, err)
+	}
+
+	// the second request waits as a pending one, the third overflows
+	release, err = cm.AcquireResource(ctx, "test", cluster.ResourceRequest)
+	assert.Nil(t, err)
+	acquired := make(chan error)
+	go func() {
+		r, err := cm.AcquireResource(ctx, "test", cluster.ResourceRequest)
+		if err == nil {
+			defer r()
+		}
+		acquired <- err
+	}()
+	assert.Eventually(t, func() bool {
+		return breaker.Count(cluster.ResourcePendingRequest) == 1
+	}, time.Second, 10*time.Millisecond)
+	_, err = cm.AcquireResource(ctx, "test", cluster.ResourceRequest)
+	assert.True(t, errors.As(err, &overflow))
+	assert.Equal(t, cluster.ResourcePendingRequest, overflow.Resource)
+	assert.Equal(t, uint64(1), breaker.Overflow(cluster.ResourcePendingRequest))
+	release()
+	assert.Nil(t, <-acquired)
+	assert.Equal(t, uint32(0), breaker.Count(cluster.ResourcePendingRequest))
+
+	// the pending request gives up when the context is done
+	release, err = cm.AcquireResource(ctx, "test", cluster.ResourceRequest)
+	assert.Nil(t, err)
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = cm.AcquireResource(timeout, "test", cluster.ResourceRequest)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, uint32(0), breaker.Count(cluster.ResourcePendingRequest))
+	release()
+
+	// the pending request proceeds once the cluster is updated to unlimited max requests
+	release, err = cm.AcquireResource(ctx, "test", cluster.ResourceRequest)
+	assert.Nil(t, err)
+	defer release()
+	go func() {
+		r, err := cm.AcquireResource(ctx, "test", cluster.ResourceRequest)
+		if err == nil {
+			defer r()
+		}
+		acquired <- err
+	}()
+	assert.Eventually(t, func() bool {
+		return breaker.Count(cluster.ResourcePendingRequest) == 1
+	}, time.Second, 10*time.Millisecond)
+	updated := *cm.store.Config[0]
+	updated.CircuitBreakers = &model.CircuitBreakers{MaxPendingRequests: 1}
+	cm.UpdateCluster(&updated)
+	_, err = cm.AcquireResource(ctx, "test", cluster.ResourceConnection)
+	assert.Nil(t, err)
+	select {
+	case err = <-acquired:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "pending request is not woken up")
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err = cm.AcquireResource(ctx, "unlimited", cluster.ResourceRequest)
+		assert.Nil(t, err)
+	}
+}
+
+type staticResolver []string
+
+func (r staticResolver) Resolve(_ context.Context, _ string) ([]dns.Record, error) {
+	records := make([]dns.Record, 0, len(r))
+	for _, ip := range r {
+		records = append(records, dns.Record{IP: net.ParseIP(ip)})
+	}
+	return records, nil
+}
+
+func TestDnsCluster(t *testing.T) {
+	cm := CreateDefaultClusterManager(&model.Bootstrap{})
+	cm.resolver = staticResolver{"10.0.0.1", "10.0.0.2"}
+	cm.AddCluster(&model.ClusterConfig{
+		Name:           "test",
+		TypeStr:        "StrictDNS",
+		LbStr:          model.LoadBalancerRoundRobin,
+		DnsRefreshRate: "20ms",
+		Endpoints: []*model.Endpoint{
+			{ID: "1", Address: model.SocketAddress{Address: "svc.local", Port: 8080}},
+		},
+	})
+
+	assert.Eventually(t, func() bool {
+		cm.rw.RLock()
+		defer cm.rw.RUnlock()
+		return len(cm.store.Config[0].Endpoints) == 2
+	}, time.Second, 10*time.Millisecond)
+	ids := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		e := cm.PickEndpoint("test", nil)
+		ids[e.ID] = true
+		assert.NotEqual(t, "svc.local", e.Address.Address)
+	}
+	assert.Equal(t, map[string]bool{"1@10.0.0.1:8080": true, "1@10.0.0.2:8080": true}, ids)
+
+	// the resolved endpoints are not applied to the removed cluster
+	cm.RemoveCluster([]string{"test"})
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, cm.HasCluster("test"))
+}
+
+func TestDnsClusterWithoutID(t *testing.T) {
+	cm := CreateDefaultClusterManager(&model.Bootstrap{})
+	cm.resolver = staticResolver{"10.0.0.1"}
+	cm.AddCluster(&model.ClusterConfig{
+		Name:           "test",
+		TypeStr:        "StrictDNS",
+		DnsRefreshRate: "20ms",
+		Endpoints: []*model.Endpoint{
+			{Address: model.SocketAddress{Address: "10.0.0.9", Port: 8080}},
+			{Address: model.SocketAddress{Address: "svc.local", Port: 8080}},
+		},
+	})
+
+	assert.Eventually(t, func() bool {
+		cm.rw.RLock()
+		defer cm.rw.RUnlock()
+		for _, e := range cm.store.Config[0].Endpoints {
+			if e.Address.Address == "svc.local" {
+				return false
+			}
+		}
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	// the endpoint of ip is kept, the one of hostname is replaced by the resolved address
+	cm.rw.RLock()
+	endpoints := cm.store.Config[0].Endpoints
+	assert.Len(t, endpoints, 2)
+	assert.Equal(t, "10.0.0.9", endpoints[0].Address.Address)
+	assert.Equal(t, "svc.local:8080@10.0.0.1:8080", endpoints[1].ID)
+	assert.Equal(t, "10.0.0.1", endpoints[1].Address.Address)
+	cm.rw.RUnlock()
+	cm.RemoveCluster([]string{"test"})
+}
@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+)
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster"
+)
+
+// GrpcConnIdleTimeout the grpc connection unused for it is closed, e.g. the endpoint is removed from the cluster
+const GrpcConnIdleTimeout = 5 * time.Minute
+
+// DialFunc the func to dial a connection to upstream
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// clusterContextKey the context key of the cluster which the upstream request is sent to
+type clusterContextKey struct{}
+
+// WithCluster the new connection dialed for the request is counted by the cluster
+func WithCluster(ctx context.Context, clusterName string) context.Context {
+	return context.WithValue(ctx, clusterContextKey{}, clusterName)
+}
+
+// LimitConnections wrap the dial func to apply the max connections of cluster circuit breakers,
+// the cluster is taken from the context set by WithCluster
+func LimitConnections(dial DialFunc) DialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		clusterName, ok := ctx.Value(clusterContextKey{}).(string)
+		if !ok {
+			return dial(ctx, network, addr)
+		}
+		release, err := GetClusterManager().AcquireResource(ctx, clusterName, cluster.ResourceConnection)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		return &limitedConn{Conn: conn, release: release}, nil
+	}
+}
+
+// limitedConn release the connection resource of cluster when it is closed
+type limitedConn struct {
+	net.Conn
+	release func()
+}
+
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.release()
+	return err
+}
+
+type (
+	// GrpcConnections the grpc connections to upstream by address and tls config
+	GrpcConnections struct {
+		mu        sync.Mutex
+		conns     map[grpcConnKey]*grpcConn
+		lastEvict time.Time
+	}
+
+	grpcConnKey struct {
+		address   string
+		tlsConfig *tls.Config
+	}
+
+	grpcConn struct {
+		*grpc.ClientConn
+		lastUsed time.Time
+	}
+)
+
+func NewGrpcConnections() *GrpcConnections {
+	return &GrpcConnections{conns: map[grpcConnKey]*grpcConn{}}
+}
+
+// Get the connection to address, plaintext if tlsConfig is nil, the connection is created without blocking
+// and reused by later calls
+func (c *GrpcConnections) Get(address string, tlsConfig *tls.Config, now time.Time) (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict(now)
+
+	key := grpcConnKey{address: address, tlsConfig: tlsConfig}
+	if cc, ok := c.conns[key]; ok {
+		cc.lastUsed = now
+		return cc.ClientConn, nil
+	}
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	cc, err := grpc.Dial(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	c.conns[key] = &grpcConn{ClientConn: cc, lastUsed: now}
+	return cc, nil
+}
+
+// evict close the connections idle for GrpcConnIdleTimeout, e.g. to the removed endpoints or with the replaced tls config,
+// the connections are checked at most once a minute
+func (c *GrpcConnections) evict(now time.Time) {
+	if now.Sub(c.lastEvict) < time.Minute {
+		return
+	}
+	c.lastEvict = now
+	for key, cc := range c.conns {
+		if now.Sub(cc.lastUsed) >= GrpcConnIdleTimeout {
+			_ = cc.Close()
+			delete(c.conns, key)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/apache/dubbo-go-pixiu/pkg/cluster"
+	"github.com/apache/dubbo-go-pixiu/pkg/model"
+)
+
+func TestLimitConnections(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			if _, err := l.Accept(); err != nil {
+				return
+			}
+		}
+	}()
+
+	bs := &model.Bootstrap{
+		StaticResources: model.StaticResources{
+			Clusters: []*model.ClusterConfig{
+				{Name: "test", CircuitBreakers: &model.CircuitBreakers{MaxConnections: 1}},
+			},
+		},
+	}
+	old := server
+	server = &Server{clusterManager: CreateDefaultClusterManager(bs)}
+	defer func() { server = old }()
+
+	dial := LimitConnections((&net.Dialer{}).DialContext)
+	ctx := WithCluster(context.Background(), "test")
+	conn, err := dial(ctx, "tcp", l.Addr().String())
+	assert.NoError(t, err)
+
+	_, err = dial(ctx, "tcp", l.Addr().String())
+	assert.IsType(t, &cluster.OverflowError{}, err)
+
+	// the connection without cluster is not limited
+	plain, err := dial(context.Background(), "tcp", l.Addr().String())
+	assert.NoError(t, err)
+	plain.Close()
+
+	conn.Close()
+	conn, err = dial(ctx, "tcp", l.Addr().String())
+	assert.NoError(t, err)
+	conn.Close()
+}
+
+func TestGrpcConnections(t *testing.T) {
+	c := NewGrpcConnections()
+	now := time.Now()
+	tlsConfig := &tls.Config{}
+
+	plain, err := c.Get("127.0.0.1:8081", nil, now)
+	assert.NoError(t, err)
+	secure, err := c.Get("127.0.0.1:8081", tlsConfig, now)
+	assert.NoError(t, err)
+	assert.NotSame(t, plain, secure)
+	conn, _ := c.Get("127.0.0.1:8081", tlsConfig, now)
+	assert.Same(t, secure, conn)
+
+	// the connection still in use is kept, the idle one is closed
+	conn, _ = c.Get("127.0.0.1:8081", nil, now.Add(GrpcConnIdleTimeout-time.Minute))
+	assert.Same(t, plain, conn)
+	_, _ = c.Get("127.0.0.1:8082", nil, now.Add(GrpcConnIdleTimeout))
+	assert.Equal(t, 2, len(c.conns))
+	assert.Contains(t, c.conns, grpcConnKey{address: "127.0.0.1:8081"})
+	assert.NotContains(t, c.conns, grpcConnKey{address: "127.0.0.1:8081", tlsConfig: tlsConfig})
+	assert.Equal(t, "SHUTDOWN", secure.GetState().String())
+	assert.NotEqual(t, "SHUTDOWN", plain.GetState().String())
+}